@@ -0,0 +1,104 @@
+// Command devtoken issues JWTs signed with a locally generated key, for
+// trying out and testing the admin API without an SSO provider.
+//
+// The first run generates an ES256 key and writes it with the matching JWKS;
+// point JWT_JWKS at the JWKS file and set JWT_ISSUER and JWT_AUDIENCE to the
+// -iss and -aud values:
+//
+//	go run ./cmd/devtoken -sub alice -roles publisher:com.yapindo.launcher.pro
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"launcherbackend_api/pkg/jwt"
+)
+
+func main() {
+	keyPath := flag.String("key", "storage/devtoken-key.pem", "private key file, generated when missing")
+	jwksPath := flag.String("jwks", "storage/devtoken-jwks.json", "JWKS file written next to a generated key")
+	kid := flag.String("kid", "devtoken", "key ID")
+	issuer := flag.String("iss", "http://localhost/devtoken", "issuer claim")
+	audience := flag.String("aud", "launcherbackend_api", "audience claim")
+	subject := flag.String("sub", "developer", "subject claim")
+	roles := flag.String("roles", "admin", "comma separated roles claim, as role or role:app_id")
+	ttl := flag.Duration("ttl", time.Hour, "token lifetime")
+	flag.Parse()
+
+	key, err := loadOrGenerateKey(*keyPath, *jwksPath, *kid)
+	if err != nil {
+		log.Fatalf("Failed to prepare signing key: %v", err)
+	}
+
+	now := time.Now()
+	claims := jwt.Claims{
+		"iss": *issuer,
+		"aud": *audience,
+		"sub": *subject,
+		"iat": now.Unix(),
+		"exp": now.Add(*ttl).Unix(),
+	}
+	if *roles != "" {
+		claims["roles"] = strings.Split(*roles, ",")
+	}
+
+	token, err := jwt.Sign("ES256", *kid, key, claims)
+	if err != nil {
+		log.Fatalf("Failed to sign token: %v", err)
+	}
+	fmt.Println(token)
+}
+
+func loadOrGenerateKey(keyPath string, jwksPath string, kid string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s is not a PEM file", keyPath)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	jwks, err := jwt.MarshalKeySet(jwt.Key{ID: kid, Algorithm: "ES256", Public: &key.PublicKey})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range []string{keyPath, jwksPath} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(jwksPath, jwks, 0o644); err != nil {
+		return nil, err
+	}
+	log.Printf("Generated a signing key in %s and its JWKS in %s", keyPath, jwksPath)
+
+	return key, nil
+}
@@ -2,9 +2,12 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -18,8 +21,14 @@ import (
 	"launcherbackend_api/internal/config"
 	_ "launcherbackend_api/internal/delivery/http/docs" 
 	"launcherbackend_api/internal/delivery/http/handle"
+	"launcherbackend_api/internal/delivery/http/middleware"
 	"launcherbackend_api/internal/repository"
 	"launcherbackend_api/internal/usecase"
+	"launcherbackend_api/pkg/jwt"
+	"launcherbackend_api/pkg/manifest"
+	"launcherbackend_api/pkg/mtls"
+	"launcherbackend_api/pkg/urlcheck"
+	"launcherbackend_api/pkg/urlsign"
 )
 
 var Module = fx.Options(
@@ -32,10 +41,25 @@ var Module = fx.Options(
 		ProvideHandlers,
 	),
 	fx.Invoke(RegisterRoutes),
+	fx.Invoke(StartBackgroundJobs),
 )
 
 func NewFiberApp(cfg *config.Config) *fiber.App {
+	// Client IPs, which rate limits and audit entries rely on, are only taken
+	// from the proxy header of requests sent by a trusted proxy
+	var trustedProxies []string
+	for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
 	app := fiber.New(fiber.Config{
+		BodyLimit:               cfg.MaxUploadSizeMB * 1024 * 1024,
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			// Default error handler
 			code := fiber.StatusInternalServerError
@@ -50,6 +74,7 @@ func NewFiberApp(cfg *config.Config) *fiber.App {
 
 	// Configure middleware
 	app.Use(recover.New())
+	app.Use(middleware.RequestID())
 	app.Use(logger.New())
 	app.Use(cors.New())
 
@@ -71,27 +96,309 @@ func ProvideDatabaseConnection(cfg *config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
-func ProvideRepositories(db *sql.DB) *repository.Repositories {
+func ProvideRepositories(db *sql.DB, cfg *config.Config) *repository.Repositories {
 	return &repository.Repositories{
-		OTA: repository.NewPostgresOTARepository(db),
+		OTA:              repository.NewPostgresOTARepository(db),
+		OTAPatch:         repository.NewPostgresOTAPatchRepository(db),
+		SigningCert:      repository.NewPostgresSigningCertRepository(db),
+		DownloadStats:    repository.NewPostgresDownloadStatsRepository(db),
+		Retention:        repository.NewPostgresRetentionPolicyRepository(db),
+		DeviceInstall:    repository.NewPostgresDeviceInstallRepository(db),
+		ArtifactBlob:     repository.NewPostgresArtifactBlobRepository(db),
+		OTAArtifact:      repository.NewPostgresOTAArtifactRepository(db),
+		OTAMirror:        repository.NewPostgresOTAMirrorRepository(db),
+		ReleaseNote:      repository.NewPostgresReleaseNoteRepository(db),
+		APIKey:           repository.NewPostgresAPIKeyRepository(db),
+		RoleBinding:      repository.NewPostgresRoleBindingRepository(db),
+		EnrollmentToken:  repository.NewPostgresEnrollmentTokenRepository(db),
+		DeviceCredential: repository.NewPostgresDeviceCredentialRepository(db),
+		TUFMetadata:      repository.NewPostgresTUFMetadataRepository(db),
+		Audit:            repository.NewPostgresAuditRepository(db),
+		RateLimit:        repository.NewPostgresRateLimitRepository(db),
+		Transactor:       repository.NewPostgresTransactor(db),
+		Artifact:         repository.NewLocalArtifactStorage(cfg.StorageDir),
 	}
 }
 
-func ProvideUseCases(repos *repository.Repositories) *usecase.UseCases {
+func ProvideUseCases(repos *repository.Repositories, cfg *config.Config) (*usecase.UseCases, error) {
+	signer, err := provideDownloadSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tuf, err := provideTUFUseCase(cfg, repos)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit, err := provideRateLimitUseCase(cfg, repos)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := usecase.NewAuditUseCase(repos.Transactor, repos.Audit)
+	blobs := usecase.NewBlobStore(repos.Transactor, repos.ArtifactBlob, repos.Artifact)
+	signingCert := usecase.NewSigningCertUseCase(repos.SigningCert, audit)
+	downloads := usecase.NewDownloadURLs(cfg.PublicBaseURL)
+	delta := usecase.NewDeltaUseCase(repos.OTA, repos.OTAPatch, repos.Artifact, downloads, blobs, cfg.DeltaMaxBaseVersions)
+	artifact := usecase.NewArtifactUseCase(repos.OTA, repos.OTAArtifact, downloads, blobs, signingCert, tuf, audit)
+	urls := provideURLChecker(cfg)
+	mirror := usecase.NewMirrorUseCase(repos.OTA, repos.OTAMirror, urls, cfg.MirrorFailureThreshold, audit)
+	releaseNote, err := usecase.NewReleaseNoteUseCase(repos.OTA, repos.ReleaseNote, cfg.ReleaseNotesDefaultLocale, audit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RELEASE_NOTES_DEFAULT_LOCALE: %w", err)
+	}
+	tokens, err := provideJWTUseCase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.EnrollmentTokenMaxTTLHours <= 0 {
+		return nil, fmt.Errorf("ENROLLMENT_TOKEN_MAX_TTL_HOURS must be positive")
+	}
+	if cfg.APIBootstrapKey == "" {
+		log.Println("API_BOOTSTRAP_KEY is not set, only API keys created through the API are accepted")
+	}
+	manifests, err := provideManifestUseCase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if manifests != nil && !cfg.URLCheckEnabled {
+		log.Println("URL_CHECK_ENABLED is false, releases registered by URL need a sha256 for their manifests to be signed")
+	}
+	download := usecase.NewDownloadUseCase(repos.OTA, repos.OTAPatch, repos.OTAArtifact, repos.DownloadStats, repos.Artifact, signer, downloads, time.Duration(cfg.DownloadURLTTLMinutes)*time.Minute)
+
 	return &usecase.UseCases{
-		OTA: usecase.NewOTAUseCase(repos.OTA),
+		OTA:         usecase.NewOTAUseCase(repos.OTA, repos.OTAPatch, repos.OTAArtifact, downloads, blobs, signingCert, delta, urls, cfg.URLCheckComputeDigest || manifests != nil, tuf, audit),
+		SigningCert: signingCert,
+		Delta:       delta,
+		Update:      usecase.NewUpdateUseCase(repos.OTA, repos.DeviceInstall, delta, artifact, download, mirror, releaseNote, manifests, cfg.DeviceAuthRequired),
+		Download:    download,
+		Artifact:    artifact,
+		Retention:   usecase.NewRetentionUseCase(repos.OTA, repos.OTAPatch, repos.OTAArtifact, repos.Retention, blobs, cfg.RetentionDefaultKeepLast, time.Duration(cfg.RetentionInstallTTLDays)*24*time.Hour, tuf, audit),
+		Mirror:      mirror,
+		ReleaseNote: releaseNote,
+		APIKey:      usecase.NewAPIKeyUseCase(repos.APIKey, repos.RoleBinding, cfg.APIBootstrapKey, audit),
+		JWT:         tokens,
+		Manifest:    manifests,
+		TUF:         tuf,
+		Audit:       audit,
+		RateLimit:   rateLimit,
+		Device:      usecase.NewDeviceUseCase(repos.EnrollmentToken, repos.DeviceCredential, time.Duration(cfg.EnrollmentTokenMaxTTLHours)*time.Hour, audit),
+	}, nil
+}
+
+// provideURLChecker returns the checker for external release URLs, or nil
+// when URL validation is disabled.
+func provideURLChecker(cfg *config.Config) *urlcheck.Checker {
+	if !cfg.URLCheckEnabled {
+		log.Println("URL_CHECK_ENABLED is false, external release URLs are not validated")
+		return nil
+	}
+
+	maxSize := int64(cfg.MaxUploadSizeMB) * 1024 * 1024
+	return urlcheck.NewChecker(urlcheck.ParseHosts(cfg.URLCheckAllowedHosts), maxSize, time.Duration(cfg.URLCheckTimeoutSeconds)*time.Second)
+}
+
+// provideJWTUseCase returns the use case authenticating JWT bearer tokens,
+// or nil when no JWKS is configured.
+func provideJWTUseCase(cfg *config.Config) (*usecase.JWTUseCase, error) {
+	if cfg.JWTJWKS == "" {
+		return nil, nil
+	}
+	if cfg.JWTIssuer == "" || cfg.JWTAudience == "" {
+		return nil, fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE are required with JWT_JWKS")
+	}
+
+	mappings, err := usecase.ParseRoleMappings(cfg.JWTRoleMappings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_ROLE_MAPPINGS: %w", err)
+	}
+
+	keys := jwt.NewKeySource(cfg.JWTJWKS, time.Duration(cfg.JWTJWKSRefreshMinutes)*time.Minute, 30*time.Second)
+	if err := keys.Load(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid JWT_JWKS: %w", err)
+	}
+
+	verifier := jwt.NewVerifier(keys, jwt.Options{
+		Issuer:    cfg.JWTIssuer,
+		Audience:  cfg.JWTAudience,
+		ClockSkew: time.Duration(cfg.JWTClockSkewSeconds) * time.Second,
+	})
+	return usecase.NewJWTUseCase(verifier, cfg.JWTRolesClaim, mappings), nil
+}
+
+// provideTUFUseCase returns the use case publishing TUF metadata, or nil when
+// no TUF keys are configured.
+func provideTUFUseCase(cfg *config.Config, repos *repository.Repositories) (*usecase.TUFUseCase, error) {
+	files := map[string]string{
+		"TUF_ROOT_KEY_FILES":      cfg.TUFRootKeyFiles,
+		"TUF_TARGETS_KEY_FILES":   cfg.TUFTargetsKeyFiles,
+		"TUF_SNAPSHOT_KEY_FILES":  cfg.TUFSnapshotKeyFiles,
+		"TUF_TIMESTAMP_KEY_FILES": cfg.TUFTimestampKeyFiles,
+	}
+	keys := make(map[string][]manifest.Key, len(files))
+	for name, paths := range files {
+		loaded, err := manifest.LoadKeys(paths)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if len(loaded) > 0 {
+			keys[name] = loaded
+		}
+	}
+	if len(keys) == 0 {
+		log.Println("TUF key files are not set, TUF metadata is not published")
+		return nil, nil
+	}
+	for name := range files {
+		if len(keys[name]) == 0 {
+			return nil, fmt.Errorf("%s is required with the other TUF key files", name)
+		}
+	}
+	if cfg.TUFTimestampExpiryMinutes <= 0 || cfg.TUFRefreshIntervalMinutes <= 0 {
+		return nil, fmt.Errorf("TUF_TIMESTAMP_EXPIRY_MINUTES and TUF_REFRESH_INTERVAL_MINUTES must be positive")
+	}
+	if 2*cfg.TUFRefreshIntervalMinutes >= cfg.TUFTimestampExpiryMinutes {
+		return nil, fmt.Errorf("TUF_REFRESH_INTERVAL_MINUTES must be under half of TUF_TIMESTAMP_EXPIRY_MINUTES")
+	}
+
+	return usecase.NewTUFUseCase(repos.Transactor, repos.OTA, repos.OTAArtifact, repos.TUFMetadata, usecase.TUFKeys{
+		Root:      keys["TUF_ROOT_KEY_FILES"],
+		Targets:   keys["TUF_TARGETS_KEY_FILES"],
+		Snapshot:  keys["TUF_SNAPSHOT_KEY_FILES"],
+		Timestamp: keys["TUF_TIMESTAMP_KEY_FILES"],
+	}, time.Duration(cfg.TUFTimestampExpiryMinutes)*time.Minute), nil
+}
+
+// provideRateLimitUseCase returns the use case rate limiting callers, or nil
+// when rate limiting is disabled.
+func provideRateLimitUseCase(cfg *config.Config, repos *repository.Repositories) (*usecase.RateLimitUseCase, error) {
+	if !cfg.RateLimitEnabled {
+		log.Println("RATE_LIMIT_ENABLED is false, requests are not rate limited")
+		return nil, nil
+	}
+	limits := map[string]usecase.RateLimit{
+		usecase.RateLimitTierIP:     {PerMinute: cfg.RateLimitIPPerMinute, Burst: cfg.RateLimitIPBurst},
+		usecase.RateLimitTierDevice: {PerMinute: cfg.RateLimitDevicePerMinute, Burst: cfg.RateLimitDeviceBurst},
+		usecase.RateLimitTierAdmin:  {PerMinute: cfg.RateLimitAdminPerMinute, Burst: cfg.RateLimitAdminBurst},
+	}
+	for tier, limit := range limits {
+		if limit.PerMinute < 0 {
+			return nil, fmt.Errorf("RATE_LIMIT_%s_PER_MINUTE must not be negative", strings.ToUpper(tier))
+		}
+		if limit.PerMinute > 0 && limit.Burst <= 0 {
+			return nil, fmt.Errorf("RATE_LIMIT_%s_BURST must be positive when the tier is enabled", strings.ToUpper(tier))
+		}
 	}
+	if cfg.RateLimitCleanupIntervalMinutes <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_CLEANUP_INTERVAL_MINUTES must be positive")
+	}
+
+	return usecase.NewRateLimitUseCase(repos.RateLimit, limits), nil
+}
+
+// provideManifestUseCase returns the use case signing update manifests, or
+// nil when no manifest signing keys are configured.
+func provideManifestUseCase(cfg *config.Config) (*usecase.ManifestUseCase, error) {
+	keys, err := manifest.LoadKeys(cfg.ManifestSigningKeyFiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MANIFEST_SIGNING_KEY_FILES: %w", err)
+	}
+	if len(keys) == 0 {
+		log.Println("MANIFEST_SIGNING_KEY_FILES is not set, update manifests are not signed")
+		return nil, nil
+	}
+	if cfg.ManifestTTLMinutes <= 0 {
+		return nil, fmt.Errorf("MANIFEST_TTL_MINUTES must be positive")
+	}
+
+	signer, err := manifest.NewSigner(keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MANIFEST_SIGNING_KEY_FILES: %w", err)
+	}
+	return usecase.NewManifestUseCase(signer, time.Duration(cfg.ManifestTTLMinutes)*time.Minute), nil
+}
+
+// provideDownloadSigner returns the signer for download URLs, or nil when no
+// signing keys are configured.
+func provideDownloadSigner(cfg *config.Config) (*urlsign.Signer, error) {
+	keys, err := urlsign.ParseKeys(cfg.DownloadSigningKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOWNLOAD_SIGNING_KEYS: %w", err)
+	}
+	if len(keys) == 0 {
+		log.Println("DOWNLOAD_SIGNING_KEYS is not set, download URLs are not signed")
+		return nil, nil
+	}
+	if cfg.DownloadURLTTLMinutes <= 0 {
+		return nil, fmt.Errorf("DOWNLOAD_URL_TTL_MINUTES must be positive")
+	}
+
+	active := cfg.DownloadSigningActiveKey
+	if active == "" {
+		active = keys[0].ID
+	}
+
+	signer, err := urlsign.NewSigner(keys, active)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download signing configuration: %w", err)
+	}
+	return signer, nil
 }
 
 func ProvideHandlers(useCases *usecase.UseCases) *handle.Handlers {
 	return &handle.Handlers{
-		OTA: handle.NewOTAHandler(useCases.OTA),
+		OTA:         handle.NewOTAHandler(useCases.OTA, useCases.ReleaseNote),
+		SigningCert: handle.NewSigningCertHandler(useCases.SigningCert),
+		Update:      handle.NewUpdateHandler(useCases.Update, useCases.Manifest),
+		Download:    handle.NewDownloadHandler(useCases.Download),
+		Retention:   handle.NewRetentionHandler(useCases.Retention),
+		Artifact:    handle.NewArtifactHandler(useCases.Artifact),
+		Mirror:      handle.NewMirrorHandler(useCases.Mirror),
+		ReleaseNote: handle.NewReleaseNoteHandler(useCases.ReleaseNote),
+		APIKey:      handle.NewAPIKeyHandler(useCases.APIKey),
+		Device:      handle.NewDeviceHandler(useCases.Device),
+		TUF:         handle.NewTUFHandler(useCases.TUF),
+		Audit:       handle.NewAuditHandler(useCases.Audit),
 	}
 }
 
-func RegisterRoutes(app *fiber.App, handlers *handle.Handlers) {
+func RegisterRoutes(app *fiber.App, cfg *config.Config, useCases *usecase.UseCases, handlers *handle.Handlers) {
 	api := app.Group("/api/v1")
+	// Limiting by IP first keeps failed authentication attempts from reaching the database unchecked
+	if useCases.RateLimit != nil {
+		api.Use(middleware.RateLimitIP(useCases.RateLimit))
+	}
+	api.Use(middleware.Auth(middleware.AuthConfig{
+		Keys:               useCases.APIKey,
+		Tokens:             useCases.JWT,
+		ProtectReads:       cfg.APIKeyProtectReads,
+		ProtectDeviceReads: cfg.APIKeyProtectDeviceReads,
+		IsDeviceRead:       isDeviceRead,
+		AlwaysProtected:    []string{"/api/v1/api-keys", "/api/v1/devices", "/api/v1/enrollment-tokens", "/api/v1/audit"},
+		Public:             []string{"/api/v1/devices/enroll", "/api/v1/manifest-keys"},
+		Devices:            useCases.Device,
+		ClientCertField:    clientCertField(cfg),
+	}))
+	// Rate limits follow Auth, which identifies the caller
+	if useCases.RateLimit != nil {
+		api.Use(middleware.RateLimit(middleware.RateLimitConfig{
+			Limiter:      useCases.RateLimit,
+			IsDeviceRead: isDeviceRead,
+		}))
+	}
+	handlers.Update.RegisterRoutes(api)
+	handlers.Download.RegisterRoutes(api)
 	handlers.OTA.RegisterRoutes(api)
+	handlers.SigningCert.RegisterRoutes(api)
+	handlers.Retention.RegisterRoutes(api)
+	handlers.Artifact.RegisterRoutes(api)
+	handlers.Mirror.RegisterRoutes(api)
+	handlers.ReleaseNote.RegisterRoutes(api)
+	handlers.APIKey.RegisterRoutes(api)
+	handlers.Device.RegisterRoutes(api)
+	handlers.TUF.RegisterRoutes(api)
+	handlers.Audit.RegisterRoutes(api)
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -103,18 +410,107 @@ func RegisterRoutes(app *fiber.App, handlers *handle.Handlers) {
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 }
 
+// clientCertField returns the client certificate field holding device IDs,
+// or "" when client certificates are not verified.
+func clientCertField(cfg *config.Config) string {
+	if cfg.TLSCertFile == "" || cfg.TLSClientCAFile == "" {
+		return ""
+	}
+	return cfg.TLSClientDeviceIDField
+}
+
+// provideTLSConfig returns the TLS configuration of the server, or nil when
+// it serves plain HTTP.
+func provideTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		if cfg.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil
+	}
+	if cfg.TLSClientCAFile != "" && !mtls.IsValidField(cfg.TLSClientDeviceIDField) {
+		return nil, fmt.Errorf("invalid TLS_CLIENT_DEVICE_ID_FIELD %q", cfg.TLSClientDeviceIDField)
+	}
+
+	tlsConfig, err := mtls.ServerConfig(mtls.Options{
+		CertFile:          cfg.TLSCertFile,
+		KeyFile:           cfg.TLSKeyFile,
+		ClientCAFile:      cfg.TLSClientCAFile,
+		ClientCRLFile:     cfg.TLSClientCRLFile,
+		RequireClientCert: cfg.TLSRequireClientCert,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	return tlsConfig, nil
+}
+
+// isDeviceRead reports whether a path is read by devices rather than admins:
+// update checks, TUF metadata and the downloads of releases, patches and
+// artifacts.
+func isDeviceRead(path string) bool {
+	return path == "/api/v1/otas/check" || strings.HasPrefix(path, "/api/v1/tuf/") || (strings.HasPrefix(path, "/api/v1/otas/") && strings.HasSuffix(path, "/download"))
+}
+
+// StartBackgroundJobs runs the workers that process releases outside of requests.
+func StartBackgroundJobs(useCases *usecase.UseCases, cfg *config.Config, lc fx.Lifecycle) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go useCases.Delta.Run(ctx)
+			if cfg.RetentionIntervalMinutes > 0 {
+				go useCases.Retention.Run(ctx, time.Duration(cfg.RetentionIntervalMinutes)*time.Minute)
+			}
+			if cfg.URLCheckEnabled && cfg.MirrorProbeIntervalMinutes > 0 {
+				go useCases.Mirror.Run(ctx, time.Duration(cfg.MirrorProbeIntervalMinutes)*time.Minute)
+			}
+			if useCases.TUF != nil {
+				go useCases.TUF.Run(ctx, time.Duration(cfg.TUFRefreshIntervalMinutes)*time.Minute)
+			}
+			if useCases.RateLimit != nil {
+				go useCases.RateLimit.Run(ctx, time.Duration(cfg.RateLimitCleanupIntervalMinutes)*time.Minute)
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
 func StartApp() {
 	fx.New(
 		Module,
-		fx.Invoke(func(app *fiber.App, cfg *config.Config, lc fx.Lifecycle) {
+		fx.Invoke(func(app *fiber.App, cfg *config.Config, lc fx.Lifecycle) error {
 			addr := fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort)
+			tlsConfig, err := provideTLSConfig(cfg)
+			if err != nil {
+				return err
+			}
 			
 			lc.Append(fx.Hook{
 				OnStart: func(ctx context.Context) error {
-					log.Printf("Starting server on %s in %s mode", addr, cfg.Environment)
-					log.Printf("Swagger documentation available at http://%s/swagger/index.html", addr)
+					if tlsConfig == nil {
+						log.Printf("Starting server on %s in %s mode", addr, cfg.Environment)
+						log.Printf("Swagger documentation available at http://%s/swagger/index.html", addr)
+						go func() {
+							if err := app.Listen(addr); err != nil {
+								log.Fatalf("Failed to start server: %v", err)
+							}
+						}()
+						return nil
+					}
+
+					ln, err := tls.Listen("tcp", addr, tlsConfig)
+					if err != nil {
+						return fmt.Errorf("failed to listen on %s: %w", addr, err)
+					}
+					log.Printf("Starting TLS server on %s in %s mode", addr, cfg.Environment)
+					log.Printf("Swagger documentation available at https://%s/swagger/index.html", addr)
 					go func() {
-						if err := app.Listen(addr); err != nil {
+						if err := app.Listener(ln); err != nil {
 							log.Fatalf("Failed to start server: %v", err)
 						}
 					}()
@@ -125,6 +521,7 @@ func StartApp() {
 					return app.Shutdown()
 				},
 			})
+			return nil
 		}),
 	).Run()
 } 
\ No newline at end of file
@@ -69,10 +69,18 @@ func UnauthorizedResponse(c *fiber.Ctx, message string) error {
 	return ErrorResponse(c, fiber.StatusUnauthorized, message)
 }
 
+func ForbiddenResponse(c *fiber.Ctx, message string) error {
+	return ErrorResponse(c, fiber.StatusForbidden, message)
+}
+
 func InternalServerErrorResponse(c *fiber.Ctx, message string) error {
 	return ErrorResponse(c, fiber.StatusInternalServerError, message)
 }
 
+func TooManyRequestsResponse(c *fiber.Ctx, message string) error {
+	return ErrorResponse(c, fiber.StatusTooManyRequests, message)
+}
+
 func ValidationErrorResponse(c *fiber.Ctx, message string) error {
 	return BadRequestResponse(c, message)
 }
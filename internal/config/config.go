@@ -11,9 +11,11 @@ import (
 // Config holds all configuration for the application
 type Config struct {
 	// Server configuration
-	ServerPort  string
-	ServerHost  string
-	Environment string
+	ServerPort     string
+	ServerHost     string
+	Environment    string
+	ProxyHeader    string
+	TrustedProxies string
 
 	// Database configuration
 	DBHost     string
@@ -22,6 +24,85 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	DBSSLMode  string
+
+	// Artifact storage configuration
+	StorageDir      string
+	PublicBaseURL   string
+	MaxUploadSizeMB int
+
+	// Delta update configuration
+	DeltaMaxBaseVersions int
+
+	// Signed download URL configuration
+	DownloadSigningKeys      string
+	DownloadSigningActiveKey string
+	DownloadURLTTLMinutes    int
+
+	// Artifact retention configuration
+	RetentionDefaultKeepLast int
+	RetentionInstallTTLDays  int
+	RetentionIntervalMinutes int
+
+	// External release URL validation configuration
+	URLCheckEnabled        bool
+	URLCheckAllowedHosts   string
+	URLCheckTimeoutSeconds int
+	URLCheckComputeDigest  bool
+
+	// Mirror health check configuration
+	MirrorProbeIntervalMinutes int
+	MirrorFailureThreshold     int
+
+	// Release notes configuration
+	ReleaseNotesDefaultLocale string
+
+	// API key authentication configuration
+	APIBootstrapKey          string
+	APIKeyProtectReads       bool
+	APIKeyProtectDeviceReads bool
+
+	// JWT bearer token authentication configuration
+	JWTJWKS               string
+	JWTIssuer             string
+	JWTAudience           string
+	JWTClockSkewSeconds   int
+	JWTJWKSRefreshMinutes int
+	JWTRolesClaim         string
+	JWTRoleMappings       string
+
+	// Device authentication configuration
+	DeviceAuthRequired         bool
+	EnrollmentTokenMaxTTLHours int
+
+	// TLS and client certificate configuration
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSClientCAFile        string
+	TLSClientCRLFile       string
+	TLSRequireClientCert   bool
+	TLSClientDeviceIDField string
+
+	// Update manifest signing configuration
+	ManifestSigningKeyFiles string
+	ManifestTTLMinutes      int
+
+	// TUF metadata configuration
+	TUFRootKeyFiles           string
+	TUFTargetsKeyFiles        string
+	TUFSnapshotKeyFiles       string
+	TUFTimestampKeyFiles      string
+	TUFTimestampExpiryMinutes int
+	TUFRefreshIntervalMinutes int
+
+	// Rate limiting configuration
+	RateLimitEnabled                bool
+	RateLimitIPPerMinute            int
+	RateLimitIPBurst                int
+	RateLimitDevicePerMinute        int
+	RateLimitDeviceBurst            int
+	RateLimitAdminPerMinute         int
+	RateLimitAdminBurst             int
+	RateLimitCleanupIntervalMinutes int
 }
 
 func (c *Config) DBConnectionString() string {
@@ -62,9 +143,11 @@ func LoadConfig() (*Config, error) {
 
 	config := &Config{
 		// Server config
-		ServerPort:  getEnv("SERVER_PORT", "8080"),
-		ServerHost:  getEnv("SERVER_HOST", "0.0.0.0"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		ServerPort:     getEnv("SERVER_PORT", "8080"),
+		ServerHost:     getEnv("SERVER_HOST", "0.0.0.0"),
+		Environment:    getEnv("ENVIRONMENT", "development"),
+		ProxyHeader:    getEnv("PROXY_HEADER", ""),
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
 
 		// Database config
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -73,7 +156,86 @@ func LoadConfig() (*Config, error) {
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "yapindolauncher"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+		// Artifact storage config
+		StorageDir:      getEnv("STORAGE_DIR", "./storage"),
+		PublicBaseURL:   getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		MaxUploadSizeMB: getEnvAsInt("MAX_UPLOAD_SIZE_MB", 200),
+
+		// Delta update config
+		DeltaMaxBaseVersions: getEnvAsInt("DELTA_MAX_BASE_VERSIONS", 3),
+
+		// Signed download URL config
+		DownloadSigningKeys:      getEnv("DOWNLOAD_SIGNING_KEYS", ""),
+		DownloadSigningActiveKey: getEnv("DOWNLOAD_SIGNING_ACTIVE_KEY", ""),
+		DownloadURLTTLMinutes:    getEnvAsInt("DOWNLOAD_URL_TTL_MINUTES", 60),
+
+		// Artifact retention config
+		RetentionDefaultKeepLast: getEnvAsInt("RETENTION_DEFAULT_KEEP_LAST", 0),
+		RetentionInstallTTLDays:  getEnvAsInt("RETENTION_INSTALL_TTL_DAYS", 90),
+		RetentionIntervalMinutes: getEnvAsInt("RETENTION_INTERVAL_MINUTES", 60),
+
+		// External release URL validation config
+		URLCheckEnabled:        getEnvAsBool("URL_CHECK_ENABLED", true),
+		URLCheckAllowedHosts:   getEnv("URL_CHECK_ALLOWED_HOSTS", ""),
+		URLCheckTimeoutSeconds: getEnvAsInt("URL_CHECK_TIMEOUT_SECONDS", 30),
+		URLCheckComputeDigest:  getEnvAsBool("URL_CHECK_COMPUTE_DIGEST", false),
+
+		// Mirror health check config
+		MirrorProbeIntervalMinutes: getEnvAsInt("MIRROR_PROBE_INTERVAL_MINUTES", 5),
+		MirrorFailureThreshold:     getEnvAsInt("MIRROR_FAILURE_THRESHOLD", 2),
+
+		// Release notes config
+		ReleaseNotesDefaultLocale: getEnv("RELEASE_NOTES_DEFAULT_LOCALE", "en"),
+
+		// API key authentication config
+		APIBootstrapKey:          getEnv("API_BOOTSTRAP_KEY", ""),
+		APIKeyProtectReads:       getEnvAsBool("API_KEY_PROTECT_READS", false),
+		APIKeyProtectDeviceReads: getEnvAsBool("API_KEY_PROTECT_DEVICE_READS", false),
+
+		// JWT bearer token authentication config
+		JWTJWKS:               getEnv("JWT_JWKS", ""),
+		JWTIssuer:             getEnv("JWT_ISSUER", ""),
+		JWTAudience:           getEnv("JWT_AUDIENCE", ""),
+		JWTClockSkewSeconds:   getEnvAsInt("JWT_CLOCK_SKEW_SECONDS", 60),
+		JWTJWKSRefreshMinutes: getEnvAsInt("JWT_JWKS_REFRESH_MINUTES", 60),
+		JWTRolesClaim:         getEnv("JWT_ROLES_CLAIM", "roles"),
+		JWTRoleMappings:       getEnv("JWT_ROLE_MAPPINGS", ""),
+
+		// Device authentication config
+		DeviceAuthRequired:         getEnvAsBool("DEVICE_AUTH_REQUIRED", false),
+		EnrollmentTokenMaxTTLHours: getEnvAsInt("ENROLLMENT_TOKEN_MAX_TTL_HOURS", 24),
+
+		// TLS and client certificate config
+		TLSCertFile:            getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:             getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile:        getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientCRLFile:       getEnv("TLS_CLIENT_CRL_FILE", ""),
+		TLSRequireClientCert:   getEnvAsBool("TLS_REQUIRE_CLIENT_CERT", false),
+		TLSClientDeviceIDField: getEnv("TLS_CLIENT_DEVICE_ID_FIELD", "cn"),
+
+		// Update manifest signing config
+		ManifestSigningKeyFiles: getEnv("MANIFEST_SIGNING_KEY_FILES", ""),
+		ManifestTTLMinutes:      getEnvAsInt("MANIFEST_TTL_MINUTES", 1440),
+
+		// TUF metadata config
+		TUFRootKeyFiles:           getEnv("TUF_ROOT_KEY_FILES", ""),
+		TUFTargetsKeyFiles:        getEnv("TUF_TARGETS_KEY_FILES", ""),
+		TUFSnapshotKeyFiles:       getEnv("TUF_SNAPSHOT_KEY_FILES", ""),
+		TUFTimestampKeyFiles:      getEnv("TUF_TIMESTAMP_KEY_FILES", ""),
+		TUFTimestampExpiryMinutes: getEnvAsInt("TUF_TIMESTAMP_EXPIRY_MINUTES", 60),
+		TUFRefreshIntervalMinutes: getEnvAsInt("TUF_REFRESH_INTERVAL_MINUTES", 10),
+
+		// Rate limiting config
+		RateLimitEnabled:                getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		RateLimitIPPerMinute:            getEnvAsInt("RATE_LIMIT_IP_PER_MINUTE", 1200),
+		RateLimitIPBurst:                getEnvAsInt("RATE_LIMIT_IP_BURST", 300),
+		RateLimitDevicePerMinute:        getEnvAsInt("RATE_LIMIT_DEVICE_PER_MINUTE", 60),
+		RateLimitDeviceBurst:            getEnvAsInt("RATE_LIMIT_DEVICE_BURST", 60),
+		RateLimitAdminPerMinute:         getEnvAsInt("RATE_LIMIT_ADMIN_PER_MINUTE", 600),
+		RateLimitAdminBurst:             getEnvAsInt("RATE_LIMIT_ADMIN_BURST", 200),
+		RateLimitCleanupIntervalMinutes: getEnvAsInt("RATE_LIMIT_CLEANUP_INTERVAL_MINUTES", 10),
 	}
 
 	return config, nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,127 @@
+package handle
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+)
+
+type APIKeyCreateRequest struct {
+	Name string `json:"name" validate:"required" example:"release-pipeline"`
+}
+
+type RoleGrantRequest struct {
+	Role  string `json:"role" validate:"required" example:"publisher"`
+	AppID string `json:"app_id,omitempty" example:"com.yapindo.launcher.pro"`
+}
+
+type APIKeyHandler struct {
+	apiKeyUseCase *usecase.APIKeyUseCase
+}
+
+func NewAPIKeyHandler(apiKeyUseCase *usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUseCase: apiKeyUseCase,
+	}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(router fiber.Router) {
+	keyRouter := router.Group("/api-keys")
+
+	keyRouter.Post("/", h.CreateKey)
+	keyRouter.Get("/", h.ListKeys)
+	keyRouter.Delete("/:id", h.RevokeKey)
+	keyRouter.Get("/:id/roles", h.ListRoles)
+	keyRouter.Post("/:id/roles", h.GrantRole)
+	keyRouter.Delete("/:id/roles/:binding_id", h.RevokeRole)
+}
+
+// CreateKey issues an API key. Its token is only part of this response.
+func (h *APIKeyHandler) CreateKey(c *fiber.Ctx) error {
+	var req APIKeyCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	key, err := h.apiKeyUseCase.CreateKey(c.UserContext(), req.Name)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to create API key: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "API key created successfully", key)
+}
+
+func (h *APIKeyHandler) ListKeys(c *fiber.Ctx) error {
+	keys, err := h.apiKeyUseCase.ListKeys(c.UserContext())
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get API keys: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "API keys retrieved successfully", keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(c *fiber.Ctx) error {
+	key, err := h.apiKeyUseCase.RevokeKey(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to revoke API key: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "API key revoked successfully", key)
+}
+
+func (h *APIKeyHandler) ListRoles(c *fiber.Ctx) error {
+	bindings, err := h.apiKeyUseCase.ListRoles(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get roles: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Roles retrieved successfully", bindings)
+}
+
+// GrantRole grants the API key a role for one app, or for every app when no
+// app ID is given.
+func (h *APIKeyHandler) GrantRole(c *fiber.Ctx) error {
+	var req RoleGrantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	binding, err := h.apiKeyUseCase.GrantRole(c.UserContext(), c.Params("id"), req.Role, req.AppID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidRole) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to grant role: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "Role granted successfully", binding)
+}
+
+func (h *APIKeyHandler) RevokeRole(c *fiber.Ctx) error {
+	if err := h.apiKeyUseCase.RevokeRole(c.UserContext(), c.Params("id"), c.Params("binding_id")); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to revoke role: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Role revoked successfully", nil)
+}
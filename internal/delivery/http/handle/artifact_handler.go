@@ -0,0 +1,93 @@
+package handle
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/usecase"
+	"launcherbackend_api/pkg/apk"
+)
+
+type ArtifactHandler struct {
+	artifactUseCase *usecase.ArtifactUseCase
+}
+
+func NewArtifactHandler(artifactUseCase *usecase.ArtifactUseCase) *ArtifactHandler {
+	return &ArtifactHandler{
+		artifactUseCase: artifactUseCase,
+	}
+}
+
+func (h *ArtifactHandler) RegisterRoutes(router fiber.Router) {
+	artifactRouter := router.Group("/otas/:id/artifacts")
+
+	artifactRouter.Get("/", h.ListArtifacts)
+	artifactRouter.Post("/", h.UploadArtifact)
+	artifactRouter.Delete("/:artifact_id", h.DeleteArtifact)
+}
+
+func (h *ArtifactHandler) ListArtifacts(c *fiber.Ctx) error {
+	artifacts, err := h.artifactUseCase.ListArtifacts(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get OTA artifacts: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA artifacts retrieved successfully", artifacts)
+}
+
+// UploadArtifact adds an APK to a release from the multipart field "apk".
+// Optional form values split_name, abi and density describe which devices it
+// is meant for; split name and ABI are read from the APK when omitted.
+func (h *ArtifactHandler) UploadArtifact(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("apk")
+	if err != nil {
+		return response.BadRequestResponse(c, "APK file is required")
+	}
+
+	density, err := apk.ParseDensity(c.FormValue("density"))
+	if err != nil {
+		return response.BadRequestResponse(c, "Invalid density")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.BadRequestResponse(c, "Failed to read APK file")
+	}
+	defer file.Close()
+
+	artifact := entity.OTAArtifact{
+		SplitName: c.FormValue("split_name"),
+		ABI:       c.FormValue("abi"),
+		Density:   density,
+	}
+
+	created, err := h.artifactUseCase.UploadArtifact(c.UserContext(), c.Params("id"), artifact, file, fileHeader.Size)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidArtifact) || errors.Is(err, usecase.ErrManifestMismatch) || errors.Is(err, usecase.ErrSigningCertMismatch) || errors.Is(err, usecase.ErrUnsupportedPayloadType) || errors.Is(err, usecase.ErrUnreviewedPermissions) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to upload OTA artifact: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "OTA artifact uploaded successfully", created)
+}
+
+func (h *ArtifactHandler) DeleteArtifact(c *fiber.Ctx) error {
+	if err := h.artifactUseCase.DeleteArtifact(c.UserContext(), c.Params("id"), c.Params("artifact_id")); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to delete OTA artifact: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA artifact deleted successfully", nil)
+}
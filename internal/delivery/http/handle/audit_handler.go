@@ -0,0 +1,77 @@
+package handle
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/usecase"
+)
+
+// maxAuditPageSize bounds how many audit entries one page holds.
+const maxAuditPageSize = 100
+
+type AuditHandler struct {
+	auditUseCase *usecase.AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase *usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+func (h *AuditHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/audit", h.ListEntries)
+}
+
+// ListEntries lists audit entries, newest first. They can be filtered by
+// app_id, resource_type, resource_id, action, actor_id and request_id, and
+// by an RFC 3339 time range with since (inclusive) and until (exclusive).
+func (h *AuditHandler) ListEntries(c *fiber.Ctx) error {
+	filter := entity.AuditFilter{
+		AppID:        c.Query("app_id"),
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		Action:       c.Query("action"),
+		ActorID:      c.Query("actor_id"),
+		RequestID:    c.Query("request_id"),
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return response.ValidationErrorResponse(c, name+" must be an RFC 3339 time")
+		}
+		*t = parsed
+	}
+
+	cursor := c.Query("cursor", "")
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+
+	entries, nextCursor, total, err := h.auditUseCase.ListEntries(c.UserContext(), filter, cursor, limit)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			return response.BadRequestResponse(c, err.Error())
+		}
+		return response.InternalServerErrorResponse(c, "Failed to get audit entries: "+err.Error())
+	}
+
+	return response.PaginatedResponse(c, "Audit entries retrieved successfully", entries, nextCursor != "", cursor != "", nextCursor, cursor, total, len(entries))
+}
@@ -0,0 +1,108 @@
+package handle
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+)
+
+type EnrollmentTokenCreateRequest struct {
+	AppID          string `json:"app_id,omitempty" example:"com.yapindo.launcher.pro"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty" example:"24"`
+}
+
+type DeviceEnrollRequest struct {
+	EnrollmentToken string `json:"enrollment_token" validate:"required" example:"lbe_3f9a1c2b_..."`
+	DeviceID        string `json:"device_id" validate:"required" example:"device-123"`
+}
+
+type DeviceHandler struct {
+	deviceUseCase *usecase.DeviceUseCase
+}
+
+func NewDeviceHandler(deviceUseCase *usecase.DeviceUseCase) *DeviceHandler {
+	return &DeviceHandler{
+		deviceUseCase: deviceUseCase,
+	}
+}
+
+func (h *DeviceHandler) RegisterRoutes(router fiber.Router) {
+	router.Post("/enrollment-tokens", h.CreateEnrollmentToken)
+
+	deviceRouter := router.Group("/devices")
+
+	deviceRouter.Post("/enroll", h.Enroll)
+	deviceRouter.Get("/", h.ListDevices)
+	deviceRouter.Delete("/:id", h.RevokeDevice)
+}
+
+// CreateEnrollmentToken issues a one-time enrollment token. Its token is only
+// part of this response.
+func (h *DeviceHandler) CreateEnrollmentToken(c *fiber.Ctx) error {
+	var req EnrollmentTokenCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	token, err := h.deviceUseCase.CreateEnrollmentToken(c.UserContext(), req.AppID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to create enrollment token: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "Enrollment token created successfully", token)
+}
+
+// Enroll exchanges an enrollment token for the device's credential, which the
+// device sends in the X-Device-Token header from then on. The credential is
+// only part of this response.
+func (h *DeviceHandler) Enroll(c *fiber.Ctx) error {
+	var req DeviceEnrollRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	device, err := h.deviceUseCase.Enroll(c.UserContext(), req.EnrollmentToken, req.DeviceID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidEnrollmentToken) {
+			return response.UnauthorizedResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrDeviceIDRequired) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to enroll device: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "Device enrolled successfully", device)
+}
+
+// ListDevices lists device credentials, optionally of one app.
+func (h *DeviceHandler) ListDevices(c *fiber.Ctx) error {
+	devices, err := h.deviceUseCase.ListDevices(c.UserContext(), c.Query("app_id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get devices: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Devices retrieved successfully", devices)
+}
+
+func (h *DeviceHandler) RevokeDevice(c *fiber.Ctx) error {
+	device, err := h.deviceUseCase.RevokeDevice(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to revoke device: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Device revoked successfully", device)
+}
@@ -0,0 +1,351 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/internal/usecase"
+	"launcherbackend_api/pkg/httprange"
+)
+
+const (
+	apkContentType   = "application/vnd.android.package-archive"
+	patchContentType = "application/octet-stream"
+)
+
+type DownloadHandler struct {
+	downloadUseCase *usecase.DownloadUseCase
+}
+
+func NewDownloadHandler(downloadUseCase *usecase.DownloadUseCase) *DownloadHandler {
+	return &DownloadHandler{
+		downloadUseCase: downloadUseCase,
+	}
+}
+
+func (h *DownloadHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/otas/:id/download", h.Download)
+	router.Get("/otas/:id/download-stats", h.GetStats)
+	router.Get("/otas/:id/patches/:patch_id/download", h.DownloadPatch)
+	router.Get("/otas/:id/artifacts/:artifact_id/download", h.DownloadArtifact)
+}
+
+// Download streams a release artifact with support for resumable Range requests.
+func (h *DownloadHandler) Download(c *fiber.Ctx) error {
+	if err := h.downloadUseCase.AuthorizeOTA(c.UserContext(), c.Params("id"), queryValues(c)); err != nil {
+		return response.ForbiddenResponse(c, err.Error())
+	}
+
+	ota, obj, err := h.downloadUseCase.OpenArtifact(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "OTA not found")
+	}
+	if obj == nil {
+		return c.Redirect(ota.URL, fiber.StatusFound)
+	}
+
+	etag := fmt.Sprintf(`"%x-%x"`, obj.Size, obj.ModTime.UnixNano())
+	if ota.SHA256 != "" {
+		etag = `"` + ota.SHA256 + `"`
+	}
+
+	contentType, extension := payloadContentType(ota.PayloadType)
+	filename := fmt.Sprintf("%s-%d.%s", ota.AppID, ota.VersionCode, extension)
+
+	otaID := ota.ID
+	return serveArtifact(c, obj, etag, filename, contentType, func(served int64, completed bool) {
+		h.downloadUseCase.RecordDownload(otaID, served, completed)
+	})
+}
+
+// DownloadArtifact streams one of the additional artifacts of a release.
+func (h *DownloadHandler) DownloadArtifact(c *fiber.Ctx) error {
+	if err := h.downloadUseCase.AuthorizeArtifact(c.UserContext(), c.Params("artifact_id"), queryValues(c)); err != nil {
+		return response.ForbiddenResponse(c, err.Error())
+	}
+
+	artifact, obj, err := h.downloadUseCase.OpenOTAArtifact(c.UserContext(), c.Params("id"), c.Params("artifact_id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "OTA artifact not found")
+	}
+
+	otaID := artifact.OTAID
+	return serveArtifact(c, obj, `"`+artifact.SHA256+`"`, artifactFilename(artifact), apkContentType, func(served int64, completed bool) {
+		h.downloadUseCase.RecordDownload(otaID, served, completed)
+	})
+}
+
+// DownloadPatch streams a binary patch to a release.
+func (h *DownloadHandler) DownloadPatch(c *fiber.Ctx) error {
+	if err := h.downloadUseCase.AuthorizePatch(c.UserContext(), c.Params("patch_id"), queryValues(c)); err != nil {
+		return response.ForbiddenResponse(c, err.Error())
+	}
+
+	patch, obj, err := h.downloadUseCase.OpenPatch(c.UserContext(), c.Params("id"), c.Params("patch_id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "OTA patch not found")
+	}
+
+	otaID := patch.OTAID
+	return serveArtifact(c, obj, `"`+patch.SHA256+`"`, fmt.Sprintf("%d-%s.patch", patch.FromVersionCode, patch.ToSHA256[:12]), patchContentType, func(served int64, completed bool) {
+		h.downloadUseCase.RecordDownload(otaID, served, completed)
+	})
+}
+
+// serveArtifact writes obj as the response body, honouring Range and If-Range.
+// onDone, if set, is called with the number of bytes sent once the body is
+// closed and whether the client received the artifact up to its last byte.
+func serveArtifact(c *fiber.Ctx, obj *repository.ArtifactObject, etag string, filename string, contentType string, onDone func(served int64, completed bool)) error {
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, obj.ModTime.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var (
+		ranges []httprange.Range
+		err    error
+	)
+	if rangeHeader := c.Get(fiber.HeaderRange); rangeHeader != "" && httprange.IfRangeMatches(c.Get(fiber.HeaderIfRange), etag, obj.ModTime) {
+		ranges, err = httprange.Parse(rangeHeader, obj.Size)
+		if errors.Is(err, httprange.ErrUnsatisfiable) {
+			obj.Close()
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", obj.Size))
+			return response.ErrorResponse(c, fiber.StatusRequestedRangeNotSatisfiable, "Requested range not satisfiable")
+		}
+		if err != nil {
+			ranges = nil
+		}
+	}
+
+	var (
+		body        io.Reader
+		length      int64
+		includesEnd = true
+		multi       *multipartBody
+	)
+
+	switch len(ranges) {
+	case 0:
+		c.Status(fiber.StatusOK)
+		c.Set(fiber.HeaderContentType, contentType)
+		body, length = obj, obj.Size
+	case 1:
+		r := ranges[0]
+		if _, err := obj.Seek(r.Start, io.SeekStart); err != nil {
+			obj.Close()
+			return response.InternalServerErrorResponse(c, "Failed to read artifact")
+		}
+		c.Status(fiber.StatusPartialContent)
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderContentRange, r.ContentRange(obj.Size))
+		body, length = io.LimitReader(obj, r.Length), r.Length
+		includesEnd = r.Start+r.Length == obj.Size
+	default:
+		multi = newMultipartBody(ranges, obj.Size, contentType)
+		length = multi.length
+		c.Status(fiber.StatusPartialContent)
+		c.Set(fiber.HeaderContentType, multi.contentType())
+		last := ranges[len(ranges)-1]
+		includesEnd = last.Start+last.Length == obj.Size
+	}
+
+	if c.Method() == fiber.MethodHead {
+		obj.Close()
+		c.Response().Header.SetContentLength(int(length))
+		return nil
+	}
+	if multi != nil {
+		body = multi.stream(obj)
+	}
+
+	expected := httprange.TotalLength(ranges)
+	if ranges == nil {
+		expected = obj.Size
+	}
+
+	c.Context().SetBodyStream(&meteredBody{
+		reader: body,
+		closer: obj,
+		onClose: func(served int64) {
+			if onDone != nil {
+				onDone(served, includesEnd && served >= expected)
+			}
+		},
+	}, int(length))
+
+	return nil
+}
+
+func (h *DownloadHandler) GetStats(c *fiber.Ctx) error {
+	stats, err := h.downloadUseCase.GetStats(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get download stats: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Download stats retrieved successfully", stats)
+}
+
+// meteredBody counts the bytes actually sent to the client. The response
+// writer closes the body stream once it is done, successfully or not.
+type meteredBody struct {
+	reader  io.Reader
+	closer  io.Closer
+	served  int64
+	onClose func(served int64)
+	once    sync.Once
+}
+
+func (m *meteredBody) Read(p []byte) (int, error) {
+	n, err := m.reader.Read(p)
+	m.served += int64(n)
+	return n, err
+}
+
+func (m *meteredBody) Close() error {
+	var err error
+	m.once.Do(func() {
+		// A multipart body is a pipe that must be closed to stop its writer.
+		if c, ok := m.reader.(io.Closer); ok && c != m.closer {
+			c.Close()
+		}
+		err = m.closer.Close()
+		m.onClose(m.served)
+	})
+	return err
+}
+
+// multipartBody is a multipart/byteranges body of several ranges. Its exact
+// length is known up front, so HEAD requests never start streaming it.
+type multipartBody struct {
+	ranges   []httprange.Range
+	size     int64
+	partType string
+	boundary string
+	length   int64
+}
+
+func newMultipartBody(ranges []httprange.Range, size int64, contentType string) *multipartBody {
+	m := &multipartBody{
+		ranges:   ranges,
+		size:     size,
+		partType: contentType,
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+	}
+
+	// Measure the framing with a dry run so Content-Length can be sent up front.
+	counter := &countingWriter{}
+	mw := multipart.NewWriter(counter)
+	mw.SetBoundary(m.boundary)
+	for _, r := range ranges {
+		mw.CreatePart(m.partHeader(r))
+		counter.n += r.Length
+	}
+	mw.Close()
+	m.length = counter.n
+
+	return m
+}
+
+func (m *multipartBody) contentType() string {
+	return "multipart/byteranges; boundary=" + m.boundary
+}
+
+func (m *multipartBody) partHeader(r httprange.Range) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":  {m.partType},
+		"Content-Range": {r.ContentRange(m.size)},
+	}
+}
+
+// stream starts writing the ranges of obj into a pipe and returns its
+// reader, which must be closed to stop the writer.
+func (m *multipartBody) stream(obj *repository.ArtifactObject) io.Reader {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	mw.SetBoundary(m.boundary)
+
+	go func() {
+		for _, r := range m.ranges {
+			part, err := mw.CreatePart(m.partHeader(r))
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := obj.Seek(r.Start, io.SeekStart); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.CopyN(part, obj, r.Length); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	return pr
+}
+
+// payloadContentType returns the media type and file extension of a payload type.
+func payloadContentType(payloadType string) (string, string) {
+	switch payloadType {
+	case entity.PayloadTypeConfigBundle:
+		return "application/json", "json"
+	case entity.PayloadTypeTheme, entity.PayloadTypeWallpaperPack, entity.PayloadTypeFirmware:
+		return "application/zip", "zip"
+	default:
+		return apkContentType, "apk"
+	}
+}
+
+func artifactFilename(a entity.OTAArtifact) string {
+	name := a.SplitName
+	if name == "" {
+		name = "base"
+	}
+	if a.ABI != "" {
+		name += "-" + a.ABI
+	}
+	if a.Density != 0 {
+		name += fmt.Sprintf("-%ddpi", a.Density)
+	}
+	return name + ".apk"
+}
+
+// queryValues returns the raw query parameters of the request.
+func queryValues(c *fiber.Ctx) url.Values {
+	values, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	return values
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,186 @@
+package handle
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// trackedObject is an artifact whose closing is observed by the test.
+type trackedObject struct {
+	*bytes.Reader
+	mu     sync.Mutex
+	closed bool
+}
+
+func (o *trackedObject) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closed = true
+	return nil
+}
+
+func (o *trackedObject) isClosed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.closed
+}
+
+type served struct {
+	bytes     int64
+	completed bool
+}
+
+// serveTestArtifact serves content through serveArtifact and returns the
+// response, the artifact and what onDone reported.
+func serveTestArtifact(t *testing.T, method string, header map[string]string, content []byte) (*httptestResponse, *trackedObject, chan served) {
+	t.Helper()
+
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	obj := &trackedObject{Reader: bytes.NewReader(content)}
+	done := make(chan served, 1)
+
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error {
+		return serveArtifact(c, &repository.ArtifactObject{ReadSeekCloser: obj, Size: int64(len(content)), ModTime: modified},
+			`"etag"`, "app.apk", apkContentType, func(n int64, completed bool) {
+				done <- served{n, completed}
+			})
+	}
+	app.Get("/artifact", handler)
+
+	req := httptest.NewRequest(method, "/artifact", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &httptestResponse{status: resp.StatusCode, header: resp.Header.Get, body: body}, obj, done
+}
+
+type httptestResponse struct {
+	status int
+	header func(string) string
+	body   []byte
+}
+
+func TestServeArtifact(t *testing.T) {
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+
+	tests := []struct {
+		name      string
+		method    string
+		header    map[string]string
+		status    int
+		body      string
+		completed bool
+	}{
+		{"full", fiber.MethodGet, nil, fiber.StatusOK, string(content), true},
+		{"single range", fiber.MethodGet, map[string]string{"Range": "bytes=10-15"}, fiber.StatusPartialContent, "abcdef", false},
+		{"suffix range", fiber.MethodGet, map[string]string{"Range": "bytes=-4"}, fiber.StatusPartialContent, "wxyz", true},
+		{"repeated ranges are sent once", fiber.MethodGet, map[string]string{"Range": "bytes=0-,0-,0-"}, fiber.StatusPartialContent, string(content), true},
+		{"too many ranges", fiber.MethodGet, map[string]string{"Range": "bytes=" + strings.Repeat("0-0,", 40) + "0-0"}, fiber.StatusOK, string(content), true},
+		{"stale If-Range", fiber.MethodGet, map[string]string{"Range": "bytes=10-15", "If-Range": `"other"`}, fiber.StatusOK, string(content), true},
+		{"unsatisfiable", fiber.MethodGet, map[string]string{"Range": "bytes=100-"}, fiber.StatusRequestedRangeNotSatisfiable, "", false},
+		{"HEAD", fiber.MethodHead, nil, fiber.StatusOK, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, obj, done := serveTestArtifact(t, tt.method, tt.header, content)
+			if resp.status != tt.status {
+				t.Fatalf("status = %d, want %d", resp.status, tt.status)
+			}
+			if tt.status != fiber.StatusRequestedRangeNotSatisfiable && string(resp.body) != tt.body {
+				t.Fatalf("body = %q, want %q", resp.body, tt.body)
+			}
+			if !obj.isClosed() {
+				t.Fatal("artifact was not closed")
+			}
+
+			if tt.method == fiber.MethodGet && tt.status != fiber.StatusRequestedRangeNotSatisfiable {
+				select {
+				case got := <-done:
+					if got.bytes != int64(len(tt.body)) || got.completed != tt.completed {
+						t.Fatalf("onDone(%d, %v), want (%d, %v)", got.bytes, got.completed, len(tt.body), tt.completed)
+					}
+				case <-time.After(time.Second):
+					t.Fatal("onDone was not called")
+				}
+			}
+		})
+	}
+}
+
+func TestServeArtifactMultipart(t *testing.T) {
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	header := map[string]string{"Range": "bytes=30-,0-3,2-5"}
+
+	get, _, done := serveTestArtifact(t, fiber.MethodGet, header, content)
+	if get.status != fiber.StatusPartialContent {
+		t.Fatalf("status = %d", get.status)
+	}
+	mediaType, params, err := mime.ParseMediaType(get.header(fiber.HeaderContentType))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q", get.header(fiber.HeaderContentType))
+	}
+	if length := get.header(fiber.HeaderContentLength); length != strconv.Itoa(len(get.body)) {
+		t.Fatalf("Content-Length = %s, body has %d bytes", length, len(get.body))
+	}
+
+	want := []struct{ contentRange, body string }{
+		{"bytes 0-5/36", "012345"},
+		{"bytes 30-35/36", "uvwxyz"},
+	}
+	mr := multipart.NewReader(bytes.NewReader(get.body), params["boundary"])
+	for i, w := range want {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Range") != w.contentRange || string(body) != w.body {
+			t.Fatalf("part %d = %s %q, want %s %q", i, part.Header.Get("Content-Range"), body, w.contentRange, w.body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Fatalf("unexpected extra part: %v", err)
+	}
+	if got := <-done; !got.completed {
+		t.Fatal("a multipart response ending with the last byte was not reported as completed")
+	}
+
+	// HEAD reports the same length without streaming the parts.
+	head, obj, _ := serveTestArtifact(t, fiber.MethodHead, header, content)
+	if head.status != fiber.StatusPartialContent || len(head.body) != 0 {
+		t.Fatalf("HEAD = %d with %d bytes", head.status, len(head.body))
+	}
+	if head.header(fiber.HeaderContentLength) != get.header(fiber.HeaderContentLength) {
+		t.Fatalf("HEAD Content-Length = %s, GET sent %s", head.header(fiber.HeaderContentLength), get.header(fiber.HeaderContentLength))
+	}
+	if !obj.isClosed() {
+		t.Fatal("artifact was not closed")
+	}
+	if pos, _ := obj.Seek(0, io.SeekCurrent); pos != 0 {
+		t.Fatalf("HEAD read the artifact up to %d", pos)
+	}
+}
@@ -1,5 +1,16 @@
 package handle
 
 type Handlers struct {
-	OTA *OTAHandler
+	OTA         *OTAHandler
+	SigningCert *SigningCertHandler
+	Update      *UpdateHandler
+	Download    *DownloadHandler
+	Retention   *RetentionHandler
+	Artifact    *ArtifactHandler
+	Mirror      *MirrorHandler
+	ReleaseNote *ReleaseNoteHandler
+	APIKey      *APIKeyHandler
+	Device      *DeviceHandler
+	TUF         *TUFHandler
+	Audit       *AuditHandler
 } 
\ No newline at end of file
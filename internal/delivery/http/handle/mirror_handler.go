@@ -0,0 +1,100 @@
+package handle
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+)
+
+type MirrorCreateRequest struct {
+	URL      string `json:"url" validate:"required,url" example:"https://cache.store-042.example.com/apps/launcher-1.0.0.apk"`
+	Priority int    `json:"priority" example:"10"`
+}
+
+type MirrorPriorityRequest struct {
+	Priority int `json:"priority" example:"10"`
+}
+
+type MirrorHandler struct {
+	mirrorUseCase *usecase.MirrorUseCase
+}
+
+func NewMirrorHandler(mirrorUseCase *usecase.MirrorUseCase) *MirrorHandler {
+	return &MirrorHandler{
+		mirrorUseCase: mirrorUseCase,
+	}
+}
+
+func (h *MirrorHandler) RegisterRoutes(router fiber.Router) {
+	mirrorRouter := router.Group("/otas/:id/mirrors")
+
+	mirrorRouter.Get("/", h.ListMirrors)
+	mirrorRouter.Post("/", h.AddMirror)
+	mirrorRouter.Put("/:mirror_id", h.SetPriority)
+	mirrorRouter.Delete("/:mirror_id", h.DeleteMirror)
+}
+
+func (h *MirrorHandler) ListMirrors(c *fiber.Ctx) error {
+	mirrors, err := h.mirrorUseCase.ListMirrors(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get OTA mirrors: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA mirrors retrieved successfully", mirrors)
+}
+
+// AddMirror adds a mirror of the release's artifact. Mirrors with a lower
+// priority are offered to devices first.
+func (h *MirrorHandler) AddMirror(c *fiber.Ctx) error {
+	var req MirrorCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	mirror, err := h.mirrorUseCase.AddMirror(c.UserContext(), c.Params("id"), req.URL, req.Priority)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidURL) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to add OTA mirror: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "OTA mirror added successfully", mirror)
+}
+
+func (h *MirrorHandler) SetPriority(c *fiber.Ctx) error {
+	var req MirrorPriorityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	mirror, err := h.mirrorUseCase.SetPriority(c.UserContext(), c.Params("id"), c.Params("mirror_id"), req.Priority)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to update OTA mirror: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA mirror updated successfully", mirror)
+}
+
+func (h *MirrorHandler) DeleteMirror(c *fiber.Ctx) error {
+	if err := h.mirrorUseCase.DeleteMirror(c.UserContext(), c.Params("id"), c.Params("mirror_id")); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to delete OTA mirror: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA mirror deleted successfully", nil)
+}
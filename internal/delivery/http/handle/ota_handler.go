@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,10 +13,12 @@ import (
 
 type OTACreateRequest struct {
 	AppID        string `json:"app_id" validate:"required" example:"com.yapindo.launcher"`
+	PayloadType  string `json:"payload_type" example:"apk"`
 	VersionName  string `json:"version_name" validate:"required" example:"1.0.0"`
 	VersionCode  int    `json:"version_code" validate:"required,gt=0" example:"100"`
 	ReleaseNotes string `json:"release_notes" example:"Initial release with basic features"`
 	URL          string `json:"url" validate:"required,url" example:"https://storage.example.com/apps/launcher-1.0.0.apk"`
+	SHA256       string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
 }
 
 type OTAUpdateRequest struct {
@@ -24,15 +27,18 @@ type OTAUpdateRequest struct {
 	VersionCode  int    `json:"version_code" validate:"required,gt=0" example:"101"`
 	ReleaseNotes string `json:"release_notes" example:"Bug fixes and performance improvements"`
 	URL          string `json:"url" validate:"required,url" example:"https://storage.example.com/apps/launcher-1.0.1.apk"`
+	SHA256       string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
 }
 
 type OTAHandler struct {
-	otaUseCase *usecase.OTAUseCase
+	otaUseCase         *usecase.OTAUseCase
+	releaseNoteUseCase *usecase.ReleaseNoteUseCase
 }
 
-func NewOTAHandler(otaUseCase *usecase.OTAUseCase) *OTAHandler {
+func NewOTAHandler(otaUseCase *usecase.OTAUseCase, releaseNoteUseCase *usecase.ReleaseNoteUseCase) *OTAHandler {
 	return &OTAHandler{
-		otaUseCase: otaUseCase,
+		otaUseCase:         otaUseCase,
+		releaseNoteUseCase: releaseNoteUseCase,
 	}
 }
 
@@ -40,9 +46,16 @@ func (h *OTAHandler) RegisterRoutes(router fiber.Router) {
 	otaRouter := router.Group("/otas")
 
 	otaRouter.Post("/", h.CreateOTA)
+	otaRouter.Post("/upload", h.UploadOTA)
 	otaRouter.Get("/", h.GetAllOTAs)
 	otaRouter.Get("/get", h.GetOTA)
+	otaRouter.Get("/compare", h.CompareOTAs)
+	otaRouter.Get("/:id/manifest", h.GetOTAManifest)
+	otaRouter.Get("/:id/patches", h.GetOTAPatches)
 	otaRouter.Put("/:id", h.UpdateOTA)
+	otaRouter.Put("/:id/pin", h.PinOTA)
+	otaRouter.Delete("/:id/pin", h.UnpinOTA)
+	otaRouter.Post("/:id/acknowledge-permissions", h.AcknowledgePermissions)
 	otaRouter.Delete("/:id", h.DeleteOTA)
 }
 
@@ -54,29 +67,142 @@ func (h *OTAHandler) CreateOTA(c *fiber.Ctx) error {
 
 	ota := entity.OTA{
 		AppID:        req.AppID,
+		PayloadType:  req.PayloadType,
 		VersionName:  req.VersionName,
 		VersionCode:  req.VersionCode,
 		ReleaseNotes: req.ReleaseNotes,
 		URL:          req.URL,
+		SHA256:       req.SHA256,
 	}
 
-	createdOTA, err := h.otaUseCase.CreateOTA(c.Context(), ota)
+	createdOTA, err := h.otaUseCase.CreateOTA(c.UserContext(), ota)
 	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrUnverifiableArtifact) || errors.Is(err, usecase.ErrUnsupportedPayloadType) || errors.Is(err, usecase.ErrInvalidURL) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
 		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create OTA: "+err.Error())
 	}
 
 	return response.CreatedResponse(c, "OTA created successfully", createdOTA)
 }
 
+// UploadOTA creates a release from the multipart field "file", or "apk" for
+// APK releases. The form value payload_type selects the kind of payload.
+func (h *OTAHandler) UploadOTA(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		if fileHeader, err = c.FormFile("apk"); err != nil {
+			return response.BadRequestResponse(c, "File is required")
+		}
+	}
+
+	versionCode := 0
+	if v := c.FormValue("version_code"); v != "" {
+		versionCode, err = strconv.Atoi(v)
+		if err != nil || versionCode <= 0 {
+			return response.BadRequestResponse(c, "Invalid version code")
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.BadRequestResponse(c, "Failed to read uploaded file")
+	}
+	defer file.Close()
+
+	ota := entity.OTA{
+		AppID:        c.FormValue("app_id"),
+		PayloadType:  c.FormValue("payload_type"),
+		VersionName:  c.FormValue("version_name"),
+		VersionCode:  versionCode,
+		ReleaseNotes: c.FormValue("release_notes"),
+	}
+
+	createdOTA, err := h.otaUseCase.UploadOTA(c.UserContext(), ota, file, fileHeader.Size)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidArtifact) || errors.Is(err, usecase.ErrManifestMismatch) || errors.Is(err, usecase.ErrSigningCertMismatch) || errors.Is(err, usecase.ErrUnsupportedPayloadType) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to upload OTA: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "OTA uploaded successfully", createdOTA)
+}
+
+func (h *OTAHandler) GetOTAManifest(c *fiber.Ctx) error {
+	ota, _, err := h.otaUseCase.GetOTA(c.UserContext(), c.Params("id"), "")
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "OTA not found")
+	}
+	if ota.Manifest == nil {
+		return response.NotFoundResponse(c, "OTA has no APK manifest")
+	}
+
+	return response.SuccessResponse(c, "OTA manifest retrieved successfully", ota.Manifest)
+}
+
+// CompareOTAs shows what changes between the releases given by the from and
+// to query parameters, for review before publishing.
+func (h *OTAHandler) CompareOTAs(c *fiber.Ctx) error {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		return response.BadRequestResponse(c, "from and to are required")
+	}
+
+	comparison, err := h.otaUseCase.CompareOTAs(c.UserContext(), from, to)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrIncomparableReleases) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to compare OTAs: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTAs compared successfully", comparison)
+}
+
+func (h *OTAHandler) GetOTAPatches(c *fiber.Ctx) error {
+	patches, err := h.otaUseCase.ListPatches(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get OTA patches: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA patches retrieved successfully", patches)
+}
+
 func (h *OTAHandler) GetOTA(c *fiber.Ctx) error {
 	id := c.Query("id", "")
 	appID := c.Query("app_id", "")
 
-	ota, err := h.otaUseCase.GetOTA(c.Context(), id, appID)
+	ota, _, err := h.otaUseCase.GetOTA(c.UserContext(), id, appID)
 	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
 		return response.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
+	if err := h.releaseNoteUseCase.Localize(c.UserContext(), &ota, preferredLanguages(c)); err != nil {
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get release notes: "+err.Error())
+	}
+	if err := h.releaseNoteUseCase.Render(&ota, c.Query("format")); err != nil {
+		return response.ValidationErrorResponse(c, err.Error())
+	}
+
 	return response.SuccessResponse(c, "OTA retrieved successfully", ota)
 }
 
@@ -89,7 +215,7 @@ func (h *OTAHandler) GetAllOTAs(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	otas, nextCursor, total, err := h.otaUseCase.GetAllOTAs(c.Context(), cursor, limit)
+	otas, nextCursor, total, err := h.otaUseCase.GetAllOTAs(c.UserContext(), cursor, limit)
 	if err != nil {
 		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get OTAs: "+err.Error())
 	}
@@ -111,8 +237,10 @@ func (h *OTAHandler) UpdateOTA(c *fiber.Ctx) error {
 		return response.BadRequestResponse(c, "Invalid request body")
 	}
 
-	otas, _, err := h.otaUseCase.GetOTA(c.Context(), id, "", "", 0)
-	if err != nil || len(otas) == 0 {
+	if _, _, err := h.otaUseCase.GetOTA(c.UserContext(), id, ""); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
 		return response.NotFoundResponse(c, "OTA not found")
 	}
 
@@ -123,24 +251,80 @@ func (h *OTAHandler) UpdateOTA(c *fiber.Ctx) error {
 		VersionCode:  req.VersionCode,
 		ReleaseNotes: req.ReleaseNotes,
 		URL:          req.URL,
+		SHA256:       req.SHA256,
 	}
 
-	updatedOTA, err := h.otaUseCase.UpdateOTA(c.Context(), ota)
+	updatedOTA, err := h.otaUseCase.UpdateOTA(c.UserContext(), ota)
 	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrUnverifiableArtifact) || errors.Is(err, usecase.ErrManifestMismatch) || errors.Is(err, usecase.ErrInvalidURL) || errors.Is(err, usecase.ErrStoredArtifact) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
 		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update OTA: "+err.Error())
 	}
 
 	return response.SuccessResponse(c, "OTA updated successfully", updatedOTA)
 }
 
+// AcknowledgePermissions publishes a release that was held back because it
+// adds dangerous permissions. The caller is recorded as having acknowledged
+// them.
+func (h *OTAHandler) AcknowledgePermissions(c *fiber.Ctx) error {
+	ota, err := h.otaUseCase.AcknowledgePermissions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrUnauthenticated) {
+			return response.UnauthorizedResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrNotPendingReview) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to acknowledge OTA permissions: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA permissions acknowledged and published successfully", ota)
+}
+
+// PinOTA exempts a release from the retention policy.
+func (h *OTAHandler) PinOTA(c *fiber.Ctx) error {
+	ota, err := h.otaUseCase.SetPinned(c.UserContext(), c.Params("id"), true)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to pin OTA: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA pinned successfully", ota)
+}
+
+func (h *OTAHandler) UnpinOTA(c *fiber.Ctx) error {
+	ota, err := h.otaUseCase.SetPinned(c.UserContext(), c.Params("id"), false)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to unpin OTA: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "OTA unpinned successfully", ota)
+}
+
 func (h *OTAHandler) DeleteOTA(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
 		return response.BadRequestResponse(c, "ID is required")
 	}
 
-	err := h.otaUseCase.DeleteOTA(c.Context(), id)
+	err := h.otaUseCase.DeleteOTA(c.UserContext(), id)
 	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
 		return response.NotFoundResponse(c, "Failed to delete OTA: "+err.Error())
 	}
 
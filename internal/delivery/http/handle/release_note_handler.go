@@ -0,0 +1,93 @@
+package handle
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+	"launcherbackend_api/pkg/locale"
+)
+
+type ReleaseNoteRequest struct {
+	Notes string `json:"notes" validate:"required" example:"Perbaikan bug dan peningkatan kinerja"`
+}
+
+type ReleaseNoteHandler struct {
+	releaseNoteUseCase *usecase.ReleaseNoteUseCase
+}
+
+func NewReleaseNoteHandler(releaseNoteUseCase *usecase.ReleaseNoteUseCase) *ReleaseNoteHandler {
+	return &ReleaseNoteHandler{
+		releaseNoteUseCase: releaseNoteUseCase,
+	}
+}
+
+func (h *ReleaseNoteHandler) RegisterRoutes(router fiber.Router) {
+	noteRouter := router.Group("/otas/:id/release-notes")
+
+	noteRouter.Get("/", h.ListNotes)
+	noteRouter.Put("/:locale", h.SetNote)
+	noteRouter.Delete("/:locale", h.DeleteNote)
+}
+
+func (h *ReleaseNoteHandler) ListNotes(c *fiber.Ctx) error {
+	notes, err := h.releaseNoteUseCase.ListNotes(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get release notes: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Release notes retrieved successfully", notes)
+}
+
+// SetNote adds or replaces the translation of the release notes into the locale.
+func (h *ReleaseNoteHandler) SetNote(c *fiber.Ctx) error {
+	var req ReleaseNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	note, err := h.releaseNoteUseCase.SetNote(c.UserContext(), c.Params("id"), c.Params("locale"), req.Notes)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidLocale) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to save release note: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Release note saved successfully", note)
+}
+
+func (h *ReleaseNoteHandler) DeleteNote(c *fiber.Ctx) error {
+	if err := h.releaseNoteUseCase.DeleteNote(c.UserContext(), c.Params("id"), c.Params("locale")); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidLocale) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to delete release note: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Release note deleted successfully", nil)
+}
+
+// preferredLanguages returns the languages the client prefers: those of the
+// comma separated lang query parameter, then those of Accept-Language.
+func preferredLanguages(c *fiber.Ctx) []string {
+	var tags []string
+	for _, tag := range strings.Split(c.Query("lang"), ",") {
+		if canonical, err := locale.Canonical(tag); err == nil {
+			tags = append(tags, canonical)
+		}
+	}
+	return append(tags, locale.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))...)
+}
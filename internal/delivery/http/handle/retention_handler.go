@@ -0,0 +1,75 @@
+package handle
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+)
+
+type RetentionPolicyRequest struct {
+	KeepLast int `json:"keep_last" validate:"gte=0" example:"5"`
+}
+
+type RetentionHandler struct {
+	retentionUseCase *usecase.RetentionUseCase
+}
+
+func NewRetentionHandler(retentionUseCase *usecase.RetentionUseCase) *RetentionHandler {
+	return &RetentionHandler{
+		retentionUseCase: retentionUseCase,
+	}
+}
+
+func (h *RetentionHandler) RegisterRoutes(router fiber.Router) {
+	retentionRouter := router.Group("/apps/:app_id/retention")
+
+	retentionRouter.Get("/", h.GetPolicy)
+	retentionRouter.Put("/", h.SetPolicy)
+	retentionRouter.Get("/report", h.GetReport)
+}
+
+func (h *RetentionHandler) GetPolicy(c *fiber.Ctx) error {
+	policy, err := h.retentionUseCase.GetPolicy(c.UserContext(), c.Params("app_id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get retention policy: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Retention policy retrieved successfully", policy)
+}
+
+func (h *RetentionHandler) SetPolicy(c *fiber.Ctx) error {
+	var req RetentionPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	policy, err := h.retentionUseCase.SetPolicy(c.UserContext(), c.Params("app_id"), req.KeepLast)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to save retention policy: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Retention policy saved successfully", policy)
+}
+
+// GetReport is a dry run of garbage collection: it lists the releases whose
+// artifacts would be deleted without deleting anything.
+func (h *RetentionHandler) GetReport(c *fiber.Ctx) error {
+	report, err := h.retentionUseCase.Report(c.UserContext(), c.Params("app_id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to build retention report: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Retention report generated successfully", report)
+}
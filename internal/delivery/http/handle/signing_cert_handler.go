@@ -0,0 +1,75 @@
+package handle
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+)
+
+type SigningCertRegisterRequest struct {
+	CertSHA256 string `json:"cert_sha256" validate:"required" example:"3f1c9a0e5b7d2c4e6f8a1b3c5d7e9f0a2b4c6d8e0f1a3b5c7d9e1f2a4b6c8d0e"`
+}
+
+type SigningCertHandler struct {
+	signingCertUseCase *usecase.SigningCertUseCase
+}
+
+func NewSigningCertHandler(signingCertUseCase *usecase.SigningCertUseCase) *SigningCertHandler {
+	return &SigningCertHandler{
+		signingCertUseCase: signingCertUseCase,
+	}
+}
+
+func (h *SigningCertHandler) RegisterRoutes(router fiber.Router) {
+	certRouter := router.Group("/apps/:app_id/signing-certs")
+
+	certRouter.Get("/", h.ListCertificates)
+	certRouter.Post("/", h.RegisterCertificate)
+	certRouter.Delete("/:id", h.CancelRotation)
+}
+
+func (h *SigningCertHandler) ListCertificates(c *fiber.Ctx) error {
+	certs, err := h.signingCertUseCase.ListCertificates(c.UserContext(), c.Params("app_id"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get signing certificates: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Signing certificates retrieved successfully", certs)
+}
+
+func (h *SigningCertHandler) RegisterCertificate(c *fiber.Ctx) error {
+	var req SigningCertRegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequestResponse(c, "Invalid request body")
+	}
+
+	cert, err := h.signingCertUseCase.RegisterCertificate(c.UserContext(), c.Params("app_id"), req.CertSHA256)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrRotationPending) {
+			return response.ErrorResponse(c, fiber.StatusConflict, err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to register signing certificate: "+err.Error())
+	}
+
+	return response.CreatedResponse(c, "Signing certificate registered successfully", cert)
+}
+
+func (h *SigningCertHandler) CancelRotation(c *fiber.Ctx) error {
+	if err := h.signingCertUseCase.CancelRotation(c.UserContext(), c.Params("id")); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		return response.NotFoundResponse(c, "Failed to cancel rotation: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Signing certificate rotation cancelled successfully", nil)
+}
@@ -0,0 +1,74 @@
+package handle
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/usecase"
+)
+
+// tufRoleFiles maps the metadata files clients fetch to their roles.
+var tufRoleFiles = map[string]string{
+	"root.json":      entity.TUFRoleRoot,
+	"targets.json":   entity.TUFRoleTargets,
+	"snapshot.json":  entity.TUFRoleSnapshot,
+	"timestamp.json": entity.TUFRoleTimestamp,
+}
+
+type TUFHandler struct {
+	tufUseCase *usecase.TUFUseCase
+}
+
+func NewTUFHandler(tufUseCase *usecase.TUFUseCase) *TUFHandler {
+	return &TUFHandler{
+		tufUseCase: tufUseCase,
+	}
+}
+
+func (h *TUFHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/tuf/:app_id/:file", h.GetMetadata)
+}
+
+// GetMetadata serves a metadata file of an app exactly as it was signed:
+// root.json, targets.json, snapshot.json, timestamp.json or a version of the
+// root as <version>.root.json.
+func (h *TUFHandler) GetMetadata(c *fiber.Ctx) error {
+	if h.tufUseCase == nil {
+		return response.NotFoundResponse(c, "TUF metadata is not published")
+	}
+
+	appID := c.Params("app_id")
+	file := strings.ToLower(c.Params("file"))
+
+	var metadata entity.TUFMetadata
+	if version, ok := usecase.ParseRootFile(file); ok {
+		var err error
+		if metadata, err = h.tufUseCase.GetRoot(c.UserContext(), appID, version); err != nil {
+			return response.NotFoundResponse(c, "Failed to get TUF metadata: "+err.Error())
+		}
+	} else {
+		role, ok := tufRoleFiles[file]
+		if !ok {
+			return response.NotFoundResponse(c, "Unknown TUF metadata file "+file)
+		}
+		var found bool
+		var err error
+		metadata, found, err = h.tufUseCase.GetMetadata(c.UserContext(), appID, role)
+		if err != nil {
+			return response.InternalServerErrorResponse(c, "Failed to get TUF metadata: "+err.Error())
+		}
+		if !found {
+			return response.NotFoundResponse(c, "No TUF metadata for app "+appID)
+		}
+	}
+
+	// Clients must always see the newest timestamp
+	if metadata.Role == entity.TUFRoleTimestamp {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(metadata.Content)
+}
@@ -0,0 +1,89 @@
+package handle
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/usecase"
+	"launcherbackend_api/pkg/apk"
+)
+
+type UpdateHandler struct {
+	updateUseCase   *usecase.UpdateUseCase
+	manifestUseCase *usecase.ManifestUseCase
+}
+
+func NewUpdateHandler(updateUseCase *usecase.UpdateUseCase, manifestUseCase *usecase.ManifestUseCase) *UpdateHandler {
+	return &UpdateHandler{
+		updateUseCase:   updateUseCase,
+		manifestUseCase: manifestUseCase,
+	}
+}
+
+func (h *UpdateHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/otas/check", h.CheckUpdate)
+	router.Get("/manifest-keys", h.ListManifestKeys)
+}
+
+func (h *UpdateHandler) CheckUpdate(c *fiber.Ctx) error {
+	versionCode, err := strconv.Atoi(c.Query("version_code", "0"))
+	if err != nil || versionCode < 0 {
+		return response.BadRequestResponse(c, "Invalid version code")
+	}
+
+	density, err := apk.ParseDensity(c.Query("density"))
+	if err != nil {
+		return response.BadRequestResponse(c, "Invalid density")
+	}
+
+	var abis []string
+	for _, abi := range strings.Split(c.Query("abi"), ",") {
+		if abi = strings.TrimSpace(abi); abi != "" {
+			abis = append(abis, abi)
+		}
+	}
+
+	check, err := h.updateUseCase.CheckUpdate(c.UserContext(), entity.UpdateCheckRequest{
+		AppID:           c.Query("app_id"),
+		PayloadType:     c.Query("payload_type"),
+		VersionCode:     versionCode,
+		InstalledSHA256: c.Query("sha256"),
+		DeviceID:        c.Query("device_id"),
+		ABIs:            abis,
+		Density:         density,
+		Languages:       preferredLanguages(c),
+		NotesFormat:     c.Query("format"),
+	})
+	if err != nil {
+		if errors.Is(err, usecase.ErrDeviceIDRequired) || errors.Is(err, usecase.ErrUnsupportedPayloadType) || errors.Is(err, usecase.ErrUnsupportedNotesFormat) {
+			return response.ValidationErrorResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrUnauthenticated) {
+			return response.UnauthorizedResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrForbidden) {
+			return response.ForbiddenResponse(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrUnsignableRelease) {
+			return response.InternalServerErrorResponse(c, "Failed to sign update manifest: "+err.Error())
+		}
+		return response.BadRequestResponse(c, "Failed to check for updates: "+err.Error())
+	}
+
+	return response.SuccessResponse(c, "Update check completed successfully", check)
+}
+
+// ListManifestKeys lists the public keys update manifests are signed with.
+// Devices should trust any of them, so keys can be rotated.
+func (h *UpdateHandler) ListManifestKeys(c *fiber.Ctx) error {
+	if h.manifestUseCase == nil {
+		return response.NotFoundResponse(c, "Update manifests are not signed")
+	}
+
+	return response.SuccessResponse(c, "Manifest keys retrieved successfully", h.manifestUseCase.Keys())
+}
@@ -0,0 +1,167 @@
+// Package middleware holds the Fiber middleware of the HTTP API.
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/usecase"
+	"launcherbackend_api/pkg/mtls"
+)
+
+// PrincipalLocal is the Fiber local holding the authenticated entity.Principal.
+// The request's user context carries it too, for the use cases to authorize.
+const PrincipalLocal = "principal"
+
+// DeviceTokenHeader carries the credential of an enrolled device.
+const DeviceTokenHeader = "X-Device-Token"
+
+// AuthConfig configures how callers authenticate and which requests need it.
+type AuthConfig struct {
+	Keys *usecase.APIKeyUseCase
+	// Tokens verifies JWT bearer tokens; nil when JWTs are not accepted.
+	Tokens *usecase.JWTUseCase
+	// ProtectReads requires authentication for GET and HEAD requests of the
+	// admin API.
+	ProtectReads bool
+	// ProtectDeviceReads requires authentication for the reads devices make,
+	// as told apart by IsDeviceRead.
+	ProtectDeviceReads bool
+	IsDeviceRead       func(path string) bool
+	// AlwaysProtected are path prefixes whose reads always need authentication.
+	AlwaysProtected []string
+	// Public are paths that never need authentication, such as device
+	// enrollment, which authenticates with the enrollment token it is sent.
+	Public []string
+	// Devices authenticates the X-Device-Token header of device requests.
+	Devices *usecase.DeviceUseCase
+	// ClientCertField names the field of verified TLS client certificates
+	// that holds the device ID, as understood by mtls.DeviceID. Client
+	// certificates identify no device when it is empty.
+	ClientCertField string
+}
+
+// Auth rejects requests without valid credentials. Writes always need them;
+// admin and device reads only when configured to. Callers authenticate with
+// an API key, sent in the X-API-Key header or as a bearer token, or with a
+// JWT bearer token. Devices send the credential they enrolled for in the
+// X-Device-Token header, which is checked whenever it is present, or with a
+// TLS client certificate; the update check decides whether either is required.
+func Auth(cfg AuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		device, ok, err := authenticateDevice(c, cfg)
+		if err != nil {
+			if errors.Is(err, usecase.ErrUnauthenticated) {
+				return response.UnauthorizedResponse(c, err.Error())
+			}
+			return response.InternalServerErrorResponse(c, "Failed to authenticate device: "+err.Error())
+		}
+		if ok {
+			c.SetUserContext(usecase.WithDevice(c.UserContext(), device))
+		}
+
+		if !requiresAuth(c, cfg) {
+			return c.Next()
+		}
+
+		principal, err := authenticate(c, cfg)
+		if err != nil {
+			if errors.Is(err, usecase.ErrUnauthenticated) {
+				return response.UnauthorizedResponse(c, err.Error())
+			}
+			return response.InternalServerErrorResponse(c, "Failed to authenticate: "+err.Error())
+		}
+
+		c.Locals(PrincipalLocal, principal)
+		c.SetUserContext(usecase.WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+// authenticateDevice returns the device identified by the request's client
+// certificate or device token. Both must name the same device when sent.
+func authenticateDevice(c *fiber.Ctx, cfg AuthConfig) (entity.DeviceCredential, bool, error) {
+	var device entity.DeviceCredential
+	certified := false
+	if cfg.ClientCertField != "" {
+		var deviceID string
+		deviceID, certified = mtls.DeviceID(c.Context().TLSConnectionState(), cfg.ClientCertField)
+		device = entity.DeviceCredential{DeviceID: deviceID}
+	}
+
+	token := c.Get(DeviceTokenHeader)
+	if token == "" || cfg.Devices == nil {
+		return device, certified, nil
+	}
+	credential, err := cfg.Devices.Authenticate(c.UserContext(), token)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUnauthenticated) {
+			return entity.DeviceCredential{}, false, fmt.Errorf("%w: invalid device credential", usecase.ErrUnauthenticated)
+		}
+		return entity.DeviceCredential{}, false, err
+	}
+	if certified && credential.DeviceID != device.DeviceID {
+		return entity.DeviceCredential{}, false, fmt.Errorf("%w: device credential and client certificate name different devices", usecase.ErrUnauthenticated)
+	}
+
+	return credential, true, nil
+}
+
+func authenticate(c *fiber.Ctx, cfg AuthConfig) (entity.Principal, error) {
+	if key := c.Get("X-API-Key"); key != "" {
+		return cfg.Keys.Authenticate(c.UserContext(), key)
+	}
+
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	token = strings.TrimSpace(token)
+	if !ok || token == "" {
+		return entity.Principal{}, usecase.ErrUnauthenticated
+	}
+
+	// Generated API keys never contain dots, while a JWT has exactly two
+	if cfg.Tokens != nil && strings.Count(token, ".") == 2 {
+		return cfg.Tokens.Authenticate(c.UserContext(), token)
+	}
+	return cfg.Keys.Authenticate(c.UserContext(), token)
+}
+
+func requiresAuth(c *fiber.Ctx, cfg AuthConfig) bool {
+	if c.Method() == fiber.MethodOptions {
+		return false
+	}
+
+	// Routing ignores case, so the path is matched in lower case
+	path := strings.ToLower(c.Path())
+	if slices.Contains(cfg.Public, strings.TrimSuffix(path, "/")) {
+		return false
+	}
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return true
+	}
+
+	for _, prefix := range cfg.AlwaysProtected {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	if cfg.IsDeviceRead != nil && cfg.IsDeviceRead(path) {
+		// An enrolled device needs no other credentials for its own reads
+		if _, ok := usecase.DeviceFromContext(c.UserContext()); ok {
+			return false
+		}
+		return cfg.ProtectDeviceReads
+	}
+	return cfg.ProtectReads
+}
+
+// AuthenticatedPrincipal returns the caller that authenticated the request, if any.
+func AuthenticatedPrincipal(c *fiber.Ctx) (entity.Principal, bool) {
+	principal, ok := c.Locals(PrincipalLocal).(entity.Principal)
+	return principal, ok
+}
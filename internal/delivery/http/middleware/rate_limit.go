@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"launcherbackend_api/internal/common/response"
+	"launcherbackend_api/internal/usecase"
+)
+
+// RateLimitConfig configures how requests are rate limited.
+type RateLimitConfig struct {
+	Limiter *usecase.RateLimitUseCase
+	// IsDeviceRead tells the requests of the device tier apart from those of
+	// the admin tier.
+	IsDeviceRead func(path string) bool
+}
+
+// RateLimitIP limits requests by source IP. It runs before Auth, so failed
+// authentication attempts such as guessed API keys are limited too.
+func RateLimitIP(limiter *usecase.RateLimitUseCase) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return limit(c, limiter, usecase.RateLimitTierIP, c.IP())
+	}
+}
+
+// RateLimit rejects requests of callers over their tier's limit with 429 Too
+// Many Requests and a Retry-After header. It must run after Auth: callers
+// are told apart by API key or token, then by device, and otherwise by IP.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tier := usecase.RateLimitTierAdmin
+		if cfg.IsDeviceRead != nil && cfg.IsDeviceRead(c.Path()) {
+			tier = usecase.RateLimitTierDevice
+		}
+		return limit(c, cfg.Limiter, tier, caller(c))
+	}
+}
+
+// limit counts the request against the caller's bucket of tier. Requests
+// are let through when the limiter fails, so an outage of its storage does
+// not take the API down with it.
+func limit(c *fiber.Ctx, limiter *usecase.RateLimitUseCase, tier string, caller string) error {
+	allowed, retryAfter, err := limiter.Allow(c.UserContext(), tier, caller)
+	if err != nil {
+		log.Printf("Rate limiter failed for %s tier on %s %s, allowing request: %v", tier, c.Method(), c.Path(), err)
+		return c.Next()
+	}
+	if !allowed {
+		seconds := max(int(math.Ceil(retryAfter.Seconds())), 1)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return response.TooManyRequestsResponse(c, fmt.Sprintf("Rate limit exceeded, retry after %d seconds", seconds))
+	}
+
+	return c.Next()
+}
+
+// caller identifies who makes a request for rate limiting.
+func caller(c *fiber.Ctx) string {
+	if principal, ok := usecase.PrincipalFromContext(c.UserContext()); ok {
+		return principal.Method + ":" + principal.ID
+	}
+	if device, ok := usecase.DeviceFromContext(c.UserContext()); ok {
+		return "device:" + device.DeviceID
+	}
+	return "ip:" + c.IP()
+}
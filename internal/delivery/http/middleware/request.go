@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"launcherbackend_api/internal/usecase"
+)
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// RequestID tags each request with an ID, taken from the X-Request-ID header
+// when a client or proxy sends one, and echoes it in the response. The ID and
+// the client's IP reach the use cases through the request's user context.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+
+		c.SetUserContext(usecase.WithRequest(c.UserContext(), usecase.RequestInfo{ID: id, SourceIP: c.IP()}))
+		return c.Next()
+	}
+}
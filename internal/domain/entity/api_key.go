@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// APIKey grants access to the admin API. Only a salted hash of its secret is kept.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Salt       string     `json:"-" db:"salt"`
+	Hash       string     `json:"-" db:"hash"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// CreatedAPIKey is a new API key together with its token, which is only
+// revealed when the key is created.
+type CreatedAPIKey struct {
+	APIKey
+	Token string `json:"token"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+// APKManifest is the metadata read from the AndroidManifest.xml of an uploaded APK.
+type APKManifest struct {
+	PackageName string   `json:"package_name"`
+	VersionCode int      `json:"version_code"`
+	VersionName string   `json:"version_name"`
+	MinSdk      int      `json:"min_sdk"`
+	TargetSdk   int      `json:"target_sdk"`
+	Permissions []string `json:"permissions"`
+}
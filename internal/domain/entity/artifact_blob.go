@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// ArtifactBlob is a stored file identified by the SHA-256 of its content. It
+// is shared by every release or patch with that content and deleted once
+// nothing references it any more.
+type ArtifactBlob struct {
+	SHA256     string    `json:"sha256" db:"sha256"`
+	StorageKey string    `json:"-" db:"storage_key"`
+	SizeBytes  int64     `json:"size_bytes" db:"size_bytes"`
+	RefCount   int       `json:"ref_count" db:"ref_count"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,66 @@
+package entity
+
+import "time"
+
+// Kinds of change recorded in the audit log.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionPublish = "publish"
+	AuditActionRollout = "rollout"
+)
+
+// Kinds of resource whose changes are recorded in the audit log.
+const (
+	AuditResourceOTA             = "ota"
+	AuditResourceArtifact        = "ota_artifact"
+	AuditResourceMirror          = "ota_mirror"
+	AuditResourceReleaseNote     = "release_note"
+	AuditResourceRetentionPolicy = "retention_policy"
+	AuditResourceSigningCert     = "signing_certificate"
+	AuditResourceAPIKey          = "api_key"
+	AuditResourceRoleBinding     = "role_binding"
+	AuditResourceEnrollmentToken = "enrollment_token"
+	AuditResourceDevice          = "device_credential"
+)
+
+// AuditEntry records a change to a resource: who made it, from where, and
+// the fields it changed. The actor is empty for changes the server makes on
+// its own, such as archiving releases by retention policy.
+type AuditEntry struct {
+	ID           int64                  `json:"id" db:"id"`
+	Action       string                 `json:"action" db:"action"`
+	ResourceType string                 `json:"resource_type" db:"resource_type"`
+	ResourceID   string                 `json:"resource_id" db:"resource_id"`
+	AppID        string                 `json:"app_id,omitempty" db:"app_id"`
+	ActorID      string                 `json:"actor_id,omitempty" db:"actor_id"`
+	ActorName    string                 `json:"actor_name,omitempty" db:"actor_name"`
+	ActorMethod  string                 `json:"actor_method,omitempty" db:"actor_method"`
+	SourceIP     string                 `json:"source_ip,omitempty" db:"source_ip"`
+	RequestID    string                 `json:"request_id,omitempty" db:"request_id"`
+	Changes      map[string]FieldChange `json:"changes" db:"changes"`
+	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
+}
+
+// FieldChange is the value of a field before and after a change. Fields set
+// by a creation have no value before it, and fields cleared by a deletion
+// none after it.
+type FieldChange struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// AuditFilter selects audit entries. Empty fields match any entry.
+type AuditFilter struct {
+	// AppIDs limits entries to those of the listed apps when not nil.
+	AppIDs       []string
+	AppID        string
+	ResourceType string
+	ResourceID   string
+	Action       string
+	ActorID      string
+	RequestID    string
+	Since        time.Time
+	Until        time.Time
+}
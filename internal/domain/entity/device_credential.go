@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// EnrollmentToken lets one device enroll, for one app or for every app when
+// AppID is empty. Only a salted hash of its secret is kept.
+type EnrollmentToken struct {
+	ID             string     `json:"id" db:"id"`
+	AppID          string     `json:"app_id,omitempty" db:"app_id"`
+	Prefix         string     `json:"prefix" db:"prefix"`
+	Salt           string     `json:"-" db:"salt"`
+	Hash           string     `json:"-" db:"hash"`
+	CreatedBy      string     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt         *time.Time `json:"used_at,omitempty" db:"used_at"`
+	UsedByDeviceID string     `json:"used_by_device_id,omitempty" db:"used_by_device_id"`
+}
+
+// CreatedEnrollmentToken is a new enrollment token together with its token,
+// which is only revealed when the token is created.
+type CreatedEnrollmentToken struct {
+	EnrollmentToken
+	Token string `json:"token"`
+}
+
+// DeviceCredential is the secret an enrolled device authenticates with.
+type DeviceCredential struct {
+	ID                string     `json:"id" db:"id"`
+	DeviceID          string     `json:"device_id" db:"device_id"`
+	AppID             string     `json:"app_id,omitempty" db:"app_id"`
+	EnrollmentTokenID string     `json:"enrollment_token_id,omitempty" db:"enrollment_token_id"`
+	Prefix            string     `json:"prefix" db:"prefix"`
+	Salt              string     `json:"-" db:"salt"`
+	Hash              string     `json:"-" db:"hash"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt        *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// EnrolledDevice is the credential issued to a device on enrollment together
+// with its token, which the device must keep: it is not revealed again.
+type EnrolledDevice struct {
+	DeviceCredential
+	Token string `json:"token"`
+}
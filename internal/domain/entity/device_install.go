@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// DeviceInstall is the version of an app a device last reported as installed.
+type DeviceInstall struct {
+	DeviceID    string    `json:"device_id" db:"device_id"`
+	AppID       string    `json:"app_id" db:"app_id"`
+	PayloadType string    `json:"payload_type" db:"payload_type"`
+	VersionCode int       `json:"version_code" db:"version_code"`
+	SHA256      string    `json:"sha256,omitempty" db:"sha256"`
+	ReportedAt  time.Time `json:"reported_at" db:"reported_at"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// DownloadStats accumulates artifact traffic served through the download proxy.
+type DownloadStats struct {
+	OTAID              string     `json:"ota_id" db:"ota_id"`
+	Requests           int64      `json:"requests" db:"requests"`
+	CompletedDownloads int64      `json:"completed_downloads" db:"completed_downloads"`
+	BytesServed        int64      `json:"bytes_served" db:"bytes_served"`
+	LastServedAt       *time.Time `json:"last_served_at,omitempty" db:"last_served_at"`
+}
@@ -2,13 +2,54 @@ package entity
 
 import "time"
 
+const (
+	OTAStatusPublished = "published"
+	OTAStatusArchived  = "archived"
+	// OTAStatusPendingReview marks a release held back until an admin
+	// acknowledges the dangerous permissions it adds.
+	OTAStatusPendingReview = "pending_review"
+)
+
+// Payload types a release can ship.
+const (
+	PayloadTypeAPK           = "apk"
+	PayloadTypeTheme         = "theme"
+	PayloadTypeWallpaperPack = "wallpaper_pack"
+	PayloadTypeConfigBundle  = "config_bundle"
+	PayloadTypeFirmware      = "firmware"
+)
+
+// IsValidPayloadType reports whether t is a known payload type.
+func IsValidPayloadType(t string) bool {
+	switch t {
+	case PayloadTypeAPK, PayloadTypeTheme, PayloadTypeWallpaperPack, PayloadTypeConfigBundle, PayloadTypeFirmware:
+		return true
+	}
+	return false
+}
+
 type OTA struct {
-	ID           string    `json:"id" db:"id"`
-	AppID        string    `json:"app_id" db:"app_id"`
-	VersionName  string    `json:"version_name" db:"version_name"`
-	VersionCode  int       `json:"version_code" db:"version_code"`
-	ReleaseNotes string    `json:"release_notes" db:"release_notes"`
-	URL          string    `json:"url" db:"url"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID                        string            `json:"id" db:"id"`
+	AppID                     string            `json:"app_id" db:"app_id"`
+	PayloadType               string            `json:"payload_type" db:"payload_type"`
+	VersionName               string            `json:"version_name" db:"version_name"`
+	VersionCode               int               `json:"version_code" db:"version_code"`
+	ReleaseNotes              string            `json:"release_notes" db:"release_notes"`
+	ReleaseNotesLocale        string            `json:"release_notes_locale,omitempty" db:"-"`
+	ReleaseNotesFormat        string            `json:"release_notes_format,omitempty" db:"-"`
+	URL                       string            `json:"url" db:"url"`
+	SHA256                    string            `json:"sha256,omitempty" db:"sha256"`
+	SizeBytes                 int64             `json:"size_bytes,omitempty" db:"size_bytes"`
+	StorageKey                string            `json:"-" db:"storage_key"`
+	Manifest                  *APKManifest      `json:"manifest,omitempty" db:"manifest"`
+	Metadata                  map[string]string `json:"metadata,omitempty" db:"metadata"`
+	SigningCertSHA256         string            `json:"signing_cert_sha256,omitempty" db:"signing_cert_sha256"`
+	DangerousPermissionsAdded []string          `json:"dangerous_permissions_added,omitempty" db:"dangerous_permissions_added"`
+	PermissionsAcknowledgedAt *time.Time        `json:"permissions_acknowledged_at,omitempty" db:"permissions_acknowledged_at"`
+	PermissionsAcknowledgedBy string            `json:"permissions_acknowledged_by,omitempty" db:"permissions_acknowledged_by"`
+	Status                    string            `json:"status" db:"status"`
+	Pinned                    bool              `json:"pinned" db:"pinned"`
+	ArchivedAt                *time.Time        `json:"archived_at,omitempty" db:"archived_at"`
+	CreatedAt                 time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt                 time.Time         `json:"updated_at" db:"updated_at"`
 }
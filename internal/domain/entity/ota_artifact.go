@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// OTAArtifact is an additional APK of a release: a build for a single ABI or
+// screen density, or a split installed together with the release's base APK.
+// An empty ABI, zero density or empty split name means the artifact is not
+// specific to that dimension.
+type OTAArtifact struct {
+	ID         string    `json:"id" db:"id"`
+	OTAID      string    `json:"ota_id" db:"ota_id"`
+	SplitName  string    `json:"split_name,omitempty" db:"split_name"`
+	ABI        string    `json:"abi,omitempty" db:"abi"`
+	Density    int       `json:"density,omitempty" db:"density"`
+	SHA256     string    `json:"sha256" db:"sha256"`
+	SizeBytes  int64     `json:"size_bytes" db:"size_bytes"`
+	URL        string    `json:"url" db:"url"`
+	StorageKey string    `json:"-" db:"storage_key"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
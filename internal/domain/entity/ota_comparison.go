@@ -0,0 +1,28 @@
+package entity
+
+// VersionChange is the change of a version number between two releases.
+type VersionChange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// OTAComparison describes what changes when a device moves from one release to another.
+type OTAComparison struct {
+	From OTA `json:"from"`
+	To   OTA `json:"to"`
+	// SizeDeltaBytes is nil when the size of either artifact is unknown.
+	SizeDeltaBytes *int64 `json:"size_delta_bytes,omitempty"`
+	// ManifestsCompared is false when either release has no APK manifest, in
+	// which case SDK levels and permissions could not be compared.
+	ManifestsCompared  bool           `json:"manifests_compared"`
+	MinSdk             *VersionChange `json:"min_sdk,omitempty"`
+	TargetSdk          *VersionChange `json:"target_sdk,omitempty"`
+	PermissionsAdded   []string       `json:"permissions_added"`
+	PermissionsRemoved []string       `json:"permissions_removed"`
+	SigningCertChanged bool           `json:"signing_cert_changed"`
+	// Changelog holds the release notes of the releases after From up to and
+	// including To, oldest first.
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+	// Risks summarizes the changes a reviewer should look at before publishing.
+	Risks []string `json:"risks"`
+}
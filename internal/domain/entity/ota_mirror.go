@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// OTAMirror is an alternative location of a release's artifact. Devices try
+// healthy mirrors in priority order, lowest first, when the release URL fails.
+type OTAMirror struct {
+	ID                  string     `json:"id" db:"id"`
+	OTAID               string     `json:"ota_id" db:"ota_id"`
+	URL                 string     `json:"url" db:"url"`
+	Priority            int        `json:"priority" db:"priority"`
+	Healthy             bool       `json:"healthy" db:"healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty" db:"last_error"`
+	LastCheckedAt       *time.Time `json:"last_checked_at,omitempty" db:"last_checked_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+}
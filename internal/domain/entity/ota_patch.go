@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+const (
+	PatchStatusPending = "pending"
+	PatchStatusReady   = "ready"
+	PatchStatusFailed  = "failed"
+	PatchStatusSkipped = "skipped"
+)
+
+// OTAPatch is a binary delta that turns the artifact of FromOTAID into the artifact of OTAID.
+// Pending and failed patches are generated again at NextAttemptAt, if set;
+// Attempts counts how often that was tried.
+type OTAPatch struct {
+	ID              string     `json:"id" db:"id"`
+	OTAID           string     `json:"ota_id" db:"ota_id"`
+	FromOTAID       string     `json:"from_ota_id" db:"from_ota_id"`
+	FromVersionCode int        `json:"from_version_code" db:"from_version_code"`
+	FromSHA256      string     `json:"from_sha256" db:"from_sha256"`
+	ToSHA256        string     `json:"to_sha256" db:"to_sha256"`
+	SHA256          string     `json:"sha256,omitempty" db:"sha256"`
+	SizeBytes       int64      `json:"size_bytes,omitempty" db:"size_bytes"`
+	URL             string     `json:"url,omitempty" db:"url"`
+	StorageKey      string     `json:"-" db:"storage_key"`
+	Status          string     `json:"status" db:"status"`
+	Error           string     `json:"error,omitempty" db:"error"`
+	Attempts        int        `json:"attempts" db:"attempts"`
+	NextAttemptAt   *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// ReleaseNote is the translation of a release's notes into one locale.
+type ReleaseNote struct {
+	OTAID     string    `json:"ota_id" db:"ota_id"`
+	Locale    string    `json:"locale" db:"locale"`
+	Notes     string    `json:"notes" db:"notes"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// RetentionPolicy limits how many published releases of an app keep their artifacts.
+type RetentionPolicy struct {
+	AppID     string    `json:"app_id" db:"app_id"`
+	KeepLast  int       `json:"keep_last" db:"keep_last"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RetentionCandidate is a release whose artifacts the retention policy would delete.
+type RetentionCandidate struct {
+	OTAID         string `json:"ota_id"`
+	VersionName   string `json:"version_name"`
+	VersionCode   int    `json:"version_code"`
+	SizeBytes     int64  `json:"size_bytes"`
+	Artifacts     int    `json:"artifacts"`
+	ArtifactBytes int64  `json:"artifact_bytes"`
+	Patches       int    `json:"patches"`
+	PatchBytes    int64  `json:"patch_bytes"`
+}
+
+// RetentionReport lists what garbage collection of an app would delete.
+type RetentionReport struct {
+	AppID            string               `json:"app_id"`
+	KeepLast         int                  `json:"keep_last"`
+	Candidates       []RetentionCandidate `json:"candidates"`
+	ReclaimableBytes int64                `json:"reclaimable_bytes"`
+}
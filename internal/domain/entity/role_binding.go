@@ -0,0 +1,47 @@
+package entity
+
+import "time"
+
+// Roles, from least to most privileged. Each role can do everything the
+// roles before it can.
+const (
+	RoleViewer         = "viewer"
+	RolePublisher      = "publisher"
+	RoleReleaseManager = "release_manager"
+	RoleAdmin          = "admin"
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleViewer, RolePublisher, RoleReleaseManager, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// RoleBinding grants an API key a role for one app, or for every app when
+// AppID is empty.
+type RoleBinding struct {
+	ID        string    `json:"id" db:"id"`
+	APIKeyID  string    `json:"api_key_id" db:"api_key_id"`
+	Role      string    `json:"role" db:"role"`
+	AppID     string    `json:"app_id,omitempty" db:"app_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Ways a caller of the admin API authenticates.
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Principal is the caller of the admin API and the roles it holds.
+type Principal struct {
+	// ID is the API key ID, or the subject of a JWT.
+	ID       string
+	Name     string
+	Method   string
+	Bindings []RoleBinding
+	// Superuser principals, such as the bootstrap key, may do anything.
+	Superuser bool
+}
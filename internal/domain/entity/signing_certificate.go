@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+const (
+	SigningCertStatusPending = "pending"
+	SigningCertStatusActive  = "active"
+	SigningCertStatusRetired = "retired"
+)
+
+// SigningCertificate is an APK signing certificate pinned to an app. Rotations
+// form a lineage through ParentID, with exactly one active certificate per app.
+type SigningCertificate struct {
+	ID          string     `json:"id" db:"id"`
+	AppID       string     `json:"app_id" db:"app_id"`
+	CertSHA256  string     `json:"cert_sha256" db:"cert_sha256"`
+	ParentID    *string    `json:"parent_id,omitempty" db:"parent_id"`
+	Status      string     `json:"status" db:"status"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty" db:"activated_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty" db:"retired_at"`
+}
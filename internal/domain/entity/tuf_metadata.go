@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// TUF metadata roles.
+const (
+	TUFRoleRoot      = "root"
+	TUFRoleTargets   = "targets"
+	TUFRoleSnapshot  = "snapshot"
+	TUFRoleTimestamp = "timestamp"
+)
+
+// TUFMetadata is one version of the signed metadata of a role of an app, in
+// The Update Framework's format.
+type TUFMetadata struct {
+	AppID   string `json:"app_id" db:"app_id"`
+	Role    string `json:"role" db:"role"`
+	Version int    `json:"version" db:"version"`
+	// BodySHA256 is the digest of the signed body without its version and
+	// expiry, telling whether a role's content changed.
+	BodySHA256 string    `json:"-" db:"body_sha256"`
+	Content    []byte    `json:"-" db:"content"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
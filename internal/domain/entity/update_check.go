@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+// UpdateCheckRequest describes the device asking for an update.
+type UpdateCheckRequest struct {
+	AppID           string
+	PayloadType     string
+	VersionCode     int
+	InstalledSHA256 string
+	DeviceID        string
+	// ABIs are the device's supported ABIs in order of preference.
+	ABIs    []string
+	Density int
+	// Languages are the language tags the device prefers release notes in.
+	Languages []string
+	// NotesFormat is the format to render release notes in.
+	NotesFormat string
+}
+
+// UpdateCheck is the answer to a device asking whether a newer release exists.
+type UpdateCheck struct {
+	UpdateAvailable bool      `json:"update_available"`
+	OTA             *OTA      `json:"ota,omitempty"`
+	Patch           *OTAPatch `json:"patch,omitempty"`
+	// Artifacts is the set of APKs to install instead of the release's
+	// universal artifact, chosen for the device's ABIs and density.
+	Artifacts []OTAArtifact `json:"artifacts,omitempty"`
+	// Mirrors are alternative URLs of the release's artifact, in the order
+	// devices should try them when the release URL fails.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// Changelog holds the release notes of every release since the device's
+	// version up to and including the offered one, oldest first.
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+	// DangerousPermissionsAdded lists the dangerous permissions the update
+	// requests that the device's installed version did not, so the launcher
+	// can warn the user before installing.
+	DangerousPermissionsAdded []string `json:"dangerous_permissions_added,omitempty"`
+	// Manifest is the base64 encoded canonical JSON of the check's
+	// UpdateManifest when manifests are signed. Signatures sign its bytes.
+	Manifest   string              `json:"manifest,omitempty"`
+	Signatures []ManifestSignature `json:"signatures,omitempty"`
+}
+
+// ChangelogEntry is the release notes of one release in a changelog.
+type ChangelogEntry struct {
+	OTAID              string    `json:"ota_id"`
+	VersionName        string    `json:"version_name"`
+	VersionCode        int       `json:"version_code"`
+	ReleaseNotes       string    `json:"release_notes"`
+	ReleaseNotesLocale string    `json:"release_notes_locale,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+// UpdateManifest is the signed part of an update check: everything a device
+// needs to decide what to install and to verify what it downloads.
+type UpdateManifest struct {
+	AppID                string             `json:"app_id"`
+	PayloadType          string             `json:"payload_type"`
+	DeviceID             string             `json:"device_id,omitempty"`
+	InstalledVersionCode int                `json:"installed_version_code"`
+	UpdateAvailable      bool               `json:"update_available"`
+	OTAID                string             `json:"ota_id,omitempty"`
+	VersionName          string             `json:"version_name,omitempty"`
+	VersionCode          int                `json:"version_code,omitempty"`
+	URL                  string             `json:"url,omitempty"`
+	SHA256               string             `json:"sha256,omitempty"`
+	SizeBytes            int64              `json:"size_bytes,omitempty"`
+	SigningCertSHA256    string             `json:"signing_cert_sha256,omitempty"`
+	Mirrors              []string           `json:"mirrors,omitempty"`
+	Patch                *ManifestPatch     `json:"patch,omitempty"`
+	Artifacts            []ManifestArtifact `json:"artifacts,omitempty"`
+	IssuedAt             time.Time          `json:"issued_at"`
+	// ExpiresAt bounds how long the manifest may be trusted, so an old
+	// response cannot be replayed to hold a device back.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ManifestPatch is the offered patch of an update manifest.
+type ManifestPatch struct {
+	FromSHA256 string `json:"from_sha256"`
+	ToSHA256   string `json:"to_sha256"`
+	URL        string `json:"url"`
+	SHA256     string `json:"sha256,omitempty"`
+	SizeBytes  int64  `json:"size_bytes,omitempty"`
+}
+
+// ManifestArtifact is an offered artifact of an update manifest.
+type ManifestArtifact struct {
+	SplitName string `json:"split_name,omitempty"`
+	ABI       string `json:"abi,omitempty"`
+	Density   int    `json:"density,omitempty"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// ManifestSignature is a base64 encoded detached signature of a manifest.
+type ManifestSignature struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature"`
+}
+
+// ManifestKey is a base64 encoded public key manifests are signed with.
+type ManifestKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
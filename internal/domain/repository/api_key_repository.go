@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	Get(ctx context.Context, id string) (entity.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (entity.APIKey, bool, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, id string) (entity.APIKey, error)
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
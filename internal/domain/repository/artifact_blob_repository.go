@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+// ArtifactBlobRepository counts the references to stored blobs. Lock and
+// AddRef must run in a transaction, which holds the blob's row lock until it
+// ends.
+type ArtifactBlobRepository interface {
+	// Lock locks the blob's row, creating it without references if it does not exist.
+	Lock(ctx context.Context, blob entity.ArtifactBlob) (entity.ArtifactBlob, error)
+	// AddRef adds a reference to a locked blob.
+	AddRef(ctx context.Context, sha256 string) (entity.ArtifactBlob, error)
+	// Release locks the blob, drops a reference and deletes it when none remain.
+	// It reports whether the blob was deleted; releasing an unknown blob does nothing.
+	Release(ctx context.Context, sha256 string) (entity.ArtifactBlob, bool, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ArtifactObject is an opened stored artifact.
+type ArtifactObject struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// ArtifactStorage stores release artifacts such as APK files.
+type ArtifactStorage interface {
+	Save(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (*ArtifactObject, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry entity.AuditEntry) (entity.AuditEntry, error)
+	// List returns up to limit entries matching filter, newest first, from
+	// the entry before the one with ID cursor when cursor is positive. It also
+	// returns the cursor of the next page, zero on the last page, and the
+	// number of matching entries.
+	List(ctx context.Context, filter entity.AuditFilter, cursor int64, limit int) ([]entity.AuditEntry, int64, int64, error)
+}
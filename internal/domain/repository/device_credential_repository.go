@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type EnrollmentTokenRepository interface {
+	Create(ctx context.Context, token entity.EnrollmentToken) (entity.EnrollmentToken, error)
+	GetByPrefix(ctx context.Context, prefix string) (entity.EnrollmentToken, bool, error)
+}
+
+type DeviceCredentialRepository interface {
+	// Enroll marks the enrollment token used by the device and issues the
+	// device's credential, revoking any it held before for the same app. It
+	// reports false when the token was already used or has expired.
+	Enroll(ctx context.Context, tokenID string, credential entity.DeviceCredential) (entity.DeviceCredential, bool, error)
+	Get(ctx context.Context, id string) (entity.DeviceCredential, error)
+	GetByPrefix(ctx context.Context, prefix string) (entity.DeviceCredential, bool, error)
+	// List returns the credentials of the app's devices, or of every device
+	// when appID is empty, newest first.
+	List(ctx context.Context, appID string) ([]entity.DeviceCredential, error)
+	Revoke(ctx context.Context, id string) (entity.DeviceCredential, error)
+	TouchLastSeen(ctx context.Context, id string, seenAt time.Time) error
+}
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type DeviceInstallRepository interface {
+	Record(ctx context.Context, install entity.DeviceInstall) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type DownloadStatsRepository interface {
+	Record(ctx context.Context, otaID string, bytesServed int64, completed bool) error
+	Get(ctx context.Context, otaID string) (entity.DownloadStats, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type OTAArtifactRepository interface {
+	Create(ctx context.Context, artifact entity.OTAArtifact) (entity.OTAArtifact, error)
+	Get(ctx context.Context, id string) (entity.OTAArtifact, error)
+	ListByOTA(ctx context.Context, otaID string) ([]entity.OTAArtifact, error)
+	Delete(ctx context.Context, id string) error
+}
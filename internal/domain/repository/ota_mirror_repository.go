@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type OTAMirrorRepository interface {
+	Create(ctx context.Context, mirror entity.OTAMirror) (entity.OTAMirror, error)
+	Get(ctx context.Context, id string) (entity.OTAMirror, error)
+	// ListByOTA returns the mirrors of a release in priority order.
+	ListByOTA(ctx context.Context, otaID string) ([]entity.OTAMirror, error)
+	// ListPublished returns the mirrors of every published release.
+	ListPublished(ctx context.Context) ([]entity.OTAMirror, error)
+	SetPriority(ctx context.Context, id string, priority int) (entity.OTAMirror, error)
+	// UpdateHealth stores the outcome of a health check.
+	UpdateHealth(ctx context.Context, mirror entity.OTAMirror) error
+	Delete(ctx context.Context, id string) error
+}
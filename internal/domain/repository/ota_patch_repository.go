@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type OTAPatchRepository interface {
+	// Enqueue creates a pending patch unless one between the same releases exists.
+	Enqueue(ctx context.Context, patch entity.OTAPatch) error
+	// ClaimNext reserves the oldest pending or failed patch that is due and has
+	// been tried fewer than maxAttempts times, counting the attempt. Other
+	// workers skip it until the lease ends.
+	ClaimNext(ctx context.Context, now time.Time, lease time.Duration, maxAttempts int) (entity.OTAPatch, bool, error)
+	// Complete stores the outcome of a claimed attempt. It reports false when
+	// the patch was deleted or claimed again since.
+	Complete(ctx context.Context, patch entity.OTAPatch) (bool, error)
+	Get(ctx context.Context, id string) (entity.OTAPatch, error)
+	ListByOTA(ctx context.Context, otaID string) ([]entity.OTAPatch, error)
+	ListInvolving(ctx context.Context, otaID string) ([]entity.OTAPatch, error)
+	FindReady(ctx context.Context, otaID string, fromSHA256 string) (entity.OTAPatch, bool, error)
+	Delete(ctx context.Context, id string) error
+}
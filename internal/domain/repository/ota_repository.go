@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"launcherbackend_api/internal/domain/entity"
 )
@@ -9,7 +10,21 @@ import (
 type OTARepository interface {
 	Create(ctx context.Context, ota entity.OTA) (entity.OTA, error)
 	Get(ctx context.Context, id string, appID string) (entity.OTA, string, error)
-	GetAll(ctx context.Context, cursor string, limit int) ([]entity.OTA, string, int64, error)
+	GetLatest(ctx context.Context, appID string, payloadType string) (entity.OTA, bool, error)
+	ListPrevious(ctx context.Context, appID string, payloadType string, beforeVersionCode int, limit int) ([]entity.OTA, error)
+	// ListHistory returns the newest limit releases ever published with a
+	// version code above afterVersionCode and up to upToVersionCode, oldest first.
+	ListHistory(ctx context.Context, appID string, payloadType string, afterVersionCode int, upToVersionCode int, limit int) ([]entity.OTA, error)
+	ListRetentionCandidates(ctx context.Context, appID string, keepLast int, installedSince time.Time) ([]entity.OTA, error)
+	ListAppIDs(ctx context.Context) ([]string, error)
+	ListPublished(ctx context.Context, appID string) ([]entity.OTA, error)
+	// GetAll pages through the releases of appIDs, or of every app when appIDs is nil.
+	GetAll(ctx context.Context, appIDs []string, cursor string, limit int) ([]entity.OTA, string, int64, error)
 	Update(ctx context.Context, ota entity.OTA) (entity.OTA, error)
+	SetPinned(ctx context.Context, id string, pinned bool) (entity.OTA, error)
+	AcknowledgePermissions(ctx context.Context, id string, acknowledgedBy string) (entity.OTA, bool, error)
+	// Archive archives a published release and reports whether it did;
+	// pinned releases are left alone.
+	Archive(ctx context.Context, id string) (bool, error)
 	Delete(ctx context.Context, id string) error
 }
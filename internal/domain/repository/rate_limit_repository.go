@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitRepository keeps token buckets in shared state, so every instance
+// of the API draws from the same buckets.
+type RateLimitRepository interface {
+	// Take takes a token from the bucket of key, which holds up to burst
+	// tokens and refills at rate tokens a second; a new bucket starts full.
+	// When the bucket is empty, it returns false and how long until it holds
+	// a token again.
+	Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error)
+	// DeleteIdle deletes the buckets untouched for longer than idle.
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type ReleaseNoteRepository interface {
+	Upsert(ctx context.Context, note entity.ReleaseNote) (entity.ReleaseNote, error)
+	ListByOTA(ctx context.Context, otaID string) ([]entity.ReleaseNote, error)
+	Delete(ctx context.Context, otaID string, locale string) error
+}
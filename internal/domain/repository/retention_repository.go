@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type RetentionPolicyRepository interface {
+	Get(ctx context.Context, appID string) (entity.RetentionPolicy, bool, error)
+	Upsert(ctx context.Context, policy entity.RetentionPolicy) (entity.RetentionPolicy, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type RoleBindingRepository interface {
+	Create(ctx context.Context, binding entity.RoleBinding) (entity.RoleBinding, error)
+	ListByAPIKey(ctx context.Context, apiKeyID string) ([]entity.RoleBinding, error)
+	Delete(ctx context.Context, apiKeyID string, id string) error
+}
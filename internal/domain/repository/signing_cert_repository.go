@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type SigningCertRepository interface {
+	Create(ctx context.Context, cert entity.SigningCertificate) (entity.SigningCertificate, error)
+	Get(ctx context.Context, id string) (entity.SigningCertificate, error)
+	ListByApp(ctx context.Context, appID string) ([]entity.SigningCertificate, error)
+	Activate(ctx context.Context, id string) (entity.SigningCertificate, error)
+	Delete(ctx context.Context, id string) error
+}
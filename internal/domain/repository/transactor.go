@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// Transactor runs work in a database transaction. Repositories called with
+// the context passed to fn take part in the transaction.
+type Transactor interface {
+	// WithinTx commits when fn succeeds and rolls back otherwise. Nested
+	// calls join the outer transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+type TUFMetadataRepository interface {
+	// Lock serializes the writers of an app's metadata until the surrounding
+	// transaction ends.
+	Lock(ctx context.Context, appID string) error
+	// Create stores a new version of a role's metadata. Earlier versions are
+	// removed, except those of the root role, which clients walk through.
+	Create(ctx context.Context, metadata entity.TUFMetadata) error
+	GetLatest(ctx context.Context, appID string, role string) (entity.TUFMetadata, bool, error)
+	Get(ctx context.Context, appID string, role string, version int) (entity.TUFMetadata, error)
+	ListAppIDs(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const apiKeyColumns = "id, name, prefix, salt, hash, created_at, last_used_at, revoked_at"
+
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyRepository(db *sql.DB) repo.APIKeyRepository {
+	return &PostgresAPIKeyRepository{
+		db: db,
+	}
+}
+
+func scanAPIKey(row rowScanner) (entity.APIKey, error) {
+	var key entity.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Salt,
+		&key.Hash,
+		&key.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	); err != nil {
+		return entity.APIKey{}, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, salt, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + apiKeyColumns
+
+	if key.ID == "" {
+		key.ID = uuid.NewString()
+	}
+	key.CreatedAt = time.Now()
+
+	created, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, key.ID, key.Name, key.Prefix, key.Salt, key.Hash, key.CreatedAt))
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresAPIKeyRepository) Get(ctx context.Context, id string) (entity.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.APIKey{}, fmt.Errorf("api key not found: %w", err)
+		}
+		return entity.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (entity.APIKey, bool, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, prefix))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.APIKey{}, false, nil
+		}
+		return entity.APIKey{}, false, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, true, nil
+}
+
+func (r *PostgresAPIKeyRepository) List(ctx context.Context) ([]entity.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []entity.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id string) (entity.APIKey, error) {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1 RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, id, time.Now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.APIKey{}, fmt.Errorf("api key not found: %w", err)
+		}
+		return entity.APIKey{}, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, usedAt); err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+	return nil
+}
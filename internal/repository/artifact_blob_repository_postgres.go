@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const artifactBlobColumns = "sha256, storage_key, size_bytes, ref_count, created_at"
+
+type PostgresArtifactBlobRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresArtifactBlobRepository(db *sql.DB) repo.ArtifactBlobRepository {
+	return &PostgresArtifactBlobRepository{
+		db: db,
+	}
+}
+
+func scanArtifactBlob(row rowScanner) (entity.ArtifactBlob, error) {
+	var blob entity.ArtifactBlob
+	if err := row.Scan(&blob.SHA256, &blob.StorageKey, &blob.SizeBytes, &blob.RefCount, &blob.CreatedAt); err != nil {
+		return entity.ArtifactBlob{}, err
+	}
+	return blob, nil
+}
+
+func (r *PostgresArtifactBlobRepository) Lock(ctx context.Context, blob entity.ArtifactBlob) (entity.ArtifactBlob, error) {
+	// A concurrent insert of the same digest waits here until its transaction ends
+	insert := `
+		INSERT INTO artifact_blobs (sha256, storage_key, size_bytes, ref_count, created_at)
+		VALUES ($1, $2, $3, 0, $4)
+		ON CONFLICT (sha256) DO NOTHING`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, insert, blob.SHA256, blob.StorageKey, blob.SizeBytes, time.Now()); err != nil {
+		return entity.ArtifactBlob{}, fmt.Errorf("failed to create artifact blob: %w", err)
+	}
+
+	query := `SELECT ` + artifactBlobColumns + ` FROM artifact_blobs WHERE sha256 = $1 FOR UPDATE`
+
+	locked, err := scanArtifactBlob(conn(ctx, r.db).QueryRowContext(ctx, query, blob.SHA256))
+	if err != nil {
+		return entity.ArtifactBlob{}, fmt.Errorf("failed to lock artifact blob: %w", err)
+	}
+
+	return locked, nil
+}
+
+func (r *PostgresArtifactBlobRepository) AddRef(ctx context.Context, sha256 string) (entity.ArtifactBlob, error) {
+	query := `UPDATE artifact_blobs SET ref_count = ref_count + 1 WHERE sha256 = $1 RETURNING ` + artifactBlobColumns
+
+	blob, err := scanArtifactBlob(conn(ctx, r.db).QueryRowContext(ctx, query, sha256))
+	if err != nil {
+		return entity.ArtifactBlob{}, fmt.Errorf("failed to reference artifact blob: %w", err)
+	}
+
+	return blob, nil
+}
+
+// Release joins the transaction in ctx, if any, so the caller can delete the
+// blob's file while its row is still locked.
+func (r *PostgresArtifactBlobRepository) Release(ctx context.Context, sha256 string) (entity.ArtifactBlob, bool, error) {
+	var blob entity.ArtifactBlob
+	var removed bool
+	err := NewPostgresTransactor(r.db).WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		blob, removed, err = r.release(ctx, sha256)
+		return err
+	})
+	return blob, removed, err
+}
+
+func (r *PostgresArtifactBlobRepository) release(ctx context.Context, sha256 string) (entity.ArtifactBlob, bool, error) {
+	query := `SELECT ` + artifactBlobColumns + ` FROM artifact_blobs WHERE sha256 = $1 FOR UPDATE`
+
+	blob, err := scanArtifactBlob(conn(ctx, r.db).QueryRowContext(ctx, query, sha256))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.ArtifactBlob{}, false, nil
+		}
+		return entity.ArtifactBlob{}, false, fmt.Errorf("failed to lock artifact blob: %w", err)
+	}
+
+	if blob.RefCount <= 1 {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM artifact_blobs WHERE sha256 = $1", sha256); err != nil {
+			return entity.ArtifactBlob{}, false, fmt.Errorf("failed to delete artifact blob: %w", err)
+		}
+		blob.RefCount = 0
+		return blob, true, nil
+	}
+
+	update := `UPDATE artifact_blobs SET ref_count = ref_count - 1 WHERE sha256 = $1 RETURNING ` + artifactBlobColumns
+
+	blob, err = scanArtifactBlob(conn(ctx, r.db).QueryRowContext(ctx, update, sha256))
+	if err != nil {
+		return entity.ArtifactBlob{}, false, fmt.Errorf("failed to release artifact blob: %w", err)
+	}
+
+	return blob, false, nil
+}
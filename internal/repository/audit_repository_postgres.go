@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const auditColumns = "id, action, resource_type, resource_id, app_id, actor_id, actor_name, actor_method, source_ip, request_id, changes, created_at"
+
+type PostgresAuditRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAuditRepository(db *sql.DB) repo.AuditRepository {
+	return &PostgresAuditRepository{
+		db: db,
+	}
+}
+
+func scanAuditEntry(row rowScanner) (entity.AuditEntry, error) {
+	var entry entity.AuditEntry
+	var changes []byte
+
+	if err := row.Scan(
+		&entry.ID,
+		&entry.Action,
+		&entry.ResourceType,
+		&entry.ResourceID,
+		&entry.AppID,
+		&entry.ActorID,
+		&entry.ActorName,
+		&entry.ActorMethod,
+		&entry.SourceIP,
+		&entry.RequestID,
+		&changes,
+		&entry.CreatedAt,
+	); err != nil {
+		return entity.AuditEntry{}, err
+	}
+
+	if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+		return entity.AuditEntry{}, fmt.Errorf("failed to decode audit changes: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (r *PostgresAuditRepository) Create(ctx context.Context, entry entity.AuditEntry) (entity.AuditEntry, error) {
+	query := `
+		INSERT INTO audit_log (action, resource_type, resource_id, app_id, actor_id, actor_name, actor_method, source_ip, request_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + auditColumns
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return entity.AuditEntry{}, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	created, err := scanAuditEntry(conn(ctx, r.db).QueryRowContext(ctx, query,
+		entry.Action,
+		entry.ResourceType,
+		entry.ResourceID,
+		entry.AppID,
+		entry.ActorID,
+		entry.ActorName,
+		entry.ActorMethod,
+		entry.SourceIP,
+		entry.RequestID,
+		changes,
+		entry.CreatedAt,
+	))
+	if err != nil {
+		return entity.AuditEntry{}, fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresAuditRepository) List(ctx context.Context, filter entity.AuditFilter, cursor int64, limit int) ([]entity.AuditEntry, int64, int64, error) {
+	var conditions []string
+	params := []interface{}{}
+	add := func(condition string, value interface{}) {
+		params = append(params, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(params)))
+	}
+
+	if filter.AppIDs != nil {
+		add("app_id = ANY($%d)", pq.Array(filter.AppIDs))
+	}
+	for _, field := range []struct{ column, value string }{
+		{"app_id", filter.AppID},
+		{"resource_type", filter.ResourceType},
+		{"resource_id", filter.ResourceID},
+		{"action", filter.Action},
+		{"actor_id", filter.ActorID},
+		{"request_id", filter.RequestID},
+	} {
+		if field.value != "" {
+			add(field.column+" = $%d", field.value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < $%d", filter.Until)
+	}
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM audit_log"
+	if len(conditions) > 0 {
+		countQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, params...).Scan(&total); err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	if cursor > 0 {
+		add("id < $%d", cursor)
+	}
+	query := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(params)+1)
+	params = append(params, limit+1)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []entity.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	var nextCursor int64
+	if len(entries) > limit {
+		nextCursor = entries[limit-1].ID
+		entries = entries[:limit]
+	}
+
+	return entries, nextCursor, total, nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const deviceCredentialColumns = "id, device_id, app_id, enrollment_token_id, prefix, salt, hash, created_at, last_seen_at, revoked_at"
+
+type PostgresDeviceCredentialRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDeviceCredentialRepository(db *sql.DB) repo.DeviceCredentialRepository {
+	return &PostgresDeviceCredentialRepository{
+		db: db,
+	}
+}
+
+func scanDeviceCredential(row rowScanner) (entity.DeviceCredential, error) {
+	var credential entity.DeviceCredential
+	var tokenID sql.NullString
+	var lastSeenAt, revokedAt sql.NullTime
+	if err := row.Scan(
+		&credential.ID,
+		&credential.DeviceID,
+		&credential.AppID,
+		&tokenID,
+		&credential.Prefix,
+		&credential.Salt,
+		&credential.Hash,
+		&credential.CreatedAt,
+		&lastSeenAt,
+		&revokedAt,
+	); err != nil {
+		return entity.DeviceCredential{}, err
+	}
+	credential.EnrollmentTokenID = tokenID.String
+	if lastSeenAt.Valid {
+		credential.LastSeenAt = &lastSeenAt.Time
+	}
+	if revokedAt.Valid {
+		credential.RevokedAt = &revokedAt.Time
+	}
+	return credential, nil
+}
+
+func (r *PostgresDeviceCredentialRepository) Enroll(ctx context.Context, tokenID string, credential entity.DeviceCredential) (entity.DeviceCredential, bool, error) {
+	var (
+		created  entity.DeviceCredential
+		enrolled bool
+	)
+	err := NewPostgresTransactor(r.db).WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, enrolled, err = r.enroll(ctx, tokenID, credential)
+		return err
+	})
+	return created, enrolled, err
+}
+
+func (r *PostgresDeviceCredentialRepository) enroll(ctx context.Context, tokenID string, credential entity.DeviceCredential) (entity.DeviceCredential, bool, error) {
+	now := time.Now()
+
+	// Only one device can claim the token
+	consume := `
+		UPDATE enrollment_tokens SET used_at = $2, used_by_device_id = $3
+		WHERE id = $1 AND used_at IS NULL AND expires_at > $2
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, consume, tokenID, now, credential.DeviceID)
+	if err != nil {
+		return entity.DeviceCredential{}, false, fmt.Errorf("failed to use enrollment token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return entity.DeviceCredential{}, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return entity.DeviceCredential{}, false, nil
+	}
+
+	// Device IDs are only unique within an app
+	revoke := `
+		UPDATE device_credentials SET revoked_at = $3
+		WHERE device_id = $1 AND app_id = $2 AND revoked_at IS NULL
+	`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, revoke, credential.DeviceID, credential.AppID, now); err != nil {
+		return entity.DeviceCredential{}, false, fmt.Errorf("failed to revoke previous device credential: %w", err)
+	}
+
+	insert := `
+		INSERT INTO device_credentials (id, device_id, app_id, enrollment_token_id, prefix, salt, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + deviceCredentialColumns
+
+	if credential.ID == "" {
+		credential.ID = uuid.NewString()
+	}
+	credential.CreatedAt = now
+
+	created, err := scanDeviceCredential(conn(ctx, r.db).QueryRowContext(ctx, insert,
+		credential.ID, credential.DeviceID, credential.AppID, tokenID, credential.Prefix, credential.Salt, credential.Hash, credential.CreatedAt,
+	))
+	if err != nil {
+		return entity.DeviceCredential{}, false, fmt.Errorf("failed to create device credential: %w", err)
+	}
+
+	return created, true, nil
+}
+
+func (r *PostgresDeviceCredentialRepository) Get(ctx context.Context, id string) (entity.DeviceCredential, error) {
+	query := `SELECT ` + deviceCredentialColumns + ` FROM device_credentials WHERE id = $1`
+
+	credential, err := scanDeviceCredential(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.DeviceCredential{}, fmt.Errorf("device credential not found: %w", err)
+		}
+		return entity.DeviceCredential{}, fmt.Errorf("failed to get device credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+func (r *PostgresDeviceCredentialRepository) GetByPrefix(ctx context.Context, prefix string) (entity.DeviceCredential, bool, error) {
+	query := `SELECT ` + deviceCredentialColumns + ` FROM device_credentials WHERE prefix = $1`
+
+	credential, err := scanDeviceCredential(conn(ctx, r.db).QueryRowContext(ctx, query, prefix))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.DeviceCredential{}, false, nil
+		}
+		return entity.DeviceCredential{}, false, fmt.Errorf("failed to get device credential: %w", err)
+	}
+
+	return credential, true, nil
+}
+
+func (r *PostgresDeviceCredentialRepository) List(ctx context.Context, appID string) ([]entity.DeviceCredential, error) {
+	query := `SELECT ` + deviceCredentialColumns + ` FROM device_credentials`
+	params := []interface{}{}
+	if appID != "" {
+		query += " WHERE app_id = $1"
+		params = append(params, appID)
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []entity.DeviceCredential
+	for rows.Next() {
+		credential, err := scanDeviceCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device credential row: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *PostgresDeviceCredentialRepository) Revoke(ctx context.Context, id string) (entity.DeviceCredential, error) {
+	query := `UPDATE device_credentials SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1 RETURNING ` + deviceCredentialColumns
+
+	credential, err := scanDeviceCredential(conn(ctx, r.db).QueryRowContext(ctx, query, id, time.Now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.DeviceCredential{}, fmt.Errorf("device credential not found: %w", err)
+		}
+		return entity.DeviceCredential{}, fmt.Errorf("failed to revoke device credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+func (r *PostgresDeviceCredentialRepository) TouchLastSeen(ctx context.Context, id string, seenAt time.Time) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE device_credentials SET last_seen_at = $2 WHERE id = $1", id, seenAt); err != nil {
+		return fmt.Errorf("failed to update device credential last seen: %w", err)
+	}
+	return nil
+}
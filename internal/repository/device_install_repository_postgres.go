@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+type PostgresDeviceInstallRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDeviceInstallRepository(db *sql.DB) repo.DeviceInstallRepository {
+	return &PostgresDeviceInstallRepository{
+		db: db,
+	}
+}
+
+func (r *PostgresDeviceInstallRepository) Record(ctx context.Context, install entity.DeviceInstall) error {
+	query := `
+		INSERT INTO device_installs (device_id, app_id, payload_type, version_code, sha256, reported_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (device_id, app_id, payload_type) DO UPDATE SET
+			version_code = EXCLUDED.version_code,
+			sha256 = EXCLUDED.sha256,
+			reported_at = EXCLUDED.reported_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, install.DeviceID, install.AppID, install.PayloadType, install.VersionCode, nullableString(install.SHA256), install.ReportedAt); err != nil {
+		return fmt.Errorf("failed to record device install: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+type PostgresDownloadStatsRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDownloadStatsRepository(db *sql.DB) repo.DownloadStatsRepository {
+	return &PostgresDownloadStatsRepository{
+		db: db,
+	}
+}
+
+func (r *PostgresDownloadStatsRepository) Record(ctx context.Context, otaID string, bytesServed int64, completed bool) error {
+	query := `
+		INSERT INTO ota_download_stats (ota_id, requests, completed_downloads, bytes_served, last_served_at)
+		VALUES ($1, 1, $2, $3, $4)
+		ON CONFLICT (ota_id) DO UPDATE SET
+			requests = ota_download_stats.requests + 1,
+			completed_downloads = ota_download_stats.completed_downloads + EXCLUDED.completed_downloads,
+			bytes_served = ota_download_stats.bytes_served + EXCLUDED.bytes_served,
+			last_served_at = EXCLUDED.last_served_at
+	`
+
+	completedCount := 0
+	if completed {
+		completedCount = 1
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, otaID, completedCount, bytesServed, time.Now()); err != nil {
+		return fmt.Errorf("failed to record download: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresDownloadStatsRepository) Get(ctx context.Context, otaID string) (entity.DownloadStats, error) {
+	query := `
+		SELECT ota_id, requests, completed_downloads, bytes_served, last_served_at
+		FROM ota_download_stats
+		WHERE ota_id = $1
+	`
+
+	stats := entity.DownloadStats{OTAID: otaID}
+	var lastServedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, otaID).Scan(
+		&stats.OTAID,
+		&stats.Requests,
+		&stats.CompletedDownloads,
+		&stats.BytesServed,
+		&lastServedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return stats, nil
+		}
+		return entity.DownloadStats{}, fmt.Errorf("failed to get download stats: %w", err)
+	}
+
+	if lastServedAt.Valid {
+		stats.LastServedAt = &lastServedAt.Time
+	}
+
+	return stats, nil
+}
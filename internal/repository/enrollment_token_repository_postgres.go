@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const enrollmentTokenColumns = "id, app_id, prefix, salt, hash, created_by, created_at, expires_at, used_at, used_by_device_id"
+
+type PostgresEnrollmentTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresEnrollmentTokenRepository(db *sql.DB) repo.EnrollmentTokenRepository {
+	return &PostgresEnrollmentTokenRepository{
+		db: db,
+	}
+}
+
+func scanEnrollmentToken(row rowScanner) (entity.EnrollmentToken, error) {
+	var token entity.EnrollmentToken
+	var usedAt sql.NullTime
+	var usedBy sql.NullString
+	if err := row.Scan(
+		&token.ID,
+		&token.AppID,
+		&token.Prefix,
+		&token.Salt,
+		&token.Hash,
+		&token.CreatedBy,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&usedAt,
+		&usedBy,
+	); err != nil {
+		return entity.EnrollmentToken{}, err
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	token.UsedByDeviceID = usedBy.String
+	return token, nil
+}
+
+func (r *PostgresEnrollmentTokenRepository) Create(ctx context.Context, token entity.EnrollmentToken) (entity.EnrollmentToken, error) {
+	query := `
+		INSERT INTO enrollment_tokens (id, app_id, prefix, salt, hash, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + enrollmentTokenColumns
+
+	if token.ID == "" {
+		token.ID = uuid.NewString()
+	}
+	token.CreatedAt = time.Now()
+
+	created, err := scanEnrollmentToken(conn(ctx, r.db).QueryRowContext(ctx, query,
+		token.ID, token.AppID, token.Prefix, token.Salt, token.Hash, token.CreatedBy, token.CreatedAt, token.ExpiresAt,
+	))
+	if err != nil {
+		return entity.EnrollmentToken{}, fmt.Errorf("failed to create enrollment token: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresEnrollmentTokenRepository) GetByPrefix(ctx context.Context, prefix string) (entity.EnrollmentToken, bool, error) {
+	query := `SELECT ` + enrollmentTokenColumns + ` FROM enrollment_tokens WHERE prefix = $1`
+
+	token, err := scanEnrollmentToken(conn(ctx, r.db).QueryRowContext(ctx, query, prefix))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.EnrollmentToken{}, false, nil
+		}
+		return entity.EnrollmentToken{}, false, fmt.Errorf("failed to get enrollment token: %w", err)
+	}
+
+	return token, true, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const otaArtifactColumns = "id, ota_id, split_name, abi, density, sha256, size_bytes, url, storage_key, created_at"
+
+type PostgresOTAArtifactRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOTAArtifactRepository(db *sql.DB) repo.OTAArtifactRepository {
+	return &PostgresOTAArtifactRepository{
+		db: db,
+	}
+}
+
+func scanOTAArtifact(row rowScanner) (entity.OTAArtifact, error) {
+	var artifact entity.OTAArtifact
+	if err := row.Scan(
+		&artifact.ID,
+		&artifact.OTAID,
+		&artifact.SplitName,
+		&artifact.ABI,
+		&artifact.Density,
+		&artifact.SHA256,
+		&artifact.SizeBytes,
+		&artifact.URL,
+		&artifact.StorageKey,
+		&artifact.CreatedAt,
+	); err != nil {
+		return entity.OTAArtifact{}, err
+	}
+	return artifact, nil
+}
+
+func (r *PostgresOTAArtifactRepository) Create(ctx context.Context, artifact entity.OTAArtifact) (entity.OTAArtifact, error) {
+	query := `
+		INSERT INTO ota_artifacts (id, ota_id, split_name, abi, density, sha256, size_bytes, url, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + otaArtifactColumns
+
+	if artifact.ID == "" {
+		artifact.ID = uuid.NewString()
+	}
+	artifact.CreatedAt = time.Now()
+
+	created, err := scanOTAArtifact(conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		artifact.ID,
+		artifact.OTAID,
+		artifact.SplitName,
+		artifact.ABI,
+		artifact.Density,
+		artifact.SHA256,
+		artifact.SizeBytes,
+		artifact.URL,
+		artifact.StorageKey,
+		artifact.CreatedAt,
+	))
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return entity.OTAArtifact{}, fmt.Errorf("artifact for this split, ABI and density already exists: %w", err)
+			}
+		}
+		return entity.OTAArtifact{}, fmt.Errorf("failed to create ota artifact: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresOTAArtifactRepository) Get(ctx context.Context, id string) (entity.OTAArtifact, error) {
+	query := `SELECT ` + otaArtifactColumns + ` FROM ota_artifacts WHERE id = $1`
+
+	artifact, err := scanOTAArtifact(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTAArtifact{}, fmt.Errorf("ota artifact not found: %w", err)
+		}
+		return entity.OTAArtifact{}, fmt.Errorf("failed to get ota artifact: %w", err)
+	}
+
+	return artifact, nil
+}
+
+func (r *PostgresOTAArtifactRepository) ListByOTA(ctx context.Context, otaID string) ([]entity.OTAArtifact, error) {
+	query := `SELECT ` + otaArtifactColumns + ` FROM ota_artifacts WHERE ota_id = $1 ORDER BY split_name, abi, density`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, otaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ota artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	var artifacts []entity.OTAArtifact
+	for rows.Next() {
+		artifact, err := scanOTAArtifact(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota artifact row: %w", err)
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	return artifacts, rows.Err()
+}
+
+func (r *PostgresOTAArtifactRepository) Delete(ctx context.Context, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM ota_artifacts WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete ota artifact: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("ota artifact not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const otaMirrorColumns = "id, ota_id, url, priority, healthy, consecutive_failures, last_error, last_checked_at, created_at"
+
+type PostgresOTAMirrorRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOTAMirrorRepository(db *sql.DB) repo.OTAMirrorRepository {
+	return &PostgresOTAMirrorRepository{
+		db: db,
+	}
+}
+
+func scanOTAMirror(row rowScanner) (entity.OTAMirror, error) {
+	var (
+		mirror    entity.OTAMirror
+		lastError sql.NullString
+		checkedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&mirror.ID,
+		&mirror.OTAID,
+		&mirror.URL,
+		&mirror.Priority,
+		&mirror.Healthy,
+		&mirror.ConsecutiveFailures,
+		&lastError,
+		&checkedAt,
+		&mirror.CreatedAt,
+	); err != nil {
+		return entity.OTAMirror{}, err
+	}
+	mirror.LastError = lastError.String
+	if checkedAt.Valid {
+		mirror.LastCheckedAt = &checkedAt.Time
+	}
+	return mirror, nil
+}
+
+func (r *PostgresOTAMirrorRepository) Create(ctx context.Context, mirror entity.OTAMirror) (entity.OTAMirror, error) {
+	query := `
+		INSERT INTO ota_mirrors (id, ota_id, url, priority, healthy, consecutive_failures, created_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6)
+		RETURNING ` + otaMirrorColumns
+
+	if mirror.ID == "" {
+		mirror.ID = uuid.NewString()
+	}
+	mirror.CreatedAt = time.Now()
+
+	created, err := scanOTAMirror(conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		mirror.ID,
+		mirror.OTAID,
+		mirror.URL,
+		mirror.Priority,
+		mirror.Healthy,
+		mirror.CreatedAt,
+	))
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return entity.OTAMirror{}, fmt.Errorf("mirror with this URL already exists: %w", err)
+			}
+		}
+		return entity.OTAMirror{}, fmt.Errorf("failed to create ota mirror: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresOTAMirrorRepository) Get(ctx context.Context, id string) (entity.OTAMirror, error) {
+	query := `SELECT ` + otaMirrorColumns + ` FROM ota_mirrors WHERE id = $1`
+
+	mirror, err := scanOTAMirror(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTAMirror{}, fmt.Errorf("ota mirror not found: %w", err)
+		}
+		return entity.OTAMirror{}, fmt.Errorf("failed to get ota mirror: %w", err)
+	}
+
+	return mirror, nil
+}
+
+func (r *PostgresOTAMirrorRepository) ListByOTA(ctx context.Context, otaID string) ([]entity.OTAMirror, error) {
+	query := `SELECT ` + otaMirrorColumns + ` FROM ota_mirrors WHERE ota_id = $1 ORDER BY priority, created_at`
+	return r.list(ctx, query, otaID)
+}
+
+func (r *PostgresOTAMirrorRepository) ListPublished(ctx context.Context) ([]entity.OTAMirror, error) {
+	query := `
+		SELECT ` + otaMirrorColumns + `
+		FROM ota_mirrors
+		WHERE ota_id IN (SELECT id FROM otas WHERE status = 'published')
+		ORDER BY ota_id, priority, created_at`
+	return r.list(ctx, query)
+}
+
+func (r *PostgresOTAMirrorRepository) list(ctx context.Context, query string, args ...any) ([]entity.OTAMirror, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ota mirrors: %w", err)
+	}
+	defer rows.Close()
+
+	var mirrors []entity.OTAMirror
+	for rows.Next() {
+		mirror, err := scanOTAMirror(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota mirror row: %w", err)
+		}
+		mirrors = append(mirrors, mirror)
+	}
+
+	return mirrors, rows.Err()
+}
+
+func (r *PostgresOTAMirrorRepository) SetPriority(ctx context.Context, id string, priority int) (entity.OTAMirror, error) {
+	query := `UPDATE ota_mirrors SET priority = $2 WHERE id = $1 RETURNING ` + otaMirrorColumns
+
+	mirror, err := scanOTAMirror(conn(ctx, r.db).QueryRowContext(ctx, query, id, priority))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTAMirror{}, fmt.Errorf("ota mirror not found: %w", err)
+		}
+		return entity.OTAMirror{}, fmt.Errorf("failed to update ota mirror: %w", err)
+	}
+
+	return mirror, nil
+}
+
+func (r *PostgresOTAMirrorRepository) UpdateHealth(ctx context.Context, mirror entity.OTAMirror) error {
+	query := `
+		UPDATE ota_mirrors
+		SET healthy = $2, consecutive_failures = $3, last_error = $4, last_checked_at = $5
+		WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		mirror.ID,
+		mirror.Healthy,
+		mirror.ConsecutiveFailures,
+		nullableString(mirror.LastError),
+		mirror.LastCheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update ota mirror health: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOTAMirrorRepository) Delete(ctx context.Context, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM ota_mirrors WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete ota mirror: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("ota mirror not found")
+	}
+
+	return nil
+}
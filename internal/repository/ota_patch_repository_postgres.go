@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const otaPatchColumns = "id, ota_id, from_ota_id, from_version_code, from_sha256, to_sha256, sha256, size_bytes, url, storage_key, status, error, attempts, next_attempt_at, created_at"
+
+type PostgresOTAPatchRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOTAPatchRepository(db *sql.DB) repo.OTAPatchRepository {
+	return &PostgresOTAPatchRepository{
+		db: db,
+	}
+}
+
+func scanOTAPatch(row rowScanner) (entity.OTAPatch, error) {
+	var patch entity.OTAPatch
+	var sha, url, storageKey, errMsg sql.NullString
+	var size sql.NullInt64
+	var nextAttempt sql.NullTime
+
+	if err := row.Scan(
+		&patch.ID,
+		&patch.OTAID,
+		&patch.FromOTAID,
+		&patch.FromVersionCode,
+		&patch.FromSHA256,
+		&patch.ToSHA256,
+		&sha,
+		&size,
+		&url,
+		&storageKey,
+		&patch.Status,
+		&errMsg,
+		&patch.Attempts,
+		&nextAttempt,
+		&patch.CreatedAt,
+	); err != nil {
+		return entity.OTAPatch{}, err
+	}
+
+	patch.SHA256 = sha.String
+	patch.SizeBytes = size.Int64
+	patch.URL = url.String
+	patch.StorageKey = storageKey.String
+	patch.Error = errMsg.String
+	if nextAttempt.Valid {
+		patch.NextAttemptAt = &nextAttempt.Time
+	}
+
+	return patch, nil
+}
+
+func (r *PostgresOTAPatchRepository) Enqueue(ctx context.Context, patch entity.OTAPatch) error {
+	query := `
+		INSERT INTO ota_patches (id, ota_id, from_ota_id, from_version_code, from_sha256, to_sha256, status, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8)
+		ON CONFLICT (ota_id, from_ota_id) DO NOTHING`
+
+	if patch.ID == "" {
+		patch.ID = uuid.NewString()
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		patch.ID,
+		patch.OTAID,
+		patch.FromOTAID,
+		patch.FromVersionCode,
+		patch.FromSHA256,
+		patch.ToSHA256,
+		entity.PatchStatusPending,
+		time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to enqueue ota patch: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOTAPatchRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration, maxAttempts int) (entity.OTAPatch, bool, error) {
+	query := `
+		UPDATE ota_patches SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE id = (
+			SELECT id FROM ota_patches
+			WHERE status IN ($3, $4) AND attempts < $5 AND (next_attempt_at IS NULL OR next_attempt_at <= $1)
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + otaPatchColumns
+
+	patch, err := scanOTAPatch(conn(ctx, r.db).QueryRowContext(ctx, query, now, now.Add(lease), entity.PatchStatusPending, entity.PatchStatusFailed, maxAttempts))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTAPatch{}, false, nil
+		}
+		return entity.OTAPatch{}, false, fmt.Errorf("failed to claim ota patch: %w", err)
+	}
+
+	return patch, true, nil
+}
+
+func (r *PostgresOTAPatchRepository) Complete(ctx context.Context, patch entity.OTAPatch) (bool, error) {
+	query := `
+		UPDATE ota_patches
+		SET sha256 = $3, size_bytes = $4, url = $5, storage_key = $6, status = $7, error = $8, next_attempt_at = $9
+		WHERE id = $1 AND attempts = $2`
+
+	var nextAttempt sql.NullTime
+	if patch.NextAttemptAt != nil {
+		nextAttempt = sql.NullTime{Time: *patch.NextAttemptAt, Valid: true}
+	}
+
+	result, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		patch.ID,
+		patch.Attempts,
+		nullableString(patch.SHA256),
+		sql.NullInt64{Int64: patch.SizeBytes, Valid: patch.SizeBytes > 0},
+		nullableString(patch.URL),
+		nullableString(patch.StorageKey),
+		patch.Status,
+		nullableString(patch.Error),
+		nextAttempt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete ota patch: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *PostgresOTAPatchRepository) Get(ctx context.Context, id string) (entity.OTAPatch, error) {
+	query := `SELECT ` + otaPatchColumns + ` FROM ota_patches WHERE id = $1`
+
+	patch, err := scanOTAPatch(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTAPatch{}, fmt.Errorf("ota patch not found: %w", err)
+		}
+		return entity.OTAPatch{}, fmt.Errorf("failed to get ota patch: %w", err)
+	}
+
+	return patch, nil
+}
+
+func (r *PostgresOTAPatchRepository) ListByOTA(ctx context.Context, otaID string) ([]entity.OTAPatch, error) {
+	query := `SELECT ` + otaPatchColumns + ` FROM ota_patches WHERE ota_id = $1 ORDER BY from_version_code DESC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, otaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ota patches: %w", err)
+	}
+	defer rows.Close()
+
+	var patches []entity.OTAPatch
+	for rows.Next() {
+		patch, err := scanOTAPatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota patch row: %w", err)
+		}
+		patches = append(patches, patch)
+	}
+
+	return patches, rows.Err()
+}
+
+// ListInvolving returns the patches towards or from the given release.
+func (r *PostgresOTAPatchRepository) ListInvolving(ctx context.Context, otaID string) ([]entity.OTAPatch, error) {
+	query := `SELECT ` + otaPatchColumns + ` FROM ota_patches WHERE ota_id = $1 OR from_ota_id = $1`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, otaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ota patches: %w", err)
+	}
+	defer rows.Close()
+
+	var patches []entity.OTAPatch
+	for rows.Next() {
+		patch, err := scanOTAPatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota patch row: %w", err)
+		}
+		patches = append(patches, patch)
+	}
+
+	return patches, rows.Err()
+}
+
+// FindReady returns a verified patch to the given release from an artifact with the given digest.
+func (r *PostgresOTAPatchRepository) FindReady(ctx context.Context, otaID string, fromSHA256 string) (entity.OTAPatch, bool, error) {
+	query := `
+		SELECT ` + otaPatchColumns + `
+		FROM ota_patches
+		WHERE ota_id = $1 AND from_sha256 = $2 AND status = $3
+		ORDER BY size_bytes ASC
+		LIMIT 1
+	`
+
+	patch, err := scanOTAPatch(conn(ctx, r.db).QueryRowContext(ctx, query, otaID, fromSHA256, entity.PatchStatusReady))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTAPatch{}, false, nil
+		}
+		return entity.OTAPatch{}, false, fmt.Errorf("failed to find ota patch: %w", err)
+	}
+
+	return patch, true, nil
+}
+
+func (r *PostgresOTAPatchRepository) Delete(ctx context.Context, id string) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM ota_patches WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete ota patch: %w", err)
+	}
+	return nil
+}
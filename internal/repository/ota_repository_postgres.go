@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +15,8 @@ import (
 	repo "launcherbackend_api/internal/domain/repository"
 )
 
+const otaColumns = "id, app_id, payload_type, version_name, version_code, release_notes, url, sha256, size_bytes, storage_key, manifest, metadata, signing_cert_sha256, dangerous_permissions_added, permissions_acknowledged_at, permissions_acknowledged_by, status, pinned, archived_at, created_at, updated_at"
+
 type PostgresOTARepository struct {
 	db *sql.DB
 }
@@ -23,42 +27,152 @@ func NewPostgresOTARepository(db *sql.DB) repo.OTARepository {
 	}
 }
 
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOTA(row rowScanner) (entity.OTA, error) {
+	var ota entity.OTA
+	var manifest, metadata, permissionsAdded []byte
+	var sha, storageKey, signingCert, acknowledgedBy sql.NullString
+	var size sql.NullInt64
+	var archivedAt, acknowledgedAt sql.NullTime
+
+	if err := row.Scan(
+		&ota.ID,
+		&ota.AppID,
+		&ota.PayloadType,
+		&ota.VersionName,
+		&ota.VersionCode,
+		&ota.ReleaseNotes,
+		&ota.URL,
+		&sha,
+		&size,
+		&storageKey,
+		&manifest,
+		&metadata,
+		&signingCert,
+		&permissionsAdded,
+		&acknowledgedAt,
+		&acknowledgedBy,
+		&ota.Status,
+		&ota.Pinned,
+		&archivedAt,
+		&ota.CreatedAt,
+		&ota.UpdatedAt,
+	); err != nil {
+		return entity.OTA{}, err
+	}
+
+	ota.SHA256 = sha.String
+	ota.SizeBytes = size.Int64
+	ota.StorageKey = storageKey.String
+	ota.SigningCertSHA256 = signingCert.String
+	ota.PermissionsAcknowledgedBy = acknowledgedBy.String
+	if archivedAt.Valid {
+		ota.ArchivedAt = &archivedAt.Time
+	}
+	if acknowledgedAt.Valid {
+		ota.PermissionsAcknowledgedAt = &acknowledgedAt.Time
+	}
+
+	if len(manifest) > 0 {
+		ota.Manifest = &entity.APKManifest{}
+		if err := json.Unmarshal(manifest, ota.Manifest); err != nil {
+			return entity.OTA{}, fmt.Errorf("failed to decode ota manifest: %w", err)
+		}
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &ota.Metadata); err != nil {
+			return entity.OTA{}, fmt.Errorf("failed to decode ota metadata: %w", err)
+		}
+	}
+	if len(permissionsAdded) > 0 {
+		if err := json.Unmarshal(permissionsAdded, &ota.DangerousPermissionsAdded); err != nil {
+			return entity.OTA{}, fmt.Errorf("failed to decode ota permission changes: %w", err)
+		}
+	}
+
+	return ota, nil
+}
+
+// nullableJSON encodes v as JSON, mapping nil pointers to SQL NULL.
+func nullableJSON[T any](v *T) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 func (r *PostgresOTARepository) Create(ctx context.Context, ota entity.OTA) (entity.OTA, error) {
 	query := `
-		INSERT INTO otas (id, app_id, version_name, version_code, release_notes, url, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, app_id, version_name, version_code, release_notes, url, created_at, updated_at
-	`
+		INSERT INTO otas (id, app_id, payload_type, version_name, version_code, release_notes, url, sha256, size_bytes, storage_key, manifest, metadata, signing_cert_sha256, dangerous_permissions_added, status, pinned, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING ` + otaColumns
 
 	if ota.ID == "" {
 		ota.ID = uuid.NewString()
 	}
+	if ota.Status == "" {
+		ota.Status = entity.OTAStatusPublished
+	}
+	if ota.PayloadType == "" {
+		ota.PayloadType = entity.PayloadTypeAPK
+	}
 
 	now := time.Now()
 	ota.CreatedAt = now
 	ota.UpdatedAt = now
 
-	err := r.db.QueryRowContext(
+	manifest, err := nullableJSON(ota.Manifest)
+	if err != nil {
+		return entity.OTA{}, fmt.Errorf("failed to encode ota manifest: %w", err)
+	}
+
+	var metadata interface{}
+	if len(ota.Metadata) > 0 {
+		if metadata, err = json.Marshal(ota.Metadata); err != nil {
+			return entity.OTA{}, fmt.Errorf("failed to encode ota metadata: %w", err)
+		}
+	}
+
+	var permissionsAdded interface{}
+	if len(ota.DangerousPermissionsAdded) > 0 {
+		if permissionsAdded, err = json.Marshal(ota.DangerousPermissionsAdded); err != nil {
+			return entity.OTA{}, fmt.Errorf("failed to encode ota permission changes: %w", err)
+		}
+	}
+
+	created, err := scanOTA(conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		ota.ID,
 		ota.AppID,
+		ota.PayloadType,
 		ota.VersionName,
 		ota.VersionCode,
 		ota.ReleaseNotes,
 		ota.URL,
+		nullableString(ota.SHA256),
+		sql.NullInt64{Int64: ota.SizeBytes, Valid: ota.SizeBytes > 0},
+		nullableString(ota.StorageKey),
+		manifest,
+		metadata,
+		nullableString(ota.SigningCertSHA256),
+		permissionsAdded,
+		ota.Status,
+		ota.Pinned,
 		ota.CreatedAt,
 		ota.UpdatedAt,
-	).Scan(
-		&ota.ID,
-		&ota.AppID,
-		&ota.VersionName,
-		&ota.VersionCode,
-		&ota.ReleaseNotes,
-		&ota.URL,
-		&ota.CreatedAt,
-		&ota.UpdatedAt,
-	)
+	))
 
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
@@ -69,104 +183,245 @@ func (r *PostgresOTARepository) Create(ctx context.Context, ota entity.OTA) (ent
 		return entity.OTA{}, fmt.Errorf("failed to create ota: %w", err)
 	}
 
-	return ota, nil
+	return created, nil
 }
 
 func (r *PostgresOTARepository) Get(ctx context.Context, id string, appID string) (entity.OTA, string, error) {
 	// Check if both id and appID are provided
 	if id != "" && appID != "" {
-		return nil, "", fmt.Errorf("cannot provide both id and appID, choose one")
+		return entity.OTA{}, "", fmt.Errorf("cannot provide both id and appID, choose one")
 	}
 
 	// Check if neither id nor appID are provided
 	if id == "" && appID == "" {
-		return nil, "", fmt.Errorf("must provide either id or appID")
+		return entity.OTA{}, "", fmt.Errorf("must provide either id or appID")
 	}
 
 	// If id is provided, get a single OTA
 	if id != "" {
-		query := `
-			SELECT id, app_id, version_name, version_code, release_notes, url, created_at, updated_at
-			FROM otas
-			WHERE id = $1
-		`
-
-		var ota entity.OTA
-		err := r.db.QueryRowContext(ctx, query, id).Scan(
-			&ota.ID,
-			&ota.AppID,
-			&ota.VersionName,
-			&ota.VersionCode,
-			&ota.ReleaseNotes,
-			&ota.URL,
-			&ota.CreatedAt,
-			&ota.UpdatedAt,
-		)
+		query := `SELECT ` + otaColumns + ` FROM otas WHERE id = $1`
 
+		ota, err := scanOTA(conn(ctx, r.db).QueryRowContext(ctx, query, id))
 		if err != nil {
 			if err == sql.ErrNoRows {
-				return nil, "", fmt.Errorf("ota not found: %w", err)
+				return entity.OTA{}, "", fmt.Errorf("ota not found: %w", err)
 			}
-			return nil, "", fmt.Errorf("failed to get ota: %w", err)
+			return entity.OTA{}, "", fmt.Errorf("failed to get ota: %w", err)
 		}
 
 		return ota, "", nil
 	}
 
 	// If appID is provided, get multiple OTAs
-	query := `
-		SELECT id, app_id, version_name, version_code, release_notes, url, created_at, updated_at
-		FROM otas
-		WHERE app_id = $1
-	`
+	query := `SELECT ` + otaColumns + ` FROM otas WHERE app_id = $1`
 
-	rows, err := r.db.QueryContext(ctx, query, appID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, appID)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get otas by app id: %w", err)
+		return entity.OTA{}, "", fmt.Errorf("failed to get otas by app id: %w", err)
 	}
 	defer rows.Close()
 
 	var ota entity.OTA
 	for rows.Next() {
-		if err := rows.Scan(
-			&ota.ID,
-			&ota.AppID,
-			&ota.VersionName,
-			&ota.VersionCode,
-			&ota.ReleaseNotes,
-			&ota.URL,
-			&ota.CreatedAt,
-			&ota.UpdatedAt,
-		); err != nil {
-			return nil, "", fmt.Errorf("failed to scan ota row: %w", err)
+		if ota, err = scanOTA(rows); err != nil {
+			return entity.OTA{}, "", fmt.Errorf("failed to scan ota row: %w", err)
 		}
 	}
 
 	return ota, "", nil
 }
 
-func (r *PostgresOTARepository) GetAll(ctx context.Context, cursor string, limit int) ([]entity.OTA, string, int64, error) {
+// GetLatest returns the published release of an app's payload type with the highest version code.
+func (r *PostgresOTARepository) GetLatest(ctx context.Context, appID string, payloadType string) (entity.OTA, bool, error) {
+	query := `SELECT ` + otaColumns + ` FROM otas WHERE app_id = $1 AND payload_type = $3 AND status = $2 ORDER BY version_code DESC LIMIT 1`
+
+	ota, err := scanOTA(conn(ctx, r.db).QueryRowContext(ctx, query, appID, entity.OTAStatusPublished, payloadType))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTA{}, false, nil
+		}
+		return entity.OTA{}, false, fmt.Errorf("failed to get latest ota: %w", err)
+	}
+
+	return ota, true, nil
+}
+
+// ListPrevious returns up to limit published releases of an app's payload type
+// older than beforeVersionCode, newest first.
+func (r *PostgresOTARepository) ListPrevious(ctx context.Context, appID string, payloadType string, beforeVersionCode int, limit int) ([]entity.OTA, error) {
 	query := `
-		SELECT id, app_id, version_name, version_code, release_notes, url, created_at, updated_at
+		SELECT ` + otaColumns + `
 		FROM otas
+		WHERE app_id = $1 AND payload_type = $5 AND version_code < $2 AND status = $4
+		ORDER BY version_code DESC
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, appID, beforeVersionCode, limit, entity.OTAStatusPublished, payloadType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous otas: %w", err)
+	}
+	defer rows.Close()
+
+	var otas []entity.OTA
+	for rows.Next() {
+		ota, err := scanOTA(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota row: %w", err)
+		}
+		otas = append(otas, ota)
+	}
+
+	return otas, rows.Err()
+}
+
+func (r *PostgresOTARepository) ListHistory(ctx context.Context, appID string, payloadType string, afterVersionCode int, upToVersionCode int, limit int) ([]entity.OTA, error) {
+	// Archived releases were published once, so their notes are still history
+	query := `
+		SELECT ` + otaColumns + `
+		FROM (
+			SELECT * FROM otas
+			WHERE app_id = $1 AND payload_type = $2 AND version_code > $3 AND version_code <= $4 AND status = ANY($5)
+			ORDER BY version_code DESC
+			LIMIT $6
+		) AS history
+		ORDER BY version_code
+	`
+
+	statuses := pq.Array([]string{entity.OTAStatusPublished, entity.OTAStatusArchived})
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, appID, payloadType, afterVersionCode, upToVersionCode, statuses, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ota history: %w", err)
+	}
+	defer rows.Close()
+
+	var otas []entity.OTA
+	for rows.Next() {
+		ota, err := scanOTA(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota row: %w", err)
+		}
+		otas = append(otas, ota)
+	}
+
+	return otas, rows.Err()
+}
+
+// ListRetentionCandidates returns the published releases of an app that fall
+// outside the keepLast newest ones of their payload type and are neither
+// pinned nor reported as installed by a device since installedSince, oldest first.
+func (r *PostgresOTARepository) ListRetentionCandidates(ctx context.Context, appID string, keepLast int, installedSince time.Time) ([]entity.OTA, error) {
+	query := `
+		SELECT ` + otaColumns + `
+		FROM otas o
+		WHERE o.app_id = $1 AND o.status = $2 AND NOT o.pinned
+			AND o.id NOT IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY payload_type ORDER BY version_code DESC) AS position
+					FROM otas
+					WHERE app_id = $1 AND status = $2
+				) AS ranked
+				WHERE position <= $3
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM device_installs d
+				WHERE d.app_id = o.app_id AND d.payload_type = o.payload_type AND d.version_code = o.version_code AND d.reported_at >= $4
+			)
+		ORDER BY o.payload_type, o.version_code ASC
 	`
 
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, appID, entity.OTAStatusPublished, keepLast, installedSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var otas []entity.OTA
+	for rows.Next() {
+		ota, err := scanOTA(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota row: %w", err)
+		}
+		otas = append(otas, ota)
+	}
+
+	return otas, rows.Err()
+}
+
+// ListAppIDs returns every app that has published releases.
+func (r *PostgresOTARepository) ListAppIDs(ctx context.Context) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT DISTINCT app_id FROM otas WHERE status = $1 ORDER BY app_id`, entity.OTAStatusPublished)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app ids: %w", err)
+	}
+	defer rows.Close()
+
+	var appIDs []string
+	for rows.Next() {
+		var appID string
+		if err := rows.Scan(&appID); err != nil {
+			return nil, fmt.Errorf("failed to scan app id: %w", err)
+		}
+		appIDs = append(appIDs, appID)
+	}
+
+	return appIDs, rows.Err()
+}
+
+// ListPublished returns the published releases of an app, oldest first.
+func (r *PostgresOTARepository) ListPublished(ctx context.Context, appID string) ([]entity.OTA, error) {
+	query := `SELECT ` + otaColumns + ` FROM otas WHERE app_id = $1 AND status = $2 ORDER BY payload_type, version_code`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, appID, entity.OTAStatusPublished)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get published otas: %w", err)
+	}
+	defer rows.Close()
+
+	var otas []entity.OTA
+	for rows.Next() {
+		ota, err := scanOTA(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ota row: %w", err)
+		}
+		otas = append(otas, ota)
+	}
+
+	return otas, rows.Err()
+}
+
+func (r *PostgresOTARepository) GetAll(ctx context.Context, appIDs []string, cursor string, limit int) ([]entity.OTA, string, int64, error) {
+	query := `SELECT ` + otaColumns + ` FROM otas`
+
+	var conditions []string
+	params := []interface{}{}
+	if appIDs != nil {
+		params = append(params, pq.Array(appIDs))
+		conditions = append(conditions, fmt.Sprintf("app_id = ANY($%d)", len(params)))
+	}
+
 	var total int64
-	countErr := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM otas").Scan(&total)
+	countQuery := "SELECT COUNT(*) FROM otas"
+	if len(conditions) > 0 {
+		countQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	countErr := conn(ctx, r.db).QueryRowContext(ctx, countQuery, params...).Scan(&total)
 	if countErr != nil {
 		return nil, "", 0, fmt.Errorf("failed to count otas: %w", countErr)
 	}
 
-	params := []interface{}{}
 	if cursor != "" {
-		query += " WHERE id > $1"
 		params = append(params, cursor)
+		conditions = append(conditions, fmt.Sprintf("id > $%d", len(params)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	query += " ORDER BY id ASC LIMIT $" + fmt.Sprintf("%d", len(params)+1)
 	params = append(params, limit+1)
 
-	rows, err := r.db.QueryContext(ctx, query, params...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, "", 0, fmt.Errorf("failed to get all otas: %w", err)
 	}
@@ -174,17 +429,8 @@ func (r *PostgresOTARepository) GetAll(ctx context.Context, cursor string, limit
 
 	var otas []entity.OTA
 	for rows.Next() {
-		var ota entity.OTA
-		if err := rows.Scan(
-			&ota.ID,
-			&ota.AppID,
-			&ota.VersionName,
-			&ota.VersionCode,
-			&ota.ReleaseNotes,
-			&ota.URL,
-			&ota.CreatedAt,
-			&ota.UpdatedAt,
-		); err != nil {
+		ota, err := scanOTA(rows)
+		if err != nil {
 			return nil, "", 0, fmt.Errorf("failed to scan ota row: %w", err)
 		}
 		otas = append(otas, ota)
@@ -202,14 +448,13 @@ func (r *PostgresOTARepository) GetAll(ctx context.Context, cursor string, limit
 func (r *PostgresOTARepository) Update(ctx context.Context, ota entity.OTA) (entity.OTA, error) {
 	query := `
 		UPDATE otas
-		SET app_id = $2, version_name = $3, version_code = $4, release_notes = $5, url = $6, updated_at = $7
+		SET app_id = $2, version_name = $3, version_code = $4, release_notes = $5, url = $6, sha256 = $7, size_bytes = $8, updated_at = $9
 		WHERE id = $1
-		RETURNING id, app_id, version_name, version_code, release_notes, url, created_at, updated_at
-	`
+		RETURNING ` + otaColumns
 
 	ota.UpdatedAt = time.Now()
 
-	err := r.db.QueryRowContext(
+	updated, err := scanOTA(conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		ota.ID,
@@ -218,17 +463,10 @@ func (r *PostgresOTARepository) Update(ctx context.Context, ota entity.OTA) (ent
 		ota.VersionCode,
 		ota.ReleaseNotes,
 		ota.URL,
+		nullableString(ota.SHA256),
+		sql.NullInt64{Int64: ota.SizeBytes, Valid: ota.SizeBytes > 0},
 		ota.UpdatedAt,
-	).Scan(
-		&ota.ID,
-		&ota.AppID,
-		&ota.VersionName,
-		&ota.VersionCode,
-		&ota.ReleaseNotes,
-		&ota.URL,
-		&ota.CreatedAt,
-		&ota.UpdatedAt,
-	)
+	))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -237,13 +475,74 @@ func (r *PostgresOTARepository) Update(ctx context.Context, ota entity.OTA) (ent
 		return entity.OTA{}, fmt.Errorf("failed to update ota: %w", err)
 	}
 
-	return ota, nil
+	return updated, nil
+}
+
+func (r *PostgresOTARepository) SetPinned(ctx context.Context, id string, pinned bool) (entity.OTA, error) {
+	query := `UPDATE otas SET pinned = $2, updated_at = $3 WHERE id = $1 RETURNING ` + otaColumns
+
+	updated, err := scanOTA(conn(ctx, r.db).QueryRowContext(ctx, query, id, pinned, time.Now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.OTA{}, fmt.Errorf("ota not found: %w", err)
+		}
+		return entity.OTA{}, fmt.Errorf("failed to update ota: %w", err)
+	}
+
+	return updated, nil
+}
+
+// AcknowledgePermissions publishes a release held for permission review and
+// records who acknowledged its new permissions. It reports false when the
+// release exists but is not awaiting review.
+func (r *PostgresOTARepository) AcknowledgePermissions(ctx context.Context, id string, acknowledgedBy string) (entity.OTA, bool, error) {
+	query := `
+		UPDATE otas
+		SET status = $2, permissions_acknowledged_at = $3, permissions_acknowledged_by = $4, updated_at = $3
+		WHERE id = $1 AND status = $5
+		RETURNING ` + otaColumns
+
+	updated, err := scanOTA(conn(ctx, r.db).QueryRowContext(ctx, query, id, entity.OTAStatusPublished, time.Now(), nullableString(acknowledgedBy), entity.OTAStatusPendingReview))
+	if err == nil {
+		return updated, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return entity.OTA{}, false, fmt.Errorf("failed to acknowledge ota permissions: %w", err)
+	}
+
+	existing, _, err := r.Get(ctx, id, "")
+	if err != nil {
+		return entity.OTA{}, false, err
+	}
+	return existing, false, nil
+}
+
+// Archive marks a published release as archived once its artifacts are gone.
+// Pinned releases are never archived.
+func (r *PostgresOTARepository) Archive(ctx context.Context, id string) (bool, error) {
+	query := `
+		UPDATE otas
+		SET status = $2, storage_key = NULL, archived_at = $3, updated_at = $3
+		WHERE id = $1 AND status = $4 AND NOT pinned
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, entity.OTAStatusArchived, time.Now(), entity.OTAStatusPublished)
+	if err != nil {
+		return false, fmt.Errorf("failed to archive ota: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
 }
 
 func (r *PostgresOTARepository) Delete(ctx context.Context, id string) error {
 	query := "DELETE FROM otas WHERE id = $1"
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete ota: %w", err)
 	}
@@ -258,4 +557,4 @@ func (r *PostgresOTARepository) Delete(ctx context.Context, id string) error {
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+// rateLimitAvailable is the number of tokens in bucket b once refilled at $2
+// tokens a second up to $3. The database clock is used so that instances
+// with skewed clocks agree.
+const rateLimitAvailable = `LEAST($3::double precision, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at)::double precision, 0) * $2::double precision)`
+
+type PostgresRateLimitRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRateLimitRepository(db *sql.DB) repo.RateLimitRepository {
+	return &PostgresRateLimitRepository{
+		db: db,
+	}
+}
+
+// Take refills and draws from the bucket in one statement, so concurrent
+// requests serialize on the bucket's row. An empty bucket is left untouched
+// and then read to tell how long until it refills.
+func (r *PostgresRateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at)
+		VALUES ($1, $3::double precision - 1, now())
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = ` + rateLimitAvailable + ` - 1, updated_at = now()
+		WHERE ` + rateLimitAvailable + ` >= 1
+		RETURNING tokens
+	`
+
+	var tokens float64
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, key, rate, burst).Scan(&tokens)
+	if err == nil {
+		return true, 0, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	var available float64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+rateLimitAvailable+` FROM rate_limit_buckets b WHERE key = $1`, key, rate, burst).Scan(&available); err != nil {
+		return false, 0, fmt.Errorf("failed to get rate limit bucket: %w", err)
+	}
+
+	wait := time.Duration((1 - available) / rate * float64(time.Second))
+	return false, wait, nil
+}
+
+func (r *PostgresRateLimitRepository) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1::double precision * interval '1 second'`, idle.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const releaseNoteColumns = "ota_id, locale, notes, created_at, updated_at"
+
+type PostgresReleaseNoteRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresReleaseNoteRepository(db *sql.DB) repo.ReleaseNoteRepository {
+	return &PostgresReleaseNoteRepository{
+		db: db,
+	}
+}
+
+func scanReleaseNote(row rowScanner) (entity.ReleaseNote, error) {
+	var note entity.ReleaseNote
+	if err := row.Scan(
+		&note.OTAID,
+		&note.Locale,
+		&note.Notes,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+	); err != nil {
+		return entity.ReleaseNote{}, err
+	}
+	return note, nil
+}
+
+func (r *PostgresReleaseNoteRepository) Upsert(ctx context.Context, note entity.ReleaseNote) (entity.ReleaseNote, error) {
+	query := `
+		INSERT INTO ota_release_notes (ota_id, locale, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (ota_id, locale) DO UPDATE SET notes = EXCLUDED.notes, updated_at = EXCLUDED.updated_at
+		RETURNING ` + releaseNoteColumns
+
+	saved, err := scanReleaseNote(conn(ctx, r.db).QueryRowContext(ctx, query, note.OTAID, note.Locale, note.Notes, time.Now()))
+	if err != nil {
+		return entity.ReleaseNote{}, fmt.Errorf("failed to save release note: %w", err)
+	}
+
+	return saved, nil
+}
+
+func (r *PostgresReleaseNoteRepository) ListByOTA(ctx context.Context, otaID string) ([]entity.ReleaseNote, error) {
+	query := `SELECT ` + releaseNoteColumns + ` FROM ota_release_notes WHERE ota_id = $1 ORDER BY locale`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, otaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []entity.ReleaseNote
+	for rows.Next() {
+		note, err := scanReleaseNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan release note row: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+func (r *PostgresReleaseNoteRepository) Delete(ctx context.Context, otaID string, locale string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM ota_release_notes WHERE ota_id = $1 AND locale = $2", otaID, locale)
+	if err != nil {
+		return fmt.Errorf("failed to delete release note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("release note not found")
+	}
+
+	return nil
+}
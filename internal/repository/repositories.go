@@ -5,5 +5,23 @@ import (
 )
 
 type Repositories struct {
-	OTA repository.OTARepository
+	OTA              repository.OTARepository
+	OTAPatch         repository.OTAPatchRepository
+	SigningCert      repository.SigningCertRepository
+	DownloadStats    repository.DownloadStatsRepository
+	Retention        repository.RetentionPolicyRepository
+	DeviceInstall    repository.DeviceInstallRepository
+	ArtifactBlob     repository.ArtifactBlobRepository
+	OTAArtifact      repository.OTAArtifactRepository
+	OTAMirror        repository.OTAMirrorRepository
+	ReleaseNote      repository.ReleaseNoteRepository
+	APIKey           repository.APIKeyRepository
+	RoleBinding      repository.RoleBindingRepository
+	EnrollmentToken  repository.EnrollmentTokenRepository
+	DeviceCredential repository.DeviceCredentialRepository
+	TUFMetadata      repository.TUFMetadataRepository
+	Audit            repository.AuditRepository
+	RateLimit        repository.RateLimitRepository
+	Transactor       repository.Transactor
+	Artifact         repository.ArtifactStorage
 } 
\ No newline at end of file
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+type PostgresRetentionPolicyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRetentionPolicyRepository(db *sql.DB) repo.RetentionPolicyRepository {
+	return &PostgresRetentionPolicyRepository{
+		db: db,
+	}
+}
+
+func (r *PostgresRetentionPolicyRepository) Get(ctx context.Context, appID string) (entity.RetentionPolicy, bool, error) {
+	query := `SELECT app_id, keep_last, created_at, updated_at FROM app_retention_policies WHERE app_id = $1`
+
+	var policy entity.RetentionPolicy
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, appID).Scan(&policy.AppID, &policy.KeepLast, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.RetentionPolicy{}, false, nil
+		}
+		return entity.RetentionPolicy{}, false, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+
+	return policy, true, nil
+}
+
+func (r *PostgresRetentionPolicyRepository) Upsert(ctx context.Context, policy entity.RetentionPolicy) (entity.RetentionPolicy, error) {
+	query := `
+		INSERT INTO app_retention_policies (app_id, keep_last, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (app_id) DO UPDATE SET
+			keep_last = EXCLUDED.keep_last,
+			updated_at = EXCLUDED.updated_at
+		RETURNING app_id, keep_last, created_at, updated_at
+	`
+
+	var saved entity.RetentionPolicy
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, policy.AppID, policy.KeepLast, time.Now()).Scan(
+		&saved.AppID,
+		&saved.KeepLast,
+		&saved.CreatedAt,
+		&saved.UpdatedAt,
+	)
+	if err != nil {
+		return entity.RetentionPolicy{}, fmt.Errorf("failed to save retention policy: %w", err)
+	}
+
+	return saved, nil
+}
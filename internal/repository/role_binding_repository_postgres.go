@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const roleBindingColumns = "id, api_key_id, role, app_id, created_at"
+
+type PostgresRoleBindingRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRoleBindingRepository(db *sql.DB) repo.RoleBindingRepository {
+	return &PostgresRoleBindingRepository{
+		db: db,
+	}
+}
+
+func scanRoleBinding(row rowScanner) (entity.RoleBinding, error) {
+	var binding entity.RoleBinding
+	err := row.Scan(
+		&binding.ID,
+		&binding.APIKeyID,
+		&binding.Role,
+		&binding.AppID,
+		&binding.CreatedAt,
+	)
+	return binding, err
+}
+
+func (r *PostgresRoleBindingRepository) Create(ctx context.Context, binding entity.RoleBinding) (entity.RoleBinding, error) {
+	query := `
+		INSERT INTO role_bindings (id, api_key_id, role, app_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + roleBindingColumns
+
+	if binding.ID == "" {
+		binding.ID = uuid.NewString()
+	}
+	binding.CreatedAt = time.Now()
+
+	created, err := scanRoleBinding(conn(ctx, r.db).QueryRowContext(ctx, query, binding.ID, binding.APIKeyID, binding.Role, binding.AppID, binding.CreatedAt))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return entity.RoleBinding{}, fmt.Errorf("role is already granted: %w", err)
+			}
+			if pqErr.Code == "23503" {
+				return entity.RoleBinding{}, fmt.Errorf("api key not found: %w", err)
+			}
+		}
+		return entity.RoleBinding{}, fmt.Errorf("failed to create role binding: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresRoleBindingRepository) ListByAPIKey(ctx context.Context, apiKeyID string) ([]entity.RoleBinding, error) {
+	query := `SELECT ` + roleBindingColumns + ` FROM role_bindings WHERE api_key_id = $1 ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, apiKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role bindings: %w", err)
+	}
+	defer rows.Close()
+
+	var bindings []entity.RoleBinding
+	for rows.Next() {
+		binding, err := scanRoleBinding(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role binding row: %w", err)
+		}
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, rows.Err()
+}
+
+func (r *PostgresRoleBindingRepository) Delete(ctx context.Context, apiKeyID string, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM role_bindings WHERE api_key_id = $1 AND id = $2", apiKeyID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete role binding: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("role binding not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const signingCertColumns = "id, app_id, cert_sha256, parent_id, status, created_at, activated_at, retired_at"
+
+type PostgresSigningCertRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSigningCertRepository(db *sql.DB) repo.SigningCertRepository {
+	return &PostgresSigningCertRepository{
+		db: db,
+	}
+}
+
+func scanSigningCert(row rowScanner) (entity.SigningCertificate, error) {
+	var cert entity.SigningCertificate
+	var parentID sql.NullString
+	var activatedAt, retiredAt sql.NullTime
+
+	if err := row.Scan(
+		&cert.ID,
+		&cert.AppID,
+		&cert.CertSHA256,
+		&parentID,
+		&cert.Status,
+		&cert.CreatedAt,
+		&activatedAt,
+		&retiredAt,
+	); err != nil {
+		return entity.SigningCertificate{}, err
+	}
+
+	if parentID.Valid {
+		cert.ParentID = &parentID.String
+	}
+	if activatedAt.Valid {
+		cert.ActivatedAt = &activatedAt.Time
+	}
+	if retiredAt.Valid {
+		cert.RetiredAt = &retiredAt.Time
+	}
+
+	return cert, nil
+}
+
+func (r *PostgresSigningCertRepository) Create(ctx context.Context, cert entity.SigningCertificate) (entity.SigningCertificate, error) {
+	query := `
+		INSERT INTO app_signing_certs (id, app_id, cert_sha256, parent_id, status, created_at, activated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + signingCertColumns
+
+	if cert.ID == "" {
+		cert.ID = uuid.NewString()
+	}
+	cert.CreatedAt = time.Now()
+	if cert.Status == entity.SigningCertStatusActive {
+		cert.ActivatedAt = &cert.CreatedAt
+	}
+
+	created, err := scanSigningCert(conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		cert.ID,
+		cert.AppID,
+		cert.CertSHA256,
+		cert.ParentID,
+		cert.Status,
+		cert.CreatedAt,
+		cert.ActivatedAt,
+	))
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" {
+				return entity.SigningCertificate{}, fmt.Errorf("signing certificate conflicts with an existing one: %w", err)
+			}
+		}
+		return entity.SigningCertificate{}, fmt.Errorf("failed to create signing certificate: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *PostgresSigningCertRepository) Get(ctx context.Context, id string) (entity.SigningCertificate, error) {
+	query := `SELECT ` + signingCertColumns + ` FROM app_signing_certs WHERE id = $1`
+
+	cert, err := scanSigningCert(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.SigningCertificate{}, fmt.Errorf("signing certificate not found: %w", err)
+		}
+		return entity.SigningCertificate{}, fmt.Errorf("failed to get signing certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+func (r *PostgresSigningCertRepository) ListByApp(ctx context.Context, appID string) ([]entity.SigningCertificate, error) {
+	query := `SELECT ` + signingCertColumns + ` FROM app_signing_certs WHERE app_id = $1 ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing certificates: %w", err)
+	}
+	defer rows.Close()
+
+	var certs []entity.SigningCertificate
+	for rows.Next() {
+		cert, err := scanSigningCert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signing certificate row: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, rows.Err()
+}
+
+// Activate promotes a pending certificate, retiring its parent in the same
+// transaction. It joins the transaction in ctx, if any.
+func (r *PostgresSigningCertRepository) Activate(ctx context.Context, id string) (entity.SigningCertificate, error) {
+	var cert entity.SigningCertificate
+	err := NewPostgresTransactor(r.db).WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		cert, err = r.activate(ctx, id)
+		return err
+	})
+	return cert, err
+}
+
+func (r *PostgresSigningCertRepository) activate(ctx context.Context, id string) (entity.SigningCertificate, error) {
+	now := time.Now()
+
+	retire := `
+		UPDATE app_signing_certs SET status = $2, retired_at = $3
+		WHERE id = (SELECT parent_id FROM app_signing_certs WHERE id = $1) AND status = $4
+	`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, retire, id, entity.SigningCertStatusRetired, now, entity.SigningCertStatusActive); err != nil {
+		return entity.SigningCertificate{}, fmt.Errorf("failed to retire signing certificate: %w", err)
+	}
+
+	activate := `
+		UPDATE app_signing_certs SET status = $2, activated_at = $3
+		WHERE id = $1 AND status = $4
+		RETURNING ` + signingCertColumns
+
+	cert, err := scanSigningCert(conn(ctx, r.db).QueryRowContext(ctx, activate, id, entity.SigningCertStatusActive, now, entity.SigningCertStatusPending))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.SigningCertificate{}, fmt.Errorf("pending signing certificate not found: %w", err)
+		}
+		return entity.SigningCertificate{}, fmt.Errorf("failed to activate signing certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+func (r *PostgresSigningCertRepository) Delete(ctx context.Context, id string) error {
+	query := "DELETE FROM app_signing_certs WHERE id = $1 AND status = $2"
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, entity.SigningCertStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to delete signing certificate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("pending signing certificate not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"launcherbackend_api/internal/domain/entity"
+	repo "launcherbackend_api/internal/domain/repository"
+)
+
+const tufMetadataColumns = "app_id, role, version, body_sha256, content, expires_at, created_at"
+
+type PostgresTUFMetadataRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTUFMetadataRepository(db *sql.DB) repo.TUFMetadataRepository {
+	return &PostgresTUFMetadataRepository{
+		db: db,
+	}
+}
+
+func scanTUFMetadata(row rowScanner) (entity.TUFMetadata, error) {
+	var metadata entity.TUFMetadata
+	var content string
+
+	if err := row.Scan(
+		&metadata.AppID,
+		&metadata.Role,
+		&metadata.Version,
+		&metadata.BodySHA256,
+		&content,
+		&metadata.ExpiresAt,
+		&metadata.CreatedAt,
+	); err != nil {
+		return entity.TUFMetadata{}, err
+	}
+	metadata.Content = []byte(content)
+
+	return metadata, nil
+}
+
+func (r *PostgresTUFMetadataRepository) Lock(ctx context.Context, appID string) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('tuf_metadata'), hashtext($1))`, appID); err != nil {
+		return fmt.Errorf("failed to lock tuf metadata: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTUFMetadataRepository) Create(ctx context.Context, metadata entity.TUFMetadata) error {
+	query := `
+		INSERT INTO tuf_metadata (app_id, role, version, body_sha256, content, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query,
+		metadata.AppID,
+		metadata.Role,
+		metadata.Version,
+		metadata.BodySHA256,
+		string(metadata.Content),
+		metadata.ExpiresAt,
+		metadata.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to create tuf metadata: %w", err)
+	}
+
+	if metadata.Role == entity.TUFRoleRoot {
+		return nil
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM tuf_metadata WHERE app_id = $1 AND role = $2 AND version < $3`,
+		metadata.AppID, metadata.Role, metadata.Version); err != nil {
+		return fmt.Errorf("failed to delete previous tuf metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresTUFMetadataRepository) GetLatest(ctx context.Context, appID string, role string) (entity.TUFMetadata, bool, error) {
+	query := `SELECT ` + tufMetadataColumns + ` FROM tuf_metadata WHERE app_id = $1 AND role = $2 ORDER BY version DESC LIMIT 1`
+
+	metadata, err := scanTUFMetadata(conn(ctx, r.db).QueryRowContext(ctx, query, appID, role))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.TUFMetadata{}, false, nil
+		}
+		return entity.TUFMetadata{}, false, fmt.Errorf("failed to get tuf metadata: %w", err)
+	}
+
+	return metadata, true, nil
+}
+
+func (r *PostgresTUFMetadataRepository) Get(ctx context.Context, appID string, role string, version int) (entity.TUFMetadata, error) {
+	query := `SELECT ` + tufMetadataColumns + ` FROM tuf_metadata WHERE app_id = $1 AND role = $2 AND version = $3`
+
+	metadata, err := scanTUFMetadata(conn(ctx, r.db).QueryRowContext(ctx, query, appID, role, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.TUFMetadata{}, fmt.Errorf("tuf metadata not found: %w", err)
+		}
+		return entity.TUFMetadata{}, fmt.Errorf("failed to get tuf metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// ListAppIDs returns every app with metadata.
+func (r *PostgresTUFMetadataRepository) ListAppIDs(ctx context.Context) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT DISTINCT app_id FROM tuf_metadata ORDER BY app_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app ids: %w", err)
+	}
+	defer rows.Close()
+
+	var appIDs []string
+	for rows.Next() {
+		var appID string
+		if err := rows.Scan(&appID); err != nil {
+			return nil, fmt.Errorf("failed to scan app id: %w", err)
+		}
+		appIDs = append(appIDs, appID)
+	}
+
+	return appIDs, rows.Err()
+}
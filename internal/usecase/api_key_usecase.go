@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/apikey"
+)
+
+var (
+	// ErrUnauthenticated is returned for missing or invalid credentials, such
+	// as an unknown or revoked API key or an expired token.
+	ErrUnauthenticated = errors.New("valid credentials are required")
+	// ErrInvalidRole is returned for a role that does not exist.
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+// BootstrapKeyID identifies the API key from configuration.
+const BootstrapKeyID = "bootstrap"
+
+// lastUsedResolution limits how often the last use of a key is written.
+const lastUsedResolution = time.Minute
+
+// APIKeyUseCase issues, revokes and authenticates API keys and grants them roles.
+type APIKeyUseCase struct {
+	keyRepo      repository.APIKeyRepository
+	bindingRepo  repository.RoleBindingRepository
+	bootstrapKey string
+	audit        *AuditUseCase
+}
+
+// NewAPIKeyUseCase creates the API key use case. bootstrapKey, when set, is
+// accepted in addition to stored keys, with every permission, so the first
+// keys can be created.
+func NewAPIKeyUseCase(keyRepo repository.APIKeyRepository, bindingRepo repository.RoleBindingRepository, bootstrapKey string, audit *AuditUseCase) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		keyRepo:      keyRepo,
+		bindingRepo:  bindingRepo,
+		bootstrapKey: bootstrapKey,
+		audit:        audit,
+	}
+}
+
+// CreateKey issues a new API key. The returned token cannot be retrieved again.
+func (uc *APIKeyUseCase) CreateKey(ctx context.Context, name string) (entity.CreatedAPIKey, error) {
+	if name == "" {
+		return entity.CreatedAPIKey{}, fmt.Errorf("name is required")
+	}
+	if err := authorize(ctx, ActionAdminister, ""); err != nil {
+		return entity.CreatedAPIKey{}, err
+	}
+
+	generated, err := apikey.Generate(apikey.SchemeAPIKey)
+	if err != nil {
+		return entity.CreatedAPIKey{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	var key entity.APIKey
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		key, err = uc.keyRepo.Create(ctx, entity.APIKey{
+			Name:   name,
+			Prefix: generated.Prefix,
+			Salt:   generated.Salt,
+			Hash:   generated.Hash,
+		})
+		return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceAPIKey, ResourceID: key.ID, After: key}, err
+	})
+	if err != nil {
+		return entity.CreatedAPIKey{}, err
+	}
+
+	return entity.CreatedAPIKey{APIKey: key, Token: generated.Token}, nil
+}
+
+func (uc *APIKeyUseCase) ListKeys(ctx context.Context) ([]entity.APIKey, error) {
+	if err := authorize(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	return uc.keyRepo.List(ctx)
+}
+
+func (uc *APIKeyUseCase) RevokeKey(ctx context.Context, id string) (entity.APIKey, error) {
+	if id == "" {
+		return entity.APIKey{}, fmt.Errorf("ID is required")
+	}
+	if err := authorize(ctx, ActionAdminister, ""); err != nil {
+		return entity.APIKey{}, err
+	}
+
+	var revoked entity.APIKey
+	err := uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		existing, err := uc.keyRepo.Get(ctx, id)
+		if err != nil {
+			return AuditChange{}, err
+		}
+		revoked, err = uc.keyRepo.Revoke(ctx, id)
+		return AuditChange{Action: entity.AuditActionUpdate, ResourceType: entity.AuditResourceAPIKey, ResourceID: id, Before: existing, After: revoked}, err
+	})
+	return revoked, err
+}
+
+func (uc *APIKeyUseCase) ListRoles(ctx context.Context, keyID string) ([]entity.RoleBinding, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("ID is required")
+	}
+	if err := authorize(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	return uc.bindingRepo.ListByAPIKey(ctx, keyID)
+}
+
+// GrantRole grants an API key a role for one app, or for every app when
+// appID is empty.
+func (uc *APIKeyUseCase) GrantRole(ctx context.Context, keyID string, role string, appID string) (entity.RoleBinding, error) {
+	if keyID == "" {
+		return entity.RoleBinding{}, fmt.Errorf("ID is required")
+	}
+	if !entity.IsValidRole(role) {
+		return entity.RoleBinding{}, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	if err := authorize(ctx, ActionAdminister, ""); err != nil {
+		return entity.RoleBinding{}, err
+	}
+
+	var binding entity.RoleBinding
+	err := uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		binding, err = uc.bindingRepo.Create(ctx, entity.RoleBinding{APIKeyID: keyID, Role: role, AppID: appID})
+		return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceRoleBinding, ResourceID: binding.ID, AppID: appID, After: binding}, err
+	})
+	return binding, err
+}
+
+func (uc *APIKeyUseCase) RevokeRole(ctx context.Context, keyID string, bindingID string) error {
+	if keyID == "" || bindingID == "" {
+		return fmt.Errorf("ID is required")
+	}
+	if err := authorize(ctx, ActionAdminister, ""); err != nil {
+		return err
+	}
+
+	return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		bindings, err := uc.bindingRepo.ListByAPIKey(ctx, keyID)
+		if err != nil {
+			return AuditChange{}, err
+		}
+		change := AuditChange{Action: entity.AuditActionDelete, ResourceType: entity.AuditResourceRoleBinding, ResourceID: bindingID}
+		for _, binding := range bindings {
+			if binding.ID == bindingID {
+				change.AppID, change.Before = binding.AppID, binding
+			}
+		}
+		return change, uc.bindingRepo.Delete(ctx, keyID, bindingID)
+	})
+}
+
+// Authenticate returns the caller a token belongs to, with the roles of its
+// API key, recording the key's use.
+func (uc *APIKeyUseCase) Authenticate(ctx context.Context, token string) (entity.Principal, error) {
+	if token == "" {
+		return entity.Principal{}, ErrUnauthenticated
+	}
+
+	if uc.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(uc.bootstrapKey)) == 1 {
+		return entity.Principal{ID: BootstrapKeyID, Name: BootstrapKeyID, Method: entity.AuthMethodAPIKey, Superuser: true}, nil
+	}
+
+	prefix, secret, err := apikey.Parse(apikey.SchemeAPIKey, token)
+	if err != nil {
+		return entity.Principal{}, ErrUnauthenticated
+	}
+
+	key, ok, err := uc.keyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return entity.Principal{}, err
+	}
+	if !ok || key.RevokedAt != nil || !apikey.Verify(key.Salt, key.Hash, secret) {
+		return entity.Principal{}, ErrUnauthenticated
+	}
+
+	bindings, err := uc.bindingRepo.ListByAPIKey(ctx, key.ID)
+	if err != nil {
+		return entity.Principal{}, err
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := uc.keyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("Failed to record use of API key %s: %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+	}
+
+	return entity.Principal{ID: key.ID, Name: key.Name, Method: entity.AuthMethodAPIKey, Bindings: bindings}, nil
+}
@@ -0,0 +1,307 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/apk"
+)
+
+// ArtifactUseCase manages the per-ABI, per-density and split APKs of releases.
+type ArtifactUseCase struct {
+	otaRepo      repository.OTARepository
+	artifactRepo repository.OTAArtifactRepository
+	downloads    DownloadURLs
+	blobs        *BlobStore
+	certs        *SigningCertUseCase
+	tuf          *TUFUseCase
+	audit        *AuditUseCase
+}
+
+func NewArtifactUseCase(otaRepo repository.OTARepository, artifactRepo repository.OTAArtifactRepository, downloads DownloadURLs, blobs *BlobStore, certs *SigningCertUseCase, tuf *TUFUseCase, audit *AuditUseCase) *ArtifactUseCase {
+	return &ArtifactUseCase{
+		otaRepo:      otaRepo,
+		artifactRepo: artifactRepo,
+		downloads:    downloads,
+		blobs:        blobs,
+		certs:        certs,
+		tuf:          tuf,
+		audit:        audit,
+	}
+}
+
+func (uc *ArtifactUseCase) ListArtifacts(ctx context.Context, otaID string) ([]entity.OTAArtifact, error) {
+	if otaID == "" {
+		return nil, fmt.Errorf("ID is required")
+	}
+	if err := authorizeRelease(ctx, uc.otaRepo, ActionView, otaID); err != nil {
+		return nil, err
+	}
+	return uc.artifactRepo.ListByOTA(ctx, otaID)
+}
+
+// UploadArtifact adds an APK to a release. The APK must belong to the same
+// package and version and be signed with the same certificate as the release,
+// and may only request dangerous permissions the release was reviewed for.
+// The split name and ABI default to what the APK itself declares.
+func (uc *ArtifactUseCase) UploadArtifact(ctx context.Context, otaID string, artifact entity.OTAArtifact, file io.ReaderAt, size int64) (entity.OTAArtifact, error) {
+	if otaID == "" {
+		return entity.OTAArtifact{}, fmt.Errorf("ID is required")
+	}
+	if artifact.Density < 0 {
+		return entity.OTAArtifact{}, fmt.Errorf("valid density is required")
+	}
+
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return entity.OTAArtifact{}, err
+	}
+	if err := authorize(ctx, ActionUpdate, ota.AppID); err != nil {
+		return entity.OTAArtifact{}, err
+	}
+	if ota.Status == entity.OTAStatusArchived {
+		return entity.OTAArtifact{}, fmt.Errorf("ota is archived")
+	}
+	if ota.PayloadType != entity.PayloadTypeAPK {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: only APK releases have per-device artifacts", ErrUnsupportedPayloadType)
+	}
+
+	manifest, err := apk.ParseManifest(file, size)
+	if err != nil {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+	if manifest.PackageName != ota.AppID || manifest.VersionCode != ota.VersionCode {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: apk is %s %d but release is %s %d", ErrManifestMismatch, manifest.PackageName, manifest.VersionCode, ota.AppID, ota.VersionCode)
+	}
+
+	// Devices install artifacts instead of the release's own APK, so they
+	// must not slip in permissions past review
+	var reviewed []string
+	if ota.Manifest != nil {
+		reviewed = ota.Manifest.Permissions
+	}
+	added, err := addedDangerousPermissions(ctx, uc.otaRepo, ota, reviewed, manifest.Permissions)
+	if err != nil {
+		return entity.OTAArtifact{}, err
+	}
+	if len(added) > 0 {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: %s", ErrUnreviewedPermissions, strings.Join(added, ", "))
+	}
+
+	if artifact.SplitName == "" {
+		artifact.SplitName = manifest.Split
+	} else if artifact.SplitName != manifest.Split {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: split %q but apk split is %q", ErrManifestMismatch, artifact.SplitName, manifest.Split)
+	}
+
+	abis, err := apk.NativeABIs(file, size)
+	if err != nil {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+	if artifact.ABI == "" && len(abis) == 1 {
+		artifact.ABI = abis[0]
+	} else if artifact.ABI != "" && !slices.Contains(abis, artifact.ABI) {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: apk has no native libraries for %s", ErrManifestMismatch, artifact.ABI)
+	}
+
+	signing, err := apk.VerifySigning(file, size)
+	if err != nil {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+	if ota.SigningCertSHA256 != "" && signing.CertSHA256 != ota.SigningCertSHA256 {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: artifact is not signed with the release's certificate", ErrSigningCertMismatch)
+	}
+	cert, err := uc.certs.VerifyRelease(ctx, ota.AppID, signing)
+	if err != nil {
+		return entity.OTAArtifact{}, err
+	}
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, io.NewSectionReader(file, 0, size)); err != nil {
+		return entity.OTAArtifact{}, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+	artifact.OTAID = ota.ID
+	artifact.SHA256 = hex.EncodeToString(digest.Sum(nil))
+	artifact.SizeBytes = size
+
+	key, err := uc.blobs.Put(ctx, artifact.SHA256, size, io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return entity.OTAArtifact{}, err
+	}
+	artifact.StorageKey = key
+	artifact.ID = uuid.NewString()
+	artifact.URL = uc.downloads.Artifact(ota.ID, artifact.ID)
+
+	var created entity.OTAArtifact
+	err = uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			// Pinned or rotated only if the artifact is added
+			if err := uc.certs.ConfirmRelease(ctx, cert); err != nil {
+				return AuditChange{}, err
+			}
+			var err error
+			created, err = uc.artifactRepo.Create(ctx, artifact)
+			return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceArtifact, ResourceID: created.ID, AppID: ota.AppID, After: created}, err
+		})
+	}, ota.AppID)
+	if err != nil {
+		if relErr := uc.blobs.Release(ctx, artifact.SHA256); relErr != nil {
+			log.Printf("Failed to release artifact %s: %v", artifact.SHA256, relErr)
+		}
+		return entity.OTAArtifact{}, err
+	}
+
+	return created, nil
+}
+
+func (uc *ArtifactUseCase) DeleteArtifact(ctx context.Context, otaID string, artifactID string) error {
+	if otaID == "" || artifactID == "" {
+		return fmt.Errorf("ID is required")
+	}
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, ActionUpdate, ota.AppID); err != nil {
+		return err
+	}
+
+	artifact, err := uc.artifactRepo.Get(ctx, artifactID)
+	if err != nil {
+		return err
+	}
+	if artifact.OTAID != otaID {
+		return fmt.Errorf("ota artifact not found")
+	}
+
+	if err := uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			change := AuditChange{Action: entity.AuditActionDelete, ResourceType: entity.AuditResourceArtifact, ResourceID: artifactID, AppID: ota.AppID, Before: artifact}
+			return change, uc.artifactRepo.Delete(ctx, artifactID)
+		})
+	}, ota.AppID); err != nil {
+		return err
+	}
+	if err := uc.blobs.Release(ctx, artifact.SHA256); err != nil {
+		log.Printf("Failed to release artifact %s: %v", artifact.SHA256, err)
+	}
+
+	return nil
+}
+
+// SelectArtifacts returns the APKs of a release to install on a device with
+// the given ABIs, in order of preference, and screen density. It returns nil
+// when the release has no artifact that fits, in which case the device should
+// install the release's universal artifact.
+func (uc *ArtifactUseCase) SelectArtifacts(ctx context.Context, otaID string, abis []string, density int) ([]entity.OTAArtifact, error) {
+	artifacts, err := uc.artifactRepo.ListByOTA(ctx, otaID)
+	if err != nil {
+		return nil, err
+	}
+	return selectArtifacts(artifacts, abis, density), nil
+}
+
+func selectArtifacts(artifacts []entity.OTAArtifact, abis []string, density int) []entity.OTAArtifact {
+	var (
+		main                           *entity.OTAArtifact
+		features, abiSplits, dpiSplits []entity.OTAArtifact
+	)
+
+	for i, a := range artifacts {
+		switch {
+		case a.SplitName == "":
+			if abiRank(a, abis) < 0 || densityRank(a, density) < 0 {
+				continue
+			}
+			if main == nil || betterFit(a, *main, abis, density) {
+				main = &artifacts[i]
+			}
+		case a.ABI != "":
+			abiSplits = append(abiSplits, a)
+		case a.Density != 0:
+			dpiSplits = append(dpiSplits, a)
+		default:
+			features = append(features, a)
+		}
+	}
+
+	if main == nil {
+		return nil
+	}
+
+	selected := []entity.OTAArtifact{*main}
+
+	// Splits extend a base APK; a standalone build for one ABI or density needs none
+	if main.ABI != "" || main.Density != 0 {
+		return selected
+	}
+
+	selected = append(selected, features...)
+	for _, group := range [][]entity.OTAArtifact{abiSplits, dpiSplits} {
+		if len(group) == 0 {
+			continue
+		}
+
+		var best *entity.OTAArtifact
+		for i, a := range group {
+			if abiRank(a, abis) < 0 || densityRank(a, density) < 0 {
+				continue
+			}
+			if best == nil || betterFit(a, *best, abis, density) {
+				best = &group[i]
+			}
+		}
+
+		// Without a matching config split the base APK lacks code or resources the device needs
+		if best == nil {
+			return nil
+		}
+		selected = append(selected, *best)
+	}
+
+	return selected
+}
+
+func betterFit(a, b entity.OTAArtifact, abis []string, density int) bool {
+	if ra, rb := abiRank(a, abis), abiRank(b, abis); ra != rb {
+		return ra < rb
+	}
+	return densityRank(a, density) < densityRank(b, density)
+}
+
+// abiRank orders artifacts by the device's ABI preference, ranking ABI
+// independent artifacts after every specific match. It is negative when the
+// device cannot run the artifact.
+func abiRank(a entity.OTAArtifact, abis []string) int {
+	if a.ABI == "" {
+		return len(abis)
+	}
+	return slices.Index(abis, a.ABI)
+}
+
+// densityRank prefers an exact density match, then the nearest higher
+// density, then density independent resources, then the nearest lower one,
+// mirroring how Android picks resources. It is negative when the device did
+// not report a density and the artifact is density specific.
+func densityRank(a entity.OTAArtifact, density int) int {
+	switch {
+	case a.Density == 0:
+		return 1 << 16
+	case density == 0:
+		return -1
+	case a.Density >= density:
+		return a.Density - density
+	default:
+		return 1<<17 + density - a.Density
+	}
+}
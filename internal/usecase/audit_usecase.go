@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// ErrInvalidCursor is returned for a pagination cursor the server did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// RequestInfo identifies the API request a change is made in.
+type RequestInfo struct {
+	ID       string
+	SourceIP string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a context carrying the API request it serves.
+func WithRequest(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey{}, info)
+}
+
+// RequestFromContext returns the API request carried by ctx, if any.
+func RequestFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestKey{}).(RequestInfo)
+	return info, ok
+}
+
+// AuditChange is a change to a resource. Before is nil for creations and
+// After for deletions; otherwise both hold the resource, whose JSON fields
+// are compared.
+type AuditChange struct {
+	Action       string
+	ResourceType string
+	ResourceID   string
+	AppID        string
+	Before       any
+	After        any
+}
+
+// AuditUseCase records who changed what in the audit log, and lists it.
+type AuditUseCase struct {
+	transactor repository.Transactor
+	auditRepo  repository.AuditRepository
+}
+
+func NewAuditUseCase(transactor repository.Transactor, auditRepo repository.AuditRepository) *AuditUseCase {
+	return &AuditUseCase{
+		transactor: transactor,
+		auditRepo:  auditRepo,
+	}
+}
+
+// Track runs write and records the change it reports in the same
+// transaction, so no change is made without its entry. Changes that leave
+// every field as it was are not recorded.
+func (uc *AuditUseCase) Track(ctx context.Context, write func(ctx context.Context) (AuditChange, error)) error {
+	return uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		change, err := write(ctx)
+		if err != nil {
+			return err
+		}
+		return uc.record(ctx, change)
+	})
+}
+
+func (uc *AuditUseCase) record(ctx context.Context, change AuditChange) error {
+	changes, err := diffFields(change.Before, change.After)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s %s: %w", change.ResourceType, change.ResourceID, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	entry := entity.AuditEntry{
+		Action:       change.Action,
+		ResourceType: change.ResourceType,
+		ResourceID:   change.ResourceID,
+		AppID:        change.AppID,
+		Changes:      changes,
+		CreatedAt:    time.Now(),
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		entry.ActorID = principal.ID
+		entry.ActorName = principal.Name
+		entry.ActorMethod = principal.Method
+	}
+	if request, ok := RequestFromContext(ctx); ok {
+		entry.RequestID = request.ID
+		entry.SourceIP = request.SourceIP
+	}
+
+	_, err = uc.auditRepo.Create(ctx, entry)
+	return err
+}
+
+// ListEntries returns the entries matching filter, newest first, with the
+// cursor of the next page and the number of matching entries. Callers see
+// the entries of the apps they administer; entries of changes not tied to an
+// app need a global admin role.
+func (uc *AuditUseCase) ListEntries(ctx context.Context, filter entity.AuditFilter, cursor string, limit int) ([]entity.AuditEntry, string, int64, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var after int64
+	if cursor != "" {
+		var err error
+		after, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || after <= 0 {
+			return nil, "", 0, ErrInvalidCursor
+		}
+	}
+
+	if filter.AppID != "" {
+		if err := authorize(ctx, ActionAdminister, filter.AppID); err != nil {
+			return nil, "", 0, err
+		}
+	} else if appIDs, all := allowedApps(ctx, ActionAdminister); !all {
+		if appIDs == nil {
+			appIDs = []string{}
+		}
+		filter.AppIDs = appIDs
+	}
+
+	entries, next, total, err := uc.auditRepo.List(ctx, filter, after, limit)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var nextCursor string
+	if next > 0 {
+		nextCursor = strconv.FormatInt(next, 10)
+	}
+	return entries, nextCursor, total, nil
+}
+
+// diffFields returns the JSON fields whose values differ between before and
+// after, either of which may be nil.
+func diffFields(before any, after any) (map[string]entity.FieldChange, error) {
+	from, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]entity.FieldChange)
+	for name, value := range from {
+		if !reflect.DeepEqual(value, to[name]) {
+			changes[name] = entity.FieldChange{Before: value, After: to[name]}
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok {
+			changes[name] = entity.FieldChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields returns the fields of v as encoded in API responses, so fields
+// hidden from responses, such as key hashes, never reach the audit log.
+func jsonFields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
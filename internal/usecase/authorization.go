@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// ErrForbidden is returned when the caller's roles do not allow an action.
+var ErrForbidden = errors.New("permission denied")
+
+// Action is something a role may be allowed to do to the releases of an app.
+type Action string
+
+// Publishing has no action of its own: a release is published when it is
+// created, so it falls under ActionCreate. ActionRollout covers pinning
+// releases.
+const (
+	ActionView    Action = "view"
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionRollout Action = "rollout"
+	ActionDelete  Action = "delete"
+	// ActionReview covers publishing releases held for permission review,
+	// which publishers must not do to their own uploads.
+	ActionReview Action = "review"
+	// ActionAdminister covers signing certificates, API keys and roles.
+	ActionAdminister Action = "administer"
+)
+
+// roleActions lists what each role may do.
+var roleActions = map[string][]Action{
+	entity.RoleViewer:         {ActionView},
+	entity.RolePublisher:      {ActionView, ActionCreate, ActionUpdate},
+	entity.RoleReleaseManager: {ActionView, ActionCreate, ActionUpdate, ActionRollout, ActionDelete, ActionReview},
+	entity.RoleAdmin:          {ActionView, ActionCreate, ActionUpdate, ActionRollout, ActionDelete, ActionReview, ActionAdminister},
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the caller of the admin API.
+func WithPrincipal(ctx context.Context, principal entity.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller carried by ctx, if any.
+func PrincipalFromContext(ctx context.Context) (entity.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(entity.Principal)
+	return principal, ok
+}
+
+// authorize checks that the caller may perform action on the releases of
+// appID; an empty appID requires a global role. Contexts without a caller,
+// such as background jobs and reads that need no API key, are not
+// restricted: authentication decides who gets that far.
+func authorize(ctx context.Context, action Action, appID string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || allows(principal, action, appID) {
+		return nil
+	}
+	if appID == "" {
+		return fmt.Errorf("%w: %s requires a global role", ErrForbidden, action)
+	}
+	return fmt.Errorf("%w: %s is not allowed for app %s", ErrForbidden, action, appID)
+}
+
+func allows(principal entity.Principal, action Action, appID string) bool {
+	if principal.Superuser {
+		return true
+	}
+	for _, binding := range principal.Bindings {
+		if binding.AppID != "" && binding.AppID != appID {
+			continue
+		}
+		for _, allowed := range roleActions[binding.Role] {
+			if allowed == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorizeRelease looks up a release and checks that the caller may perform
+// action on it. The release is only looked up when there is a caller.
+func authorizeRelease(ctx context.Context, otaRepo repository.OTARepository, action Action, otaID string) error {
+	if _, ok := PrincipalFromContext(ctx); !ok {
+		return nil
+	}
+	ota, _, err := otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return err
+	}
+	return authorize(ctx, action, ota.AppID)
+}
+
+// viewableApps returns the apps whose releases the caller may view, and
+// whether it may view those of every app.
+func viewableApps(ctx context.Context) ([]string, bool) {
+	return allowedApps(ctx, ActionView)
+}
+
+// allowedApps returns the apps whose releases the caller may perform action
+// on, and whether it may do so for every app.
+func allowedApps(ctx context.Context, action Action) ([]string, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || allows(principal, action, "") {
+		return nil, true
+	}
+	var apps []string
+	for _, binding := range principal.Bindings {
+		if binding.AppID != "" && allows(entity.Principal{Bindings: []entity.RoleBinding{binding}}, action, binding.AppID) {
+			apps = append(apps, binding.AppID)
+		}
+	}
+	return apps, false
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+func TestAuthorizeRoleMatrix(t *testing.T) {
+	actions := []Action{ActionView, ActionCreate, ActionUpdate, ActionRollout, ActionDelete, ActionReview, ActionAdminister}
+
+	// allowed lists, per role, the actions it grants; everything else is denied.
+	allowed := map[string][]Action{
+		entity.RoleViewer:         {ActionView},
+		entity.RolePublisher:      {ActionView, ActionCreate, ActionUpdate},
+		entity.RoleReleaseManager: {ActionView, ActionCreate, ActionUpdate, ActionRollout, ActionDelete, ActionReview},
+		entity.RoleAdmin:          actions,
+	}
+
+	for role, granted := range allowed {
+		for _, action := range actions {
+			want := slices.Contains(granted, action)
+
+			for _, tt := range []struct {
+				scope   string
+				binding entity.RoleBinding
+				appID   string
+				want    bool
+			}{
+				{"global role", entity.RoleBinding{Role: role}, "app-1", want},
+				{"global role without app", entity.RoleBinding{Role: role}, "", want},
+				{"app role", entity.RoleBinding{Role: role, AppID: "app-1"}, "app-1", want},
+				{"other app's role", entity.RoleBinding{Role: role, AppID: "app-2"}, "app-1", false},
+				{"app role without app", entity.RoleBinding{Role: role, AppID: "app-1"}, "", false},
+			} {
+				t.Run(role+"/"+string(action)+"/"+tt.scope, func(t *testing.T) {
+					ctx := WithPrincipal(context.Background(), entity.Principal{ID: "key-1", Bindings: []entity.RoleBinding{tt.binding}})
+					err := authorize(ctx, action, tt.appID)
+					if tt.want && err != nil {
+						t.Fatalf("authorize = %v, want allowed", err)
+					}
+					if !tt.want && !errors.Is(err, ErrForbidden) {
+						t.Fatalf("authorize = %v, want ErrForbidden", err)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestAuthorizePrincipals(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		action  Action
+		appID   string
+		allowed bool
+	}{
+		{"no caller", context.Background(), ActionAdminister, "", true},
+		{"superuser", WithPrincipal(context.Background(), entity.Principal{Superuser: true}), ActionAdminister, "", true},
+		{"no bindings", WithPrincipal(context.Background(), entity.Principal{ID: "key-1"}), ActionView, "app-1", false},
+		{"unknown role", WithPrincipal(context.Background(), entity.Principal{Bindings: []entity.RoleBinding{{Role: "owner"}}}), ActionView, "app-1", false},
+		{"highest of several bindings", WithPrincipal(context.Background(), entity.Principal{Bindings: []entity.RoleBinding{
+			{Role: entity.RoleViewer},
+			{Role: entity.RoleReleaseManager, AppID: "app-1"},
+		}}), ActionRollout, "app-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorize(tt.ctx, tt.action, tt.appID)
+			if (err == nil) != tt.allowed {
+				t.Fatalf("authorize = %v, want allowed = %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestAllowedApps(t *testing.T) {
+	ctx := WithPrincipal(context.Background(), entity.Principal{Bindings: []entity.RoleBinding{
+		{Role: entity.RoleViewer, AppID: "app-1"},
+		{Role: entity.RolePublisher, AppID: "app-2"},
+		{Role: entity.RoleReleaseManager, AppID: "app-3"},
+	}})
+
+	tests := []struct {
+		action Action
+		apps   []string
+	}{
+		{ActionView, []string{"app-1", "app-2", "app-3"}},
+		{ActionCreate, []string{"app-2", "app-3"}},
+		{ActionReview, []string{"app-3"}},
+		{ActionAdminister, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			apps, all := allowedApps(ctx, tt.action)
+			slices.Sort(apps)
+			if all || !reflect.DeepEqual(apps, tt.apps) {
+				t.Fatalf("allowedApps = %v, %v; want %v, false", apps, all, tt.apps)
+			}
+		})
+	}
+
+	if _, all := allowedApps(WithPrincipal(context.Background(), entity.Principal{Bindings: []entity.RoleBinding{{Role: entity.RoleViewer}}}), ActionView); !all {
+		t.Fatal("a global viewer may not view every app")
+	}
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// BlobStore stores artifacts under their content digest so identical files
+// are kept once, however many releases or patches reference them. Files are
+// written and deleted while the blob's row is locked, so a blob is never
+// referenced before its file exists or deleted after being referenced again.
+type BlobStore struct {
+	transactor repository.Transactor
+	blobRepo   repository.ArtifactBlobRepository
+	storage    repository.ArtifactStorage
+}
+
+func NewBlobStore(transactor repository.Transactor, blobRepo repository.ArtifactBlobRepository, storage repository.ArtifactStorage) *BlobStore {
+	return &BlobStore{
+		transactor: transactor,
+		blobRepo:   blobRepo,
+		storage:    storage,
+	}
+}
+
+// Put adds a reference to the blob with the given digest and returns its
+// storage key. The content is only read and written if the blob is new.
+func (s *BlobStore) Put(ctx context.Context, sha256 string, size int64, r io.Reader) (string, error) {
+	var key string
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		blob, err := s.blobRepo.Lock(ctx, entity.ArtifactBlob{
+			SHA256:     sha256,
+			StorageKey: blobKey(sha256),
+			SizeBytes:  size,
+		})
+		if err != nil {
+			return err
+		}
+
+		if blob.RefCount == 0 {
+			if _, err := s.storage.Save(ctx, blob.StorageKey, r); err != nil {
+				return err
+			}
+		}
+
+		if _, err := s.blobRepo.AddRef(ctx, sha256); err != nil {
+			return err
+		}
+		key = blob.StorageKey
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Release drops a reference to the blob with the given digest and deletes the
+// file once nothing references it.
+func (s *BlobStore) Release(ctx context.Context, sha256 string) error {
+	if sha256 == "" {
+		return nil
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		blob, removed, err := s.blobRepo.Release(ctx, sha256)
+		if err != nil || !removed {
+			return err
+		}
+		return s.storage.Delete(ctx, blob.StorageKey)
+	})
+}
+
+func blobKey(sha256 string) string {
+	return fmt.Sprintf("blobs/sha256/%s/%s", sha256[:2], sha256)
+}
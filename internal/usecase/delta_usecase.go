@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/bsdiff"
+)
+
+// Patch generation is retried with exponential backoff, starting at
+// patchRetryBackoff, until it was tried maxPatchAttempts times. A worker
+// holds a patch for patchLease, after which another may take it over.
+const (
+	maxPatchAttempts  = 5
+	patchRetryBackoff = time.Minute
+	patchLease        = 30 * time.Minute
+	patchPollInterval = time.Minute
+)
+
+// maxPatchArtifactSize bounds the artifacts patches are generated between.
+// Diffing holds both artifacts and a suffix array of the base in memory, about
+// ten times the size of the base plus twice that of the target.
+const maxPatchArtifactSize = 256 << 20
+
+// DeltaUseCase generates binary patches between releases in the background.
+// Patches to generate are pending rows, so no work is lost on restart and
+// every API instance can take part.
+type DeltaUseCase struct {
+	otaRepo         repository.OTARepository
+	patchRepo       repository.OTAPatchRepository
+	storage         repository.ArtifactStorage
+	downloads       DownloadURLs
+	blobs           *BlobStore
+	maxBaseVersions int
+	wake            chan struct{}
+}
+
+func NewDeltaUseCase(otaRepo repository.OTARepository, patchRepo repository.OTAPatchRepository, storage repository.ArtifactStorage, downloads DownloadURLs, blobs *BlobStore, maxBaseVersions int) *DeltaUseCase {
+	return &DeltaUseCase{
+		otaRepo:         otaRepo,
+		patchRepo:       patchRepo,
+		storage:         storage,
+		downloads:       downloads,
+		blobs:           blobs,
+		maxBaseVersions: maxBaseVersions,
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+// Enqueue schedules patches towards the release from the most recent previous
+// releases of its app. It joins the transaction in ctx, so patches are
+// scheduled exactly when the release is created.
+func (uc *DeltaUseCase) Enqueue(ctx context.Context, target entity.OTA) error {
+	if uc.maxBaseVersions <= 0 || target.StorageKey == "" {
+		return nil
+	}
+
+	bases, err := uc.otaRepo.ListPrevious(ctx, target.AppID, target.PayloadType, target.VersionCode, uc.maxBaseVersions)
+	if err != nil {
+		return err
+	}
+
+	for _, base := range bases {
+		if base.StorageKey == "" || base.SHA256 == "" || base.SHA256 == target.SHA256 {
+			continue
+		}
+		if err := uc.patchRepo.Enqueue(ctx, entity.OTAPatch{
+			OTAID:           target.ID,
+			FromOTAID:       base.ID,
+			FromVersionCode: base.VersionCode,
+			FromSHA256:      base.SHA256,
+			ToSHA256:        target.SHA256,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Wake makes the worker look for pending patches without waiting for its
+// next poll.
+func (uc *DeltaUseCase) Wake() {
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run generates due patches one at a time until ctx is cancelled. Diffing
+// large APKs is memory hungry, so patches are never generated concurrently.
+func (uc *DeltaUseCase) Run(ctx context.Context) {
+	ticker := time.NewTicker(patchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for uc.generateNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-uc.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *DeltaUseCase) ListPatches(ctx context.Context, otaID string) ([]entity.OTAPatch, error) {
+	if otaID == "" {
+		return nil, fmt.Errorf("ID is required")
+	}
+	return uc.patchRepo.ListByOTA(ctx, otaID)
+}
+
+// FindPatch returns a verified patch to the release for a device whose
+// installed artifact has the given digest.
+func (uc *DeltaUseCase) FindPatch(ctx context.Context, otaID string, fromSHA256 string) (*entity.OTAPatch, error) {
+	if fromSHA256 == "" {
+		return nil, nil
+	}
+
+	patch, ok, err := uc.patchRepo.FindReady(ctx, otaID, fromSHA256)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &patch, nil
+}
+
+// generateNext generates the next due patch and reports whether there was one.
+func (uc *DeltaUseCase) generateNext(ctx context.Context) bool {
+	patch, ok, err := uc.patchRepo.ClaimNext(ctx, time.Now(), patchLease, maxPatchAttempts)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to claim a pending patch: %v", err)
+		}
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	result := uc.generatePatch(ctx, patch)
+	if result.Status == entity.PatchStatusFailed && result.Attempts < maxPatchAttempts {
+		next := time.Now().Add(patchRetryBackoff << (result.Attempts - 1))
+		result.NextAttemptAt = &next
+	} else {
+		result.NextAttemptAt = nil
+	}
+
+	completed, err := uc.patchRepo.Complete(ctx, result)
+	if err != nil {
+		log.Printf("Failed to store patch %s: %v", patch.ID, err)
+	}
+	if !completed && result.StorageKey != "" {
+		if err := uc.blobs.Release(ctx, result.SHA256); err != nil {
+			log.Printf("Failed to release patch artifact %s: %v", result.SHA256, err)
+		}
+	}
+
+	log.Printf("Patch %s from %d (attempt %d): %s %s", result.OTAID, result.FromVersionCode, result.Attempts, result.Status, result.Error)
+	return true
+}
+
+// generatePatch diffs the base release of a claimed patch against its target.
+// Only patches that provably reproduce the target artifact are made ready.
+func (uc *DeltaUseCase) generatePatch(ctx context.Context, patch entity.OTAPatch) entity.OTAPatch {
+	patch.Status = entity.PatchStatusFailed
+	patch.Error = ""
+
+	target, _, err := uc.otaRepo.Get(ctx, patch.OTAID, "")
+	if err != nil {
+		patch.Error = err.Error()
+		return patch
+	}
+	base, _, err := uc.otaRepo.Get(ctx, patch.FromOTAID, "")
+	if err != nil {
+		patch.Error = err.Error()
+		return patch
+	}
+	if target.StorageKey == "" || base.StorageKey == "" {
+		patch.Status = entity.PatchStatusSkipped
+		patch.Error = "artifact is no longer stored"
+		return patch
+	}
+	if target.SizeBytes > maxPatchArtifactSize || base.SizeBytes > maxPatchArtifactSize {
+		patch.Status = entity.PatchStatusSkipped
+		patch.Error = fmt.Sprintf("artifacts over %d bytes are not diffed", maxPatchArtifactSize)
+		return patch
+	}
+
+	newData, err := uc.readArtifact(ctx, target.StorageKey, target.SHA256)
+	if err != nil {
+		patch.Error = err.Error()
+		return patch
+	}
+	oldData, err := uc.readArtifact(ctx, base.StorageKey, base.SHA256)
+	if err != nil {
+		patch.Error = err.Error()
+		return patch
+	}
+
+	diff, err := bsdiff.Diff(oldData, newData)
+	if err != nil {
+		patch.Error = err.Error()
+		return patch
+	}
+
+	applied, err := bsdiff.Patch(oldData, diff)
+	if err != nil {
+		patch.Error = "patch verification failed: " + err.Error()
+		return patch
+	}
+	if digest := sha256Hex(applied); digest != target.SHA256 {
+		patch.Error = "patch verification failed: produced digest " + digest
+		return patch
+	}
+
+	if int64(len(diff)) >= int64(len(newData)) {
+		patch.Status = entity.PatchStatusSkipped
+		patch.Error = "patch is not smaller than the full artifact"
+		return patch
+	}
+
+	digest := sha256Hex(diff)
+	key, err := uc.blobs.Put(ctx, digest, int64(len(diff)), bytes.NewReader(diff))
+	if err != nil {
+		patch.Error = err.Error()
+		return patch
+	}
+
+	patch.Status = entity.PatchStatusReady
+	patch.SHA256 = digest
+	patch.SizeBytes = int64(len(diff))
+	patch.StorageKey = key
+	patch.URL = uc.downloads.Patch(target.ID, patch.ID)
+
+	return patch
+}
+
+func (uc *DeltaUseCase) readArtifact(ctx context.Context, key string, expectedSHA256 string) ([]byte, error) {
+	rc, err := uc.storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	if rc.Size > maxPatchArtifactSize {
+		return nil, fmt.Errorf("artifact %s is over %d bytes", key, maxPatchArtifactSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, maxPatchArtifactSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact %s: %w", key, err)
+	}
+	if sha256Hex(data) != expectedSHA256 {
+		return nil, fmt.Errorf("artifact %s does not match its recorded digest", key)
+	}
+
+	return data, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/apikey"
+)
+
+// ErrInvalidEnrollmentToken is returned for an enrollment token that is
+// unknown, expired or already used.
+var ErrInvalidEnrollmentToken = errors.New("invalid enrollment token")
+
+// DeviceUseCase enrolls devices and authenticates them by their credentials.
+type DeviceUseCase struct {
+	tokenRepo      repository.EnrollmentTokenRepository
+	credentialRepo repository.DeviceCredentialRepository
+	tokenTTL       time.Duration
+	audit          *AuditUseCase
+}
+
+// NewDeviceUseCase creates the device use case. Enrollment tokens expire
+// after tokenTTL unless created with a shorter lifetime.
+func NewDeviceUseCase(tokenRepo repository.EnrollmentTokenRepository, credentialRepo repository.DeviceCredentialRepository, tokenTTL time.Duration, audit *AuditUseCase) *DeviceUseCase {
+	return &DeviceUseCase{
+		tokenRepo:      tokenRepo,
+		credentialRepo: credentialRepo,
+		tokenTTL:       tokenTTL,
+		audit:          audit,
+	}
+}
+
+type deviceKey struct{}
+
+// WithDevice returns a context carrying the device making a request.
+func WithDevice(ctx context.Context, credential entity.DeviceCredential) context.Context {
+	return context.WithValue(ctx, deviceKey{}, credential)
+}
+
+// DeviceFromContext returns the authenticated device carried by ctx, if any.
+func DeviceFromContext(ctx context.Context) (entity.DeviceCredential, bool) {
+	credential, ok := ctx.Value(deviceKey{}).(entity.DeviceCredential)
+	return credential, ok
+}
+
+// CreateEnrollmentToken issues a one-time token a device of the app, or of
+// any app when appID is empty, enrolls with. The returned token cannot be
+// retrieved again.
+func (uc *DeviceUseCase) CreateEnrollmentToken(ctx context.Context, appID string, ttl time.Duration) (entity.CreatedEnrollmentToken, error) {
+	if ttl < 0 {
+		return entity.CreatedEnrollmentToken{}, fmt.Errorf("lifetime must not be negative")
+	}
+	if ttl == 0 || ttl > uc.tokenTTL {
+		ttl = uc.tokenTTL
+	}
+	if err := authorize(ctx, ActionAdminister, appID); err != nil {
+		return entity.CreatedEnrollmentToken{}, err
+	}
+
+	generated, err := apikey.Generate(apikey.SchemeEnrollmentToken)
+	if err != nil {
+		return entity.CreatedEnrollmentToken{}, fmt.Errorf("failed to generate enrollment token: %w", err)
+	}
+
+	token := entity.EnrollmentToken{
+		AppID:     appID,
+		Prefix:    generated.Prefix,
+		Salt:      generated.Salt,
+		Hash:      generated.Hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		token.CreatedBy = principal.Name
+	}
+
+	var created entity.EnrollmentToken
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		created, err = uc.tokenRepo.Create(ctx, token)
+		return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceEnrollmentToken, ResourceID: created.ID, AppID: appID, After: created}, err
+	})
+	if err != nil {
+		return entity.CreatedEnrollmentToken{}, err
+	}
+
+	return entity.CreatedEnrollmentToken{EnrollmentToken: created, Token: generated.Token}, nil
+}
+
+// Enroll exchanges an enrollment token for the device's credential. A device
+// that enrolls again for the same app loses its previous credential.
+func (uc *DeviceUseCase) Enroll(ctx context.Context, enrollmentToken string, deviceID string) (entity.EnrolledDevice, error) {
+	if deviceID == "" {
+		return entity.EnrolledDevice{}, ErrDeviceIDRequired
+	}
+
+	prefix, secret, err := apikey.Parse(apikey.SchemeEnrollmentToken, enrollmentToken)
+	if err != nil {
+		return entity.EnrolledDevice{}, ErrInvalidEnrollmentToken
+	}
+	token, ok, err := uc.tokenRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return entity.EnrolledDevice{}, err
+	}
+	if !ok || !apikey.Verify(token.Salt, token.Hash, secret) {
+		return entity.EnrolledDevice{}, ErrInvalidEnrollmentToken
+	}
+
+	generated, err := apikey.Generate(apikey.SchemeDevice)
+	if err != nil {
+		return entity.EnrolledDevice{}, fmt.Errorf("failed to generate device credential: %w", err)
+	}
+
+	var credential entity.DeviceCredential
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		credential, ok, err = uc.credentialRepo.Enroll(ctx, token.ID, entity.DeviceCredential{
+			DeviceID: deviceID,
+			AppID:    token.AppID,
+			Prefix:   generated.Prefix,
+			Salt:     generated.Salt,
+			Hash:     generated.Hash,
+		})
+		if err != nil || !ok {
+			return AuditChange{}, err
+		}
+		return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceDevice, ResourceID: credential.ID, AppID: credential.AppID, After: credential}, nil
+	})
+	if err != nil {
+		return entity.EnrolledDevice{}, err
+	}
+	if !ok {
+		return entity.EnrolledDevice{}, fmt.Errorf("%w: expired or already used", ErrInvalidEnrollmentToken)
+	}
+
+	return entity.EnrolledDevice{DeviceCredential: credential, Token: generated.Token}, nil
+}
+
+// Authenticate returns the credential a device token belongs to, recording
+// when the device was last seen.
+func (uc *DeviceUseCase) Authenticate(ctx context.Context, token string) (entity.DeviceCredential, error) {
+	prefix, secret, err := apikey.Parse(apikey.SchemeDevice, token)
+	if err != nil {
+		return entity.DeviceCredential{}, ErrUnauthenticated
+	}
+
+	credential, ok, err := uc.credentialRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return entity.DeviceCredential{}, err
+	}
+	if !ok || credential.RevokedAt != nil || !apikey.Verify(credential.Salt, credential.Hash, secret) {
+		return entity.DeviceCredential{}, ErrUnauthenticated
+	}
+
+	now := time.Now()
+	if credential.LastSeenAt == nil || now.Sub(*credential.LastSeenAt) >= lastUsedResolution {
+		if err := uc.credentialRepo.TouchLastSeen(ctx, credential.ID, now); err != nil {
+			log.Printf("Failed to record check-in of device %s: %v", credential.DeviceID, err)
+		}
+		credential.LastSeenAt = &now
+	}
+
+	return credential, nil
+}
+
+// ListDevices returns the credentials of the app's devices, or of every
+// device when appID is empty.
+func (uc *DeviceUseCase) ListDevices(ctx context.Context, appID string) ([]entity.DeviceCredential, error) {
+	if err := authorize(ctx, ActionAdminister, appID); err != nil {
+		return nil, err
+	}
+	return uc.credentialRepo.List(ctx, appID)
+}
+
+func (uc *DeviceUseCase) RevokeDevice(ctx context.Context, id string) (entity.DeviceCredential, error) {
+	if id == "" {
+		return entity.DeviceCredential{}, fmt.Errorf("ID is required")
+	}
+
+	credential, err := uc.credentialRepo.Get(ctx, id)
+	if err != nil {
+		return entity.DeviceCredential{}, err
+	}
+	if err := authorize(ctx, ActionAdminister, credential.AppID); err != nil {
+		return entity.DeviceCredential{}, err
+	}
+
+	var revoked entity.DeviceCredential
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		revoked, err = uc.credentialRepo.Revoke(ctx, id)
+		return AuditChange{Action: entity.AuditActionUpdate, ResourceType: entity.AuditResourceDevice, ResourceID: id, AppID: credential.AppID, Before: credential, After: revoked}, err
+	})
+	return revoked, err
+}
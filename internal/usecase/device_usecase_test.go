@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+func newTestDeviceUseCase(store *fakeStore) *DeviceUseCase {
+	audit := NewAuditUseCase(store, &fakeAuditRepo{store: store})
+	return NewDeviceUseCase(&fakeTokenRepo{store: store}, &fakeCredentialRepo{store: store}, time.Hour, audit)
+}
+
+func newEnrollmentToken(t *testing.T, uc *DeviceUseCase) entity.CreatedEnrollmentToken {
+	t.Helper()
+	token, err := uc.CreateEnrollmentToken(context.Background(), "launcher", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// deviceAuditEntries returns the audit entries about device credentials.
+func deviceAuditEntries(store *fakeStore) []entity.AuditEntry {
+	var entries []entity.AuditEntry
+	for _, entry := range store.tables.audit {
+		if entry.ResourceType == entity.AuditResourceDevice {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestEnroll(t *testing.T) {
+	store := newFakeStore()
+	uc := newTestDeviceUseCase(store)
+	ctx := context.Background()
+
+	token := newEnrollmentToken(t, uc)
+	enrolled, err := uc.Enroll(ctx, token.Token, "device-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enrolled.DeviceID != "device-1" || enrolled.AppID != "launcher" || enrolled.EnrollmentTokenID != token.ID {
+		t.Fatalf("enrolled %+v", enrolled.DeviceCredential)
+	}
+
+	entries := deviceAuditEntries(store)
+	if len(entries) != 1 || entries[0].Action != entity.AuditActionCreate || entries[0].ResourceID != enrolled.ID || entries[0].AppID != "launcher" {
+		t.Fatalf("audit entries %+v, want the enrollment", entries)
+	}
+
+	credential, err := uc.Authenticate(ctx, enrolled.Token)
+	if err != nil {
+		t.Fatalf("the issued credential does not authenticate: %v", err)
+	}
+	if credential.ID != enrolled.ID {
+		t.Fatalf("authenticated as %s, want %s", credential.ID, enrolled.ID)
+	}
+
+	// Enrolling again with a new token revokes the previous credential
+	if _, err := uc.Enroll(ctx, newEnrollmentToken(t, uc).Token, "device-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.Authenticate(ctx, enrolled.Token); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("revoked credential authenticated: %v", err)
+	}
+}
+
+func TestEnrollRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    func(t *testing.T, store *fakeStore, uc *DeviceUseCase) string
+		deviceID string
+		err      error
+	}{
+		{
+			name: "token already used",
+			token: func(t *testing.T, store *fakeStore, uc *DeviceUseCase) string {
+				token := newEnrollmentToken(t, uc)
+				if _, err := uc.Enroll(context.Background(), token.Token, "device-0"); err != nil {
+					t.Fatal(err)
+				}
+				return token.Token
+			},
+			deviceID: "device-1",
+			err:      ErrInvalidEnrollmentToken,
+		},
+		{
+			name: "token expired",
+			token: func(t *testing.T, store *fakeStore, uc *DeviceUseCase) string {
+				token := newEnrollmentToken(t, uc)
+				expired := store.tables.tokens[token.ID]
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				store.tables.tokens[token.ID] = expired
+				return token.Token
+			},
+			deviceID: "device-1",
+			err:      ErrInvalidEnrollmentToken,
+		},
+		{
+			name: "wrong secret",
+			token: func(t *testing.T, store *fakeStore, uc *DeviceUseCase) string {
+				token := newEnrollmentToken(t, uc).Token
+				last := "x"
+				if token[len(token)-1] == 'x' {
+					last = "y"
+				}
+				return token[:len(token)-1] + last
+			},
+			deviceID: "device-1",
+			err:      ErrInvalidEnrollmentToken,
+		},
+		{
+			name: "malformed token",
+			token: func(t *testing.T, store *fakeStore, uc *DeviceUseCase) string {
+				return "not-a-token"
+			},
+			deviceID: "device-1",
+			err:      ErrInvalidEnrollmentToken,
+		},
+		{
+			name: "missing device ID",
+			token: func(t *testing.T, store *fakeStore, uc *DeviceUseCase) string {
+				return newEnrollmentToken(t, uc).Token
+			},
+			err: ErrDeviceIDRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			uc := newTestDeviceUseCase(store)
+			token := tt.token(t, store, uc)
+			credentials := len(store.tables.credentials)
+			entries := len(deviceAuditEntries(store))
+
+			if _, err := uc.Enroll(context.Background(), token, tt.deviceID); !errors.Is(err, tt.err) {
+				t.Fatalf("Enroll error = %v, want %v", err, tt.err)
+			}
+			if len(store.tables.credentials) != credentials {
+				t.Error("a rejected enrollment issued a credential")
+			}
+			if len(deviceAuditEntries(store)) != entries {
+				t.Error("a rejected enrollment was audited")
+			}
+		})
+	}
+}
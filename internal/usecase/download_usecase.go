@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/urlsign"
+)
+
+var (
+	// ErrDownloadForbidden is returned when a download URL is unsigned, expired or tampered with.
+	ErrDownloadForbidden = errors.New("download URL is not valid")
+	// ErrDeviceIDRequired is returned when a signed URL is requested without a device to bind it to.
+	ErrDeviceIDRequired = errors.New("device ID is required")
+)
+
+// DownloadURLs builds the API URLs stored artifacts are downloaded from.
+// Stored artifacts are only ever served through the API, so every download
+// goes through the same authentication and release checks.
+type DownloadURLs struct {
+	baseURL string
+}
+
+func NewDownloadURLs(baseURL string) DownloadURLs {
+	return DownloadURLs{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// OTA returns the download URL of a release.
+func (u DownloadURLs) OTA(otaID string) string {
+	return u.baseURL + fmt.Sprintf("/api/v1/otas/%s/download", otaID)
+}
+
+// Patch returns the download URL of a patch to a release.
+func (u DownloadURLs) Patch(otaID string, patchID string) string {
+	return u.baseURL + fmt.Sprintf("/api/v1/otas/%s/patches/%s/download", otaID, patchID)
+}
+
+// Artifact returns the download URL of an additional artifact of a release.
+func (u DownloadURLs) Artifact(otaID string, artifactID string) string {
+	return u.baseURL + fmt.Sprintf("/api/v1/otas/%s/artifacts/%s/download", otaID, artifactID)
+}
+
+// DownloadUseCase serves release artifacts through the API and tracks the traffic.
+type DownloadUseCase struct {
+	otaRepo      repository.OTARepository
+	patchRepo    repository.OTAPatchRepository
+	artifactRepo repository.OTAArtifactRepository
+	statsRepo    repository.DownloadStatsRepository
+	storage      repository.ArtifactStorage
+	signer       *urlsign.Signer
+	urls         DownloadURLs
+	urlTTL       time.Duration
+}
+
+// NewDownloadUseCase creates the download use case. A nil signer disables
+// signed URLs and downloads are served to anyone who knows the release ID.
+func NewDownloadUseCase(otaRepo repository.OTARepository, patchRepo repository.OTAPatchRepository, artifactRepo repository.OTAArtifactRepository, statsRepo repository.DownloadStatsRepository, storage repository.ArtifactStorage, signer *urlsign.Signer, urls DownloadURLs, urlTTL time.Duration) *DownloadUseCase {
+	return &DownloadUseCase{
+		otaRepo:      otaRepo,
+		patchRepo:    patchRepo,
+		artifactRepo: artifactRepo,
+		statsRepo:    statsRepo,
+		storage:      storage,
+		signer:       signer,
+		urls:         urls,
+		urlTTL:       urlTTL,
+	}
+}
+
+// SigningEnabled reports whether downloads require a signed URL.
+func (uc *DownloadUseCase) SigningEnabled() bool {
+	return uc.signer != nil
+}
+
+// SignOTAURL returns a download URL for the release bound to the device. The
+// release's own URL is returned unchanged when signing is disabled.
+func (uc *DownloadUseCase) SignOTAURL(ota entity.OTA, deviceID string) (string, error) {
+	if uc.signer == nil {
+		return ota.URL, nil
+	}
+	return uc.signURL(otaResource(ota.ID), uc.urls.OTA(ota.ID), deviceID)
+}
+
+// SignPatchURL returns a download URL for the patch bound to the device.
+func (uc *DownloadUseCase) SignPatchURL(patch entity.OTAPatch, deviceID string) (string, error) {
+	if uc.signer == nil {
+		return patch.URL, nil
+	}
+	return uc.signURL(patchResource(patch.ID), uc.urls.Patch(patch.OTAID, patch.ID), deviceID)
+}
+
+// SignArtifactURL returns a download URL for an artifact of a release bound to the device.
+func (uc *DownloadUseCase) SignArtifactURL(artifact entity.OTAArtifact, deviceID string) (string, error) {
+	if uc.signer == nil {
+		return artifact.URL, nil
+	}
+	return uc.signURL(artifactResource(artifact.ID), uc.urls.Artifact(artifact.OTAID, artifact.ID), deviceID)
+}
+
+func (uc *DownloadUseCase) signURL(resource string, downloadURL string, deviceID string) (string, error) {
+	if deviceID == "" {
+		return "", ErrDeviceIDRequired
+	}
+	query := uc.signer.Sign(resource, deviceID, time.Now().Add(uc.urlTTL))
+	return downloadURL + "?" + query.Encode(), nil
+}
+
+// AuthorizeOTA verifies the signature of a release download request.
+func (uc *DownloadUseCase) AuthorizeOTA(ctx context.Context, otaID string, query url.Values) error {
+	return uc.authorize(ctx, otaResource(otaID), query)
+}
+
+// AuthorizePatch verifies the signature of a patch download request.
+func (uc *DownloadUseCase) AuthorizePatch(ctx context.Context, patchID string, query url.Values) error {
+	return uc.authorize(ctx, patchResource(patchID), query)
+}
+
+// AuthorizeArtifact verifies the signature of a release artifact download request.
+func (uc *DownloadUseCase) AuthorizeArtifact(ctx context.Context, artifactID string, query url.Values) error {
+	return uc.authorize(ctx, artifactResource(artifactID), query)
+}
+
+// authorize verifies a signed download URL. An authenticated device may only
+// use URLs signed for itself, so a leaked URL is useless to other devices.
+func (uc *DownloadUseCase) authorize(ctx context.Context, resource string, query url.Values) error {
+	if uc.signer == nil {
+		return nil
+	}
+	if err := uc.signer.Verify(resource, query, time.Now()); err != nil {
+		return fmt.Errorf("%w: %v", ErrDownloadForbidden, err)
+	}
+	if device, ok := DeviceFromContext(ctx); ok && device.DeviceID != query.Get("device_id") {
+		return fmt.Errorf("%w: URL was signed for another device", ErrDownloadForbidden)
+	}
+	return nil
+}
+
+// OpenArtifact opens the stored artifact of a release. The returned object is
+// nil when the release points at an external URL that cannot be proxied.
+func (uc *DownloadUseCase) OpenArtifact(ctx context.Context, otaID string) (entity.OTA, *repository.ArtifactObject, error) {
+	if otaID == "" {
+		return entity.OTA{}, nil, fmt.Errorf("ID is required")
+	}
+
+	ota, err := uc.downloadableRelease(ctx, otaID)
+	if err != nil {
+		return entity.OTA{}, nil, err
+	}
+	if ota.StorageKey == "" {
+		return ota, nil, nil
+	}
+
+	obj, err := uc.storage.Open(ctx, ota.StorageKey)
+	if err != nil {
+		return entity.OTA{}, nil, err
+	}
+
+	return ota, obj, nil
+}
+
+// OpenPatch opens the stored artifact of a ready patch to the given release.
+func (uc *DownloadUseCase) OpenPatch(ctx context.Context, otaID string, patchID string) (entity.OTAPatch, *repository.ArtifactObject, error) {
+	if otaID == "" || patchID == "" {
+		return entity.OTAPatch{}, nil, fmt.Errorf("ID is required")
+	}
+	if _, err := uc.downloadableRelease(ctx, otaID); err != nil {
+		return entity.OTAPatch{}, nil, err
+	}
+
+	patch, err := uc.patchRepo.Get(ctx, patchID)
+	if err != nil {
+		return entity.OTAPatch{}, nil, err
+	}
+	if patch.OTAID != otaID || patch.Status != entity.PatchStatusReady || patch.StorageKey == "" {
+		return entity.OTAPatch{}, nil, fmt.Errorf("ota patch not found")
+	}
+
+	obj, err := uc.storage.Open(ctx, patch.StorageKey)
+	if err != nil {
+		return entity.OTAPatch{}, nil, err
+	}
+
+	return patch, obj, nil
+}
+
+// OpenOTAArtifact opens one of the additional artifacts of a release.
+func (uc *DownloadUseCase) OpenOTAArtifact(ctx context.Context, otaID string, artifactID string) (entity.OTAArtifact, *repository.ArtifactObject, error) {
+	if otaID == "" || artifactID == "" {
+		return entity.OTAArtifact{}, nil, fmt.Errorf("ID is required")
+	}
+	if _, err := uc.downloadableRelease(ctx, otaID); err != nil {
+		return entity.OTAArtifact{}, nil, err
+	}
+
+	artifact, err := uc.artifactRepo.Get(ctx, artifactID)
+	if err != nil {
+		return entity.OTAArtifact{}, nil, err
+	}
+	if artifact.OTAID != otaID {
+		return entity.OTAArtifact{}, nil, fmt.Errorf("ota artifact not found")
+	}
+
+	obj, err := uc.storage.Open(ctx, artifact.StorageKey)
+	if err != nil {
+		return entity.OTAArtifact{}, nil, err
+	}
+
+	return artifact, obj, nil
+}
+
+// downloadableRelease returns the release unless it may not be downloaded:
+// archived releases, releases held for permission review and releases of
+// apps the caller is not allowed to see. Releases have no channels, so access
+// is granted per app: to devices enrolled for the app and to API keys with a
+// role for it.
+func (uc *DownloadUseCase) downloadableRelease(ctx context.Context, otaID string) (entity.OTA, error) {
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return entity.OTA{}, err
+	}
+	if device, ok := DeviceFromContext(ctx); ok && device.AppID != "" && device.AppID != ota.AppID {
+		return entity.OTA{}, fmt.Errorf("%w: credential was issued for another app", ErrForbidden)
+	}
+	if err := authorize(ctx, ActionView, ota.AppID); err != nil {
+		return entity.OTA{}, err
+	}
+	switch ota.Status {
+	case entity.OTAStatusArchived:
+		return entity.OTA{}, fmt.Errorf("ota is archived")
+	case entity.OTAStatusPendingReview:
+		return entity.OTA{}, fmt.Errorf("ota is held for permission review")
+	}
+	return ota, nil
+}
+
+// RecordDownload adds served bytes to the release's download statistics,
+// whether the release itself, one of its artifacts or a patch to it was
+// downloaded. It runs after the response has been streamed, outside the
+// request context.
+func (uc *DownloadUseCase) RecordDownload(otaID string, bytesServed int64, completed bool) {
+	if err := uc.statsRepo.Record(context.Background(), otaID, bytesServed, completed); err != nil {
+		log.Printf("Failed to record download of OTA %s: %v", otaID, err)
+	}
+}
+
+func (uc *DownloadUseCase) GetStats(ctx context.Context, otaID string) (entity.DownloadStats, error) {
+	if otaID == "" {
+		return entity.DownloadStats{}, fmt.Errorf("ID is required")
+	}
+	if err := authorizeRelease(ctx, uc.otaRepo, ActionView, otaID); err != nil {
+		return entity.DownloadStats{}, err
+	}
+	return uc.statsRepo.Get(ctx, otaID)
+}
+
+func otaResource(otaID string) string {
+	return "ota/" + otaID
+}
+
+func patchResource(patchID string) string {
+	return "patch/" + patchID
+}
+
+func artifactResource(artifactID string) string {
+	return "artifact/" + artifactID
+}
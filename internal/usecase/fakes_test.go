@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// fakeTables are the rows of the in-memory database.
+type fakeTables struct {
+	otas        map[string]entity.OTA
+	artifacts   map[string]entity.OTAArtifact
+	patches     map[string]entity.OTAPatch
+	blobs       map[string]entity.ArtifactBlob
+	files       map[string][]byte
+	audit       []entity.AuditEntry
+	tokens      map[string]entity.EnrollmentToken
+	credentials map[string]entity.DeviceCredential
+}
+
+func (t fakeTables) clone() fakeTables {
+	return fakeTables{
+		otas:        maps.Clone(t.otas),
+		artifacts:   maps.Clone(t.artifacts),
+		patches:     maps.Clone(t.patches),
+		blobs:       maps.Clone(t.blobs),
+		files:       maps.Clone(t.files),
+		audit:       slices.Clone(t.audit),
+		tokens:      maps.Clone(t.tokens),
+		credentials: maps.Clone(t.credentials),
+	}
+}
+
+// fakeStore is an in-memory database backing the fake repositories. A
+// transaction snapshots every table and restores them when it rolls back;
+// nested transactions join the outer one. Stored files are part of the
+// snapshot too, so a rolled back transaction leaves them as they were.
+type fakeStore struct {
+	tables fakeTables
+	depth  int
+	nextID int
+
+	// auditErr fails every audit entry, rolling its transaction back.
+	auditErr error
+	// releases records the transaction depth at which each blob reference
+	// was dropped.
+	releases map[string][]int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		tables: fakeTables{
+			otas:        map[string]entity.OTA{},
+			artifacts:   map[string]entity.OTAArtifact{},
+			patches:     map[string]entity.OTAPatch{},
+			blobs:       map[string]entity.ArtifactBlob{},
+			files:       map[string][]byte{},
+			tokens:      map[string]entity.EnrollmentToken{},
+			credentials: map[string]entity.DeviceCredential{},
+		},
+		releases: map[string][]int{},
+	}
+}
+
+func (s *fakeStore) id(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", prefix, s.nextID)
+}
+
+// putBlob stores a file under its digest with the given number of references.
+func (s *fakeStore) putBlob(digest string, refs int) string {
+	key := blobKey(digest)
+	s.tables.blobs[digest] = entity.ArtifactBlob{SHA256: digest, StorageKey: key, SizeBytes: int64(len(digest)), RefCount: refs}
+	s.tables.files[key] = []byte(digest)
+	return key
+}
+
+func (s *fakeStore) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	var snapshot fakeTables
+	outer := s.depth == 0
+	if outer {
+		snapshot = s.tables.clone()
+	}
+
+	s.depth++
+	err := fn(ctx)
+	s.depth--
+	if err != nil && outer {
+		s.tables = snapshot
+	}
+	return err
+}
+
+type fakeOTARepo struct {
+	repository.OTARepository
+	store *fakeStore
+	// onListCandidates runs once retention candidates were listed, to change
+	// releases between planning and archiving.
+	onListCandidates func()
+}
+
+func (r *fakeOTARepo) Get(ctx context.Context, id string, appID string) (entity.OTA, string, error) {
+	ota, ok := r.store.tables.otas[id]
+	if !ok {
+		return entity.OTA{}, "", fmt.Errorf("ota not found")
+	}
+	return ota, "", nil
+}
+
+// ListRetentionCandidates returns the unpinned published releases of the app
+// but the keepLast newest ones.
+func (r *fakeOTARepo) ListRetentionCandidates(ctx context.Context, appID string, keepLast int, installedSince time.Time) ([]entity.OTA, error) {
+	var published []entity.OTA
+	for _, ota := range r.store.tables.otas {
+		if ota.AppID == appID && ota.Status == entity.OTAStatusPublished {
+			published = append(published, ota)
+		}
+	}
+	slices.SortFunc(published, func(a, b entity.OTA) int { return b.VersionCode - a.VersionCode })
+
+	var candidates []entity.OTA
+	for i, ota := range published {
+		if i >= keepLast && !ota.Pinned {
+			candidates = append(candidates, ota)
+		}
+	}
+	if r.onListCandidates != nil {
+		r.onListCandidates()
+	}
+	return candidates, nil
+}
+
+func (r *fakeOTARepo) Archive(ctx context.Context, id string) (bool, error) {
+	ota, ok := r.store.tables.otas[id]
+	if !ok || ota.Pinned || ota.Status != entity.OTAStatusPublished {
+		return false, nil
+	}
+	now := time.Now()
+	ota.Status, ota.ArchivedAt = entity.OTAStatusArchived, &now
+	r.store.tables.otas[id] = ota
+	return true, nil
+}
+
+// Delete removes the release together with its artifacts and patches.
+func (r *fakeOTARepo) Delete(ctx context.Context, id string) error {
+	if _, ok := r.store.tables.otas[id]; !ok {
+		return fmt.Errorf("ota not found")
+	}
+	delete(r.store.tables.otas, id)
+	maps.DeleteFunc(r.store.tables.artifacts, func(_ string, a entity.OTAArtifact) bool { return a.OTAID == id })
+	maps.DeleteFunc(r.store.tables.patches, func(_ string, p entity.OTAPatch) bool { return p.OTAID == id || p.FromOTAID == id })
+	return nil
+}
+
+type fakeArtifactRepo struct {
+	repository.OTAArtifactRepository
+	store *fakeStore
+}
+
+func (r *fakeArtifactRepo) ListByOTA(ctx context.Context, otaID string) ([]entity.OTAArtifact, error) {
+	var artifacts []entity.OTAArtifact
+	for _, a := range r.store.tables.artifacts {
+		if a.OTAID == otaID {
+			artifacts = append(artifacts, a)
+		}
+	}
+	return artifacts, nil
+}
+
+func (r *fakeArtifactRepo) Delete(ctx context.Context, id string) error {
+	delete(r.store.tables.artifacts, id)
+	return nil
+}
+
+type fakePatchRepo struct {
+	repository.OTAPatchRepository
+	store *fakeStore
+}
+
+func (r *fakePatchRepo) ListInvolving(ctx context.Context, otaID string) ([]entity.OTAPatch, error) {
+	var patches []entity.OTAPatch
+	for _, p := range r.store.tables.patches {
+		if p.OTAID == otaID || p.FromOTAID == otaID {
+			patches = append(patches, p)
+		}
+	}
+	return patches, nil
+}
+
+func (r *fakePatchRepo) Delete(ctx context.Context, id string) error {
+	delete(r.store.tables.patches, id)
+	return nil
+}
+
+type fakePolicyRepo struct {
+	repository.RetentionPolicyRepository
+}
+
+func (r *fakePolicyRepo) Get(ctx context.Context, appID string) (entity.RetentionPolicy, bool, error) {
+	return entity.RetentionPolicy{}, false, nil
+}
+
+type fakeBlobRepo struct {
+	store *fakeStore
+}
+
+func (r *fakeBlobRepo) Lock(ctx context.Context, blob entity.ArtifactBlob) (entity.ArtifactBlob, error) {
+	if existing, ok := r.store.tables.blobs[blob.SHA256]; ok {
+		return existing, nil
+	}
+	r.store.tables.blobs[blob.SHA256] = blob
+	return blob, nil
+}
+
+func (r *fakeBlobRepo) AddRef(ctx context.Context, sha256 string) (entity.ArtifactBlob, error) {
+	blob := r.store.tables.blobs[sha256]
+	blob.RefCount++
+	r.store.tables.blobs[sha256] = blob
+	return blob, nil
+}
+
+func (r *fakeBlobRepo) Release(ctx context.Context, sha256 string) (entity.ArtifactBlob, bool, error) {
+	r.store.releases[sha256] = append(r.store.releases[sha256], r.store.depth)
+
+	blob, ok := r.store.tables.blobs[sha256]
+	if !ok {
+		return entity.ArtifactBlob{}, false, nil
+	}
+	blob.RefCount--
+	if blob.RefCount > 0 {
+		r.store.tables.blobs[sha256] = blob
+		return blob, false, nil
+	}
+	delete(r.store.tables.blobs, sha256)
+	return blob, true, nil
+}
+
+type fakeStorage struct {
+	store *fakeStore
+}
+
+func (s *fakeStorage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	s.store.tables.files[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *fakeStorage) Open(ctx context.Context, key string) (*repository.ArtifactObject, error) {
+	data, ok := s.store.tables.files[key]
+	if !ok {
+		return nil, fmt.Errorf("artifact %s not found", key)
+	}
+	return &repository.ArtifactObject{ReadSeekCloser: nopSeekCloser{bytes.NewReader(data)}, Size: int64(len(data))}, nil
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, key string) error {
+	delete(s.store.tables.files, key)
+	return nil
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+type fakeAuditRepo struct {
+	repository.AuditRepository
+	store *fakeStore
+}
+
+func (r *fakeAuditRepo) Create(ctx context.Context, entry entity.AuditEntry) (entity.AuditEntry, error) {
+	if r.store.auditErr != nil {
+		return entity.AuditEntry{}, r.store.auditErr
+	}
+	entry.ID = int64(len(r.store.tables.audit) + 1)
+	r.store.tables.audit = append(r.store.tables.audit, entry)
+	return entry, nil
+}
+
+type fakeTokenRepo struct {
+	store *fakeStore
+}
+
+func (r *fakeTokenRepo) Create(ctx context.Context, token entity.EnrollmentToken) (entity.EnrollmentToken, error) {
+	token.ID = r.store.id("token")
+	token.CreatedAt = time.Now()
+	r.store.tables.tokens[token.ID] = token
+	return token, nil
+}
+
+func (r *fakeTokenRepo) GetByPrefix(ctx context.Context, prefix string) (entity.EnrollmentToken, bool, error) {
+	for _, token := range r.store.tables.tokens {
+		if token.Prefix == prefix {
+			return token, true, nil
+		}
+	}
+	return entity.EnrollmentToken{}, false, nil
+}
+
+type fakeCredentialRepo struct {
+	repository.DeviceCredentialRepository
+	store *fakeStore
+}
+
+func (r *fakeCredentialRepo) Enroll(ctx context.Context, tokenID string, credential entity.DeviceCredential) (entity.DeviceCredential, bool, error) {
+	now := time.Now()
+	token, ok := r.store.tables.tokens[tokenID]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return entity.DeviceCredential{}, false, nil
+	}
+	token.UsedAt, token.UsedByDeviceID = &now, credential.DeviceID
+	r.store.tables.tokens[tokenID] = token
+
+	for id, c := range r.store.tables.credentials {
+		if c.DeviceID == credential.DeviceID && c.AppID == credential.AppID && c.RevokedAt == nil {
+			c.RevokedAt = &now
+			r.store.tables.credentials[id] = c
+		}
+	}
+
+	credential.ID = r.store.id("credential")
+	credential.EnrollmentTokenID = tokenID
+	credential.CreatedAt = now
+	r.store.tables.credentials[credential.ID] = credential
+	return credential, true, nil
+}
+
+func (r *fakeCredentialRepo) GetByPrefix(ctx context.Context, prefix string) (entity.DeviceCredential, bool, error) {
+	for _, c := range r.store.tables.credentials {
+		if c.Prefix == prefix {
+			return c, true, nil
+		}
+	}
+	return entity.DeviceCredential{}, false, nil
+}
+
+func (r *fakeCredentialRepo) TouchLastSeen(ctx context.Context, id string, seenAt time.Time) error {
+	c := r.store.tables.credentials[id]
+	c.LastSeenAt = &seenAt
+	r.store.tables.credentials[id] = c
+	return nil
+}
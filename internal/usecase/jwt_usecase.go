@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/pkg/jwt"
+)
+
+// JWTUseCase authenticates callers of the admin API by the JWT bearer tokens
+// of an OpenID Connect provider, granting them the roles their claims map to.
+type JWTUseCase struct {
+	verifier   *jwt.Verifier
+	rolesClaim string
+	// mappings maps claim values to the roles they grant. When nil, claim
+	// values are read as roles themselves.
+	mappings map[string][]entity.RoleBinding
+}
+
+// NewJWTUseCase creates the JWT use case. Roles are taken from rolesClaim,
+// which may name a nested claim such as realm_access.roles. Its values are
+// looked up in mappings when given, and otherwise read as "role" for every
+// app or "role:app_id" for one.
+func NewJWTUseCase(verifier *jwt.Verifier, rolesClaim string, mappings map[string][]entity.RoleBinding) *JWTUseCase {
+	return &JWTUseCase{
+		verifier:   verifier,
+		rolesClaim: rolesClaim,
+		mappings:   mappings,
+	}
+}
+
+// Authenticate verifies a token and returns the caller it identifies.
+func (uc *JWTUseCase) Authenticate(ctx context.Context, token string) (entity.Principal, error) {
+	claims, err := uc.verifier.Verify(ctx, token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrKeySetUnavailable) {
+			return entity.Principal{}, err
+		}
+		return entity.Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	subject := claims.String("sub")
+	if subject == "" {
+		return entity.Principal{}, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	principal := entity.Principal{ID: subject, Name: subject, Method: entity.AuthMethodJWT}
+	for _, name := range []string{"email", "preferred_username", "name"} {
+		if value := claims.String(name); value != "" {
+			principal.Name = value
+			break
+		}
+	}
+
+	for _, value := range claims.Strings(uc.rolesClaim) {
+		if uc.mappings != nil {
+			principal.Bindings = append(principal.Bindings, uc.mappings[value]...)
+			continue
+		}
+		binding, err := ParseRoleGrant(value)
+		if err != nil {
+			log.Printf("Ignoring %s claim %q of %s: %v", uc.rolesClaim, value, subject, err)
+			continue
+		}
+		principal.Bindings = append(principal.Bindings, binding)
+	}
+
+	return principal, nil
+}
+
+// ParseRoleGrant parses "role" or "role:app_id" into a role binding.
+func ParseRoleGrant(s string) (entity.RoleBinding, error) {
+	role, appID, _ := strings.Cut(strings.TrimSpace(s), ":")
+	if !entity.IsValidRole(role) {
+		return entity.RoleBinding{}, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	return entity.RoleBinding{Role: role, AppID: appID}, nil
+}
+
+// ParseRoleMappings parses comma separated "value=role" or
+// "value=role:app_id" pairs. A value may be listed more than once.
+func ParseRoleMappings(s string) (map[string][]entity.RoleBinding, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	mappings := make(map[string][]entity.RoleBinding)
+	for _, pair := range strings.Split(s, ",") {
+		value, grant, ok := strings.Cut(pair, "=")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid role mapping %q", pair)
+		}
+		binding, err := ParseRoleGrant(grant)
+		if err != nil {
+			return nil, fmt.Errorf("invalid role mapping %q: %w", pair, err)
+		}
+		mappings[value] = append(mappings[value], binding)
+	}
+
+	return mappings, nil
+}
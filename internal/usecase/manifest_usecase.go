@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/pkg/manifest"
+)
+
+// ErrUnsignableRelease is returned when the offered release has no digest,
+// so a signed manifest could not tell devices which bytes to accept.
+var ErrUnsignableRelease = errors.New("release has no SHA-256 digest to sign")
+
+// ManifestUseCase signs update checks so devices can verify them end to end.
+type ManifestUseCase struct {
+	signer *manifest.Signer
+	ttl    time.Duration
+}
+
+// NewManifestUseCase creates the manifest use case. Signed manifests expire
+// after ttl.
+func NewManifestUseCase(signer *manifest.Signer, ttl time.Duration) *ManifestUseCase {
+	return &ManifestUseCase{
+		signer: signer,
+		ttl:    ttl,
+	}
+}
+
+// Sign attaches the signed manifest of an update check to it.
+func (uc *ManifestUseCase) Sign(req entity.UpdateCheckRequest, check *entity.UpdateCheck) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	m := entity.UpdateManifest{
+		AppID:                req.AppID,
+		PayloadType:          req.PayloadType,
+		DeviceID:             req.DeviceID,
+		InstalledVersionCode: req.VersionCode,
+		UpdateAvailable:      check.UpdateAvailable,
+		Mirrors:              check.Mirrors,
+		IssuedAt:             now,
+		ExpiresAt:            now.Add(uc.ttl),
+	}
+	if ota := check.OTA; ota != nil {
+		if ota.SHA256 == "" {
+			return fmt.Errorf("%w: OTA %s", ErrUnsignableRelease, ota.ID)
+		}
+		m.OTAID = ota.ID
+		m.VersionName = ota.VersionName
+		m.VersionCode = ota.VersionCode
+		m.URL = ota.URL
+		m.SHA256 = ota.SHA256
+		m.SizeBytes = ota.SizeBytes
+		m.SigningCertSHA256 = ota.SigningCertSHA256
+	}
+	if patch := check.Patch; patch != nil {
+		m.Patch = &entity.ManifestPatch{
+			FromSHA256: patch.FromSHA256,
+			ToSHA256:   patch.ToSHA256,
+			URL:        patch.URL,
+			SHA256:     patch.SHA256,
+			SizeBytes:  patch.SizeBytes,
+		}
+	}
+	for _, artifact := range check.Artifacts {
+		m.Artifacts = append(m.Artifacts, entity.ManifestArtifact{
+			SplitName: artifact.SplitName,
+			ABI:       artifact.ABI,
+			Density:   artifact.Density,
+			URL:       artifact.URL,
+			SHA256:    artifact.SHA256,
+			SizeBytes: artifact.SizeBytes,
+		})
+	}
+
+	payload, err := manifest.Canonicalize(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode update manifest: %w", err)
+	}
+
+	check.Manifest = base64.StdEncoding.EncodeToString(payload)
+	check.Signatures = nil
+	for _, signature := range uc.signer.Sign(payload) {
+		check.Signatures = append(check.Signatures, entity.ManifestSignature{
+			KeyID:     signature.KeyID,
+			Algorithm: manifest.Algorithm,
+			Signature: base64.StdEncoding.EncodeToString(signature.Signature),
+		})
+	}
+
+	return nil
+}
+
+// Keys returns the public keys manifests are signed with.
+func (uc *ManifestUseCase) Keys() []entity.ManifestKey {
+	var keys []entity.ManifestKey
+	for _, key := range uc.signer.Keys() {
+		keys = append(keys, entity.ManifestKey{
+			KeyID:     key.ID,
+			Algorithm: manifest.Algorithm,
+			PublicKey: base64.StdEncoding.EncodeToString(key.Public()),
+		})
+	}
+	return keys
+}
@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/urlcheck"
+)
+
+// MirrorUseCase manages the mirrors of releases and tracks their health.
+type MirrorUseCase struct {
+	otaRepo          repository.OTARepository
+	mirrorRepo       repository.OTAMirrorRepository
+	urls             *urlcheck.Checker
+	failureThreshold int
+	audit            *AuditUseCase
+}
+
+// NewMirrorUseCase creates the mirror use case. Mirrors are checked with urls
+// when added and by the prober; a nil checker accepts any URL and leaves every
+// mirror healthy. A mirror is dropped from update checks after
+// failureThreshold consecutive failed probes.
+func NewMirrorUseCase(otaRepo repository.OTARepository, mirrorRepo repository.OTAMirrorRepository, urls *urlcheck.Checker, failureThreshold int, audit *AuditUseCase) *MirrorUseCase {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &MirrorUseCase{
+		otaRepo:          otaRepo,
+		mirrorRepo:       mirrorRepo,
+		urls:             urls,
+		failureThreshold: failureThreshold,
+		audit:            audit,
+	}
+}
+
+func (uc *MirrorUseCase) ListMirrors(ctx context.Context, otaID string) ([]entity.OTAMirror, error) {
+	if otaID == "" {
+		return nil, fmt.Errorf("ID is required")
+	}
+	if err := authorizeRelease(ctx, uc.otaRepo, ActionView, otaID); err != nil {
+		return nil, err
+	}
+	return uc.mirrorRepo.ListByOTA(ctx, otaID)
+}
+
+// AddMirror adds a mirror to a release after checking that it serves the
+// release's artifact.
+func (uc *MirrorUseCase) AddMirror(ctx context.Context, otaID string, url string, priority int) (entity.OTAMirror, error) {
+	if otaID == "" {
+		return entity.OTAMirror{}, fmt.Errorf("ID is required")
+	}
+	if url == "" {
+		return entity.OTAMirror{}, fmt.Errorf("URL is required")
+	}
+
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return entity.OTAMirror{}, err
+	}
+	if err := authorize(ctx, ActionUpdate, ota.AppID); err != nil {
+		return entity.OTAMirror{}, err
+	}
+	if ota.Status == entity.OTAStatusArchived {
+		return entity.OTAMirror{}, fmt.Errorf("ota is archived")
+	}
+
+	mirror := entity.OTAMirror{
+		OTAID:    ota.ID,
+		URL:      url,
+		Priority: priority,
+		Healthy:  true,
+	}
+	if err := uc.check(ctx, ota, mirror); err != nil {
+		return entity.OTAMirror{}, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	var created entity.OTAMirror
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		created, err = uc.mirrorRepo.Create(ctx, mirror)
+		return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceMirror, ResourceID: created.ID, AppID: ota.AppID, After: created}, err
+	})
+	return created, err
+}
+
+func (uc *MirrorUseCase) SetPriority(ctx context.Context, otaID string, mirrorID string, priority int) (entity.OTAMirror, error) {
+	ota, existing, err := uc.getMirror(ctx, otaID, mirrorID)
+	if err != nil {
+		return entity.OTAMirror{}, err
+	}
+
+	var updated entity.OTAMirror
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		updated, err = uc.mirrorRepo.SetPriority(ctx, mirrorID, priority)
+		return AuditChange{Action: entity.AuditActionUpdate, ResourceType: entity.AuditResourceMirror, ResourceID: mirrorID, AppID: ota.AppID, Before: existing, After: updated}, err
+	})
+	return updated, err
+}
+
+func (uc *MirrorUseCase) DeleteMirror(ctx context.Context, otaID string, mirrorID string) error {
+	ota, existing, err := uc.getMirror(ctx, otaID, mirrorID)
+	if err != nil {
+		return err
+	}
+
+	return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		change := AuditChange{Action: entity.AuditActionDelete, ResourceType: entity.AuditResourceMirror, ResourceID: mirrorID, AppID: ota.AppID, Before: existing}
+		return change, uc.mirrorRepo.Delete(ctx, mirrorID)
+	})
+}
+
+// getMirror returns a mirror of a release together with the release, once
+// the caller is authorized to change it.
+func (uc *MirrorUseCase) getMirror(ctx context.Context, otaID string, mirrorID string) (entity.OTA, entity.OTAMirror, error) {
+	if otaID == "" || mirrorID == "" {
+		return entity.OTA{}, entity.OTAMirror{}, fmt.Errorf("ID is required")
+	}
+
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return entity.OTA{}, entity.OTAMirror{}, err
+	}
+	if err := authorize(ctx, ActionUpdate, ota.AppID); err != nil {
+		return entity.OTA{}, entity.OTAMirror{}, err
+	}
+
+	mirror, err := uc.mirrorRepo.Get(ctx, mirrorID)
+	if err != nil {
+		return entity.OTA{}, entity.OTAMirror{}, err
+	}
+	if mirror.OTAID != otaID {
+		return entity.OTA{}, entity.OTAMirror{}, fmt.Errorf("ota mirror not found")
+	}
+
+	return ota, mirror, nil
+}
+
+// HealthyURLs returns the URLs of the release's healthy mirrors in priority order.
+func (uc *MirrorUseCase) HealthyURLs(ctx context.Context, otaID string) ([]string, error) {
+	mirrors, err := uc.mirrorRepo.ListByOTA(ctx, otaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, m := range mirrors {
+		if m.Healthy {
+			urls = append(urls, m.URL)
+		}
+	}
+	return urls, nil
+}
+
+// Run probes the mirrors of every published release at the given interval
+// until ctx is cancelled.
+func (uc *MirrorUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := uc.Probe(ctx); err != nil {
+				log.Printf("Failed to probe mirrors: %v", err)
+			}
+		}
+	}
+}
+
+// Probe checks every mirror of the published releases once. A mirror that
+// fails failureThreshold times in a row is marked unhealthy until a probe
+// succeeds again.
+func (uc *MirrorUseCase) Probe(ctx context.Context) error {
+	if uc.urls == nil {
+		return nil
+	}
+
+	mirrors, err := uc.mirrorRepo.ListPublished(ctx)
+	if err != nil {
+		return err
+	}
+
+	otas := make(map[string]entity.OTA)
+	for _, mirror := range mirrors {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		ota, ok := otas[mirror.OTAID]
+		if !ok {
+			if ota, _, err = uc.otaRepo.Get(ctx, mirror.OTAID, ""); err != nil {
+				log.Printf("Failed to get OTA %s of mirror %s: %v", mirror.OTAID, mirror.ID, err)
+				continue
+			}
+			otas[mirror.OTAID] = ota
+		}
+
+		now := time.Now()
+		mirror.LastCheckedAt = &now
+		if err := uc.check(ctx, ota, mirror); err != nil {
+			mirror.ConsecutiveFailures++
+			mirror.LastError = err.Error()
+			if mirror.Healthy && mirror.ConsecutiveFailures >= uc.failureThreshold {
+				mirror.Healthy = false
+				log.Printf("Mirror %s of OTA %s is unhealthy: %v", mirror.URL, ota.ID, err)
+			}
+		} else {
+			if !mirror.Healthy {
+				log.Printf("Mirror %s of OTA %s is healthy again", mirror.URL, ota.ID)
+			}
+			mirror.Healthy = true
+			mirror.ConsecutiveFailures = 0
+			mirror.LastError = ""
+		}
+
+		if err := uc.mirrorRepo.UpdateHealth(ctx, mirror); err != nil {
+			log.Printf("Failed to record health of mirror %s: %v", mirror.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// check verifies that the mirror serves content of the release's type and,
+// when the release's size is known, of the same size.
+func (uc *MirrorUseCase) check(ctx context.Context, ota entity.OTA, mirror entity.OTAMirror) error {
+	if uc.urls == nil {
+		return nil
+	}
+
+	result, err := uc.urls.Check(ctx, mirror.URL, urlcheck.Options{ContentTypes: payloadContentTypes[ota.PayloadType]})
+	if err != nil {
+		return err
+	}
+	if ota.SizeBytes > 0 && result.ContentLength != ota.SizeBytes {
+		return fmt.Errorf("%w: mirror serves %d bytes but the release is %d bytes", urlcheck.ErrContentLength, result.ContentLength, ota.SizeBytes)
+	}
+
+	return nil
+}
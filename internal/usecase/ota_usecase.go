@@ -2,19 +2,86 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	"github.com/google/uuid"
 
 	"launcherbackend_api/internal/domain/entity"
 	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/apk"
+	"launcherbackend_api/pkg/payload"
+	"launcherbackend_api/pkg/urlcheck"
+)
+
+var (
+	// ErrInvalidArtifact is returned when an uploaded artifact cannot be read.
+	ErrInvalidArtifact = errors.New("invalid artifact")
+	// ErrManifestMismatch is returned when the APK contradicts the metadata supplied by the uploader.
+	ErrManifestMismatch = errors.New("apk manifest does not match release metadata")
+	// ErrUnverifiableArtifact is returned when a release of an app with a pinned
+	// signing certificate would point at an artifact the server has not verified.
+	ErrUnverifiableArtifact = errors.New("releases of this app must be uploaded so their signing certificate can be verified")
+	// ErrUnsupportedPayloadType is returned for a payload type the server does not know.
+	ErrUnsupportedPayloadType = errors.New("unsupported payload type")
+	// ErrInvalidURL is returned when an external release URL is broken or serves the wrong content.
+	ErrInvalidURL = errors.New("invalid release URL")
+	// ErrIncomparableReleases is returned when comparing releases of different apps or payload types.
+	ErrIncomparableReleases = errors.New("releases belong to different apps or payload types")
+	// ErrNotPendingReview is returned when acknowledging permissions of a release that is not held for review.
+	ErrNotPendingReview = errors.New("ota is not awaiting permission review")
+	// ErrUnreviewedPermissions is returned when an artifact requests dangerous
+	// permissions its release was not reviewed for.
+	ErrUnreviewedPermissions = errors.New("artifact requests dangerous permissions its release was not reviewed for")
+	// ErrStoredArtifact is returned when pointing a release whose artifact was
+	// uploaded to the server at an external URL.
+	ErrStoredArtifact = errors.New("the artifact of an uploaded release cannot be replaced by a URL")
 )
 
+// payloadContentTypes lists the media types an external URL may serve for each payload type.
+var payloadContentTypes = map[string][]string{
+	entity.PayloadTypeAPK:           {"application/vnd.android.package-archive", "application/zip", "application/octet-stream"},
+	entity.PayloadTypeTheme:         {"application/zip", "application/x-zip-compressed", "application/octet-stream"},
+	entity.PayloadTypeWallpaperPack: {"application/zip", "application/x-zip-compressed", "application/octet-stream"},
+	entity.PayloadTypeConfigBundle:  {"application/json", "application/octet-stream"},
+	entity.PayloadTypeFirmware:      {"application/zip", "application/x-zip-compressed", "application/octet-stream"},
+}
+
 type OTAUseCase struct {
-	otaRepo repository.OTARepository
+	otaRepo      repository.OTARepository
+	patchRepo    repository.OTAPatchRepository
+	artifactRepo repository.OTAArtifactRepository
+	downloads    DownloadURLs
+	blobs        *BlobStore
+	certs        *SigningCertUseCase
+	delta        *DeltaUseCase
+	urls         *urlcheck.Checker
+	urlDigest    bool
+	tuf          *TUFUseCase
+	audit        *AuditUseCase
 }
 
-func NewOTAUseCase(otaRepo repository.OTARepository) *OTAUseCase {
+// NewOTAUseCase creates the OTA use case. External release URLs are checked
+// with urls, and downloaded to compute their digest when urlDigest is set; a
+// nil checker accepts any URL.
+func NewOTAUseCase(otaRepo repository.OTARepository, patchRepo repository.OTAPatchRepository, artifactRepo repository.OTAArtifactRepository, downloads DownloadURLs, blobs *BlobStore, certs *SigningCertUseCase, delta *DeltaUseCase, urls *urlcheck.Checker, urlDigest bool, tuf *TUFUseCase, audit *AuditUseCase) *OTAUseCase {
 	return &OTAUseCase{
-		otaRepo: otaRepo,
+		otaRepo:      otaRepo,
+		patchRepo:    patchRepo,
+		artifactRepo: artifactRepo,
+		downloads:    downloads,
+		blobs:        blobs,
+		certs:        certs,
+		delta:        delta,
+		urls:         urls,
+		urlDigest:    urlDigest,
+		tuf:          tuf,
+		audit:        audit,
 	}
 }
 
@@ -22,6 +89,12 @@ func (uc *OTAUseCase) CreateOTA(ctx context.Context, ota entity.OTA) (entity.OTA
 	if ota.AppID == "" {
 		return entity.OTA{}, fmt.Errorf("app ID is required")
 	}
+	if ota.PayloadType == "" {
+		ota.PayloadType = entity.PayloadTypeAPK
+	}
+	if !entity.IsValidPayloadType(ota.PayloadType) {
+		return entity.OTA{}, fmt.Errorf("%w: %q", ErrUnsupportedPayloadType, ota.PayloadType)
+	}
 	if ota.VersionName == "" {
 		return entity.OTA{}, fmt.Errorf("version name is required")
 	}
@@ -31,30 +104,442 @@ func (uc *OTAUseCase) CreateOTA(ctx context.Context, ota entity.OTA) (entity.OTA
 	if ota.URL == "" {
 		return entity.OTA{}, fmt.Errorf("URL is required")
 	}
+	if err := authorize(ctx, ActionCreate, ota.AppID); err != nil {
+		return entity.OTA{}, err
+	}
+
+	if ota.PayloadType == entity.PayloadTypeAPK {
+		pinned, err := uc.certs.IsPinned(ctx, ota.AppID)
+		if err != nil {
+			return entity.OTA{}, err
+		}
+		if pinned {
+			return entity.OTA{}, ErrUnverifiableArtifact
+		}
+	}
+
+	if err := uc.checkURL(ctx, &ota); err != nil {
+		return entity.OTA{}, err
+	}
+
+	return uc.create(ctx, ota, nil)
+}
+
+// create stores a release and publishes it to the TUF metadata of its app.
+// The signing certificate the release was verified against, if any, is
+// pinned or activated in the same transaction, so a failed release leaves
+// the app's certificates as they were.
+func (uc *OTAUseCase) create(ctx context.Context, ota entity.OTA, cert *entity.SigningCertificate) (entity.OTA, error) {
+	var created entity.OTA
+	err := uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			if cert != nil {
+				if err := uc.certs.ConfirmRelease(ctx, *cert); err != nil {
+					return AuditChange{}, err
+				}
+			}
+			var err error
+			created, err = uc.otaRepo.Create(ctx, ota)
+			if err == nil {
+				err = uc.delta.Enqueue(ctx, created)
+			}
+			return otaChange(entity.AuditActionCreate, nil, &created), err
+		})
+	}, ota.AppID)
+	return created, err
+}
+
+// otaChange describes a change to a release for the audit log.
+func otaChange(action string, before *entity.OTA, after *entity.OTA) AuditChange {
+	change := AuditChange{Action: action, ResourceType: entity.AuditResourceOTA}
+	if before != nil {
+		change.ResourceID, change.AppID, change.Before = before.ID, before.AppID, before
+	}
+	if after != nil {
+		change.ResourceID, change.AppID, change.After = after.ID, after.AppID, after
+	}
+	return change
+}
+
+// checkURL verifies that the release's external URL serves its payload and
+// records the size and, when known, the digest of what it serves. A digest
+// supplied by the caller is verified against the content.
+func (uc *OTAUseCase) checkURL(ctx context.Context, ota *entity.OTA) error {
+	if uc.urls == nil {
+		return nil
+	}
+
+	result, err := uc.urls.Check(ctx, ota.URL, urlcheck.Options{
+		ContentTypes:  payloadContentTypes[ota.PayloadType],
+		SHA256:        ota.SHA256,
+		ComputeDigest: uc.urlDigest,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	ota.SizeBytes = result.ContentLength
+	if result.SHA256 != "" {
+		ota.SHA256 = result.SHA256
+	}
+	return nil
+}
+
+// UploadOTA creates a release from an uploaded payload, validated according
+// to the release's payload type. APKs are the default.
+func (uc *OTAUseCase) UploadOTA(ctx context.Context, ota entity.OTA, file io.ReaderAt, size int64) (entity.OTA, error) {
+	if ota.PayloadType == "" {
+		ota.PayloadType = entity.PayloadTypeAPK
+	}
+
+	var cert *entity.SigningCertificate
+	var err error
+	switch ota.PayloadType {
+	case entity.PayloadTypeAPK:
+		ota, cert, err = uc.prepareAPK(ctx, ota, file, size)
+	default:
+		ota, err = uc.preparePayload(ctx, ota, file, size)
+	}
+	if err != nil {
+		return entity.OTA{}, err
+	}
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, io.NewSectionReader(file, 0, size)); err != nil {
+		return entity.OTA{}, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+	ota.SHA256 = hex.EncodeToString(digest.Sum(nil))
+	ota.SizeBytes = size
+
+	key, err := uc.blobs.Put(ctx, ota.SHA256, size, io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return entity.OTA{}, err
+	}
+	ota.ID = uuid.NewString()
+	ota.URL = uc.downloads.OTA(ota.ID)
+	ota.StorageKey = key
+
+	created, err := uc.create(ctx, ota, cert)
+	if err != nil {
+		if relErr := uc.blobs.Release(ctx, ota.SHA256); relErr != nil {
+			log.Printf("Failed to release artifact %s: %v", ota.SHA256, relErr)
+		}
+		return entity.OTA{}, err
+	}
+
+	uc.delta.Wake()
+
+	return created, nil
+}
+
+// prepareAPK fills release metadata from an uploaded APK. Package name,
+// version code and version name are taken from the APK manifest; values
+// supplied by the uploader must agree with it. The caller is authorized as
+// soon as the app ID is known, before the app's signing certificates are
+// touched. The returned certificate is confirmed when the release is created.
+func (uc *OTAUseCase) prepareAPK(ctx context.Context, ota entity.OTA, file io.ReaderAt, size int64) (entity.OTA, *entity.SigningCertificate, error) {
+	manifest, err := apk.ParseManifest(file, size)
+	if err != nil {
+		return entity.OTA{}, nil, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+
+	if ota.AppID == "" {
+		ota.AppID = manifest.PackageName
+	} else if ota.AppID != manifest.PackageName {
+		return entity.OTA{}, nil, fmt.Errorf("%w: app ID %q but apk package is %q", ErrManifestMismatch, ota.AppID, manifest.PackageName)
+	}
+	if err := authorize(ctx, ActionCreate, ota.AppID); err != nil {
+		return entity.OTA{}, nil, err
+	}
+
+	if ota.VersionCode == 0 {
+		ota.VersionCode = manifest.VersionCode
+	} else if ota.VersionCode != manifest.VersionCode {
+		return entity.OTA{}, nil, fmt.Errorf("%w: version code %d but apk version code is %d", ErrManifestMismatch, ota.VersionCode, manifest.VersionCode)
+	}
+
+	if ota.VersionName == "" {
+		ota.VersionName = manifest.VersionName
+	}
+	if ota.VersionName == "" {
+		return entity.OTA{}, nil, fmt.Errorf("version name is required")
+	}
+
+	signing, err := apk.VerifySigning(file, size)
+	if err != nil {
+		return entity.OTA{}, nil, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+
+	cert, err := uc.certs.VerifyRelease(ctx, ota.AppID, signing)
+	if err != nil {
+		return entity.OTA{}, nil, err
+	}
+	ota.SigningCertSHA256 = signing.CertSHA256
+
+	ota.Manifest = &entity.APKManifest{
+		PackageName: manifest.PackageName,
+		VersionCode: manifest.VersionCode,
+		VersionName: manifest.VersionName,
+		MinSdk:      manifest.MinSdk,
+		TargetSdk:   manifest.TargetSdk,
+		Permissions: manifest.Permissions,
+	}
+
+	if err := uc.reviewPermissions(ctx, &ota); err != nil {
+		return entity.OTA{}, nil, err
+	}
+
+	return ota, &cert, nil
+}
+
+// reviewPermissions holds a release for review when it requests dangerous
+// permissions that the previous published release of the app did not.
+func (uc *OTAUseCase) reviewPermissions(ctx context.Context, ota *entity.OTA) error {
+	added, err := addedDangerousPermissions(ctx, uc.otaRepo, *ota, nil, ota.Manifest.Permissions)
+	if err != nil {
+		return err
+	}
+
+	ota.DangerousPermissionsAdded = added
+	if len(ota.DangerousPermissionsAdded) > 0 {
+		ota.Status = entity.OTAStatusPendingReview
+		log.Printf("Holding %s %d for review: adds dangerous permissions %v", ota.AppID, ota.VersionCode, ota.DangerousPermissionsAdded)
+	}
+
+	return nil
+}
+
+// addedDangerousPermissions returns the dangerous permissions in requested
+// that neither the previous published release of the app nor reviewed hold.
+// The first release of an app is not reviewed. A previous release without a
+// manifest cannot be compared, so every dangerous permission counts as added.
+func addedDangerousPermissions(ctx context.Context, otaRepo repository.OTARepository, ota entity.OTA, reviewed []string, requested []string) ([]string, error) {
+	previous, err := otaRepo.ListPrevious(ctx, ota.AppID, ota.PayloadType, ota.VersionCode, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(previous) == 0 {
+		return nil, nil
+	}
+
+	had := reviewed
+	if previous[0].Manifest != nil {
+		had = append(slices.Clone(had), previous[0].Manifest.Permissions...)
+	}
+
+	return apk.AddedDangerousPermissions(had, requested), nil
+}
+
+// AcknowledgePermissions publishes a release held for permission review,
+// recording the caller as who acknowledged the dangerous permissions it adds.
+func (uc *OTAUseCase) AcknowledgePermissions(ctx context.Context, id string) (entity.OTA, error) {
+	if id == "" {
+		return entity.OTA{}, fmt.Errorf("ID is required")
+	}
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return entity.OTA{}, fmt.Errorf("%w: acknowledging permissions requires an API key or token", ErrUnauthenticated)
+	}
+	acknowledgedBy := principal.Method + ":" + principal.ID
+
+	existing, _, err := uc.otaRepo.Get(ctx, id, "")
+	if err != nil {
+		return entity.OTA{}, err
+	}
+	if err := authorize(ctx, ActionReview, existing.AppID); err != nil {
+		return entity.OTA{}, err
+	}
+
+	var ota entity.OTA
+	err = uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			var ok bool
+			var err error
+			ota, ok, err = uc.otaRepo.AcknowledgePermissions(ctx, id, acknowledgedBy)
+			if err == nil && !ok {
+				err = fmt.Errorf("%w: status is %s", ErrNotPendingReview, ota.Status)
+			}
+			return otaChange(entity.AuditActionPublish, &existing, &ota), err
+		})
+	}, existing.AppID)
+	if err != nil {
+		return entity.OTA{}, err
+	}
 
-	return uc.otaRepo.Create(ctx, ota)
+	return ota, nil
+}
+
+// preparePayload validates a non-APK payload. Such payloads carry no package
+// metadata, so app ID, version code and version name must be supplied.
+func (uc *OTAUseCase) preparePayload(ctx context.Context, ota entity.OTA, file io.ReaderAt, size int64) (entity.OTA, error) {
+	if ota.AppID == "" {
+		return entity.OTA{}, fmt.Errorf("app ID is required")
+	}
+	if err := authorize(ctx, ActionCreate, ota.AppID); err != nil {
+		return entity.OTA{}, err
+	}
+	if ota.VersionName == "" {
+		return entity.OTA{}, fmt.Errorf("version name is required")
+	}
+	if ota.VersionCode <= 0 {
+		return entity.OTA{}, fmt.Errorf("valid version code is required")
+	}
+
+	var validate func(io.ReaderAt, int64) (map[string]string, error)
+	switch ota.PayloadType {
+	case entity.PayloadTypeTheme:
+		validate = payload.ValidateThemePack
+	case entity.PayloadTypeWallpaperPack:
+		validate = payload.ValidateWallpaperPack
+	case entity.PayloadTypeConfigBundle:
+		validate = payload.ValidateConfigBundle
+	case entity.PayloadTypeFirmware:
+		validate = payload.ValidateFirmware
+	default:
+		return entity.OTA{}, fmt.Errorf("%w: %q", ErrUnsupportedPayloadType, ota.PayloadType)
+	}
+
+	metadata, err := validate(file, size)
+	if err != nil {
+		return entity.OTA{}, fmt.Errorf("%w: %v", ErrInvalidArtifact, err)
+	}
+	ota.Metadata = metadata
+
+	return ota, nil
 }
 
 func (uc *OTAUseCase) GetOTA(ctx context.Context, id string, appID string) (entity.OTA, string, error) {
 	// Check if both id and appID are provided
 	if id != "" && appID != "" {
-		return nil, "", fmt.Errorf("cannot provide both id and appID, choose one")
+		return entity.OTA{}, "", fmt.Errorf("cannot provide both id and appID, choose one")
 	}
 	
 	// Check if at least one of id or appID is provided
 	if id == "" && appID == "" {
-		return nil, "", fmt.Errorf("must provide either id or appID")
+		return entity.OTA{}, "", fmt.Errorf("must provide either id or appID")
+	}
+
+	ota, message, err := uc.otaRepo.Get(ctx, id, appID)
+	if err != nil {
+		return entity.OTA{}, "", err
+	}
+	if err := authorize(ctx, ActionView, ota.AppID); err != nil {
+		return entity.OTA{}, "", err
+	}
+
+	return ota, message, nil
+}
+
+func (uc *OTAUseCase) ListPatches(ctx context.Context, id string) ([]entity.OTAPatch, error) {
+	if err := authorizeRelease(ctx, uc.otaRepo, ActionView, id); err != nil {
+		return nil, err
+	}
+	return uc.delta.ListPatches(ctx, id)
+}
+
+// CompareOTAs describes the differences between two releases of an app,
+// flagging the changes that put devices or users at risk.
+func (uc *OTAUseCase) CompareOTAs(ctx context.Context, fromID string, toID string) (entity.OTAComparison, error) {
+	if fromID == "" || toID == "" {
+		return entity.OTAComparison{}, fmt.Errorf("ID is required")
+	}
+
+	from, _, err := uc.otaRepo.Get(ctx, fromID, "")
+	if err != nil {
+		return entity.OTAComparison{}, err
+	}
+	to, _, err := uc.otaRepo.Get(ctx, toID, "")
+	if err != nil {
+		return entity.OTAComparison{}, err
+	}
+	if from.AppID != to.AppID || from.PayloadType != to.PayloadType {
+		return entity.OTAComparison{}, ErrIncomparableReleases
+	}
+	if err := authorize(ctx, ActionView, from.AppID); err != nil {
+		return entity.OTAComparison{}, err
+	}
+
+	cmp := entity.OTAComparison{
+		From:               from,
+		To:                 to,
+		PermissionsAdded:   []string{},
+		PermissionsRemoved: []string{},
+		Risks:              []string{},
+	}
+
+	if from.SizeBytes > 0 && to.SizeBytes > 0 {
+		delta := to.SizeBytes - from.SizeBytes
+		cmp.SizeDeltaBytes = &delta
+	}
+
+	if to.VersionCode <= from.VersionCode {
+		cmp.Risks = append(cmp.Risks, fmt.Sprintf("version code goes from %d to %d; devices will not offer it as an update", from.VersionCode, to.VersionCode))
+	}
+
+	if from.SigningCertSHA256 != "" && to.SigningCertSHA256 != "" && from.SigningCertSHA256 != to.SigningCertSHA256 {
+		cmp.SigningCertChanged = true
+		cmp.Risks = append(cmp.Risks, "signing certificate changed; devices cannot install the release over the installed one")
+	}
+
+	if from.Manifest != nil && to.Manifest != nil {
+		cmp.ManifestsCompared = true
+		cmp.MinSdk = &entity.VersionChange{From: from.Manifest.MinSdk, To: to.Manifest.MinSdk}
+		cmp.TargetSdk = &entity.VersionChange{From: from.Manifest.TargetSdk, To: to.Manifest.TargetSdk}
+
+		for _, p := range to.Manifest.Permissions {
+			if !slices.Contains(from.Manifest.Permissions, p) {
+				cmp.PermissionsAdded = append(cmp.PermissionsAdded, p)
+			}
+		}
+		for _, p := range from.Manifest.Permissions {
+			if !slices.Contains(to.Manifest.Permissions, p) {
+				cmp.PermissionsRemoved = append(cmp.PermissionsRemoved, p)
+			}
+		}
+
+		if to.Manifest.MinSdk > from.Manifest.MinSdk {
+			cmp.Risks = append(cmp.Risks, fmt.Sprintf("minSdk raised from %d to %d; devices below API %d stop receiving updates", from.Manifest.MinSdk, to.Manifest.MinSdk, to.Manifest.MinSdk))
+		}
+		if to.Manifest.TargetSdk != from.Manifest.TargetSdk {
+			cmp.Risks = append(cmp.Risks, fmt.Sprintf("targetSdk changed from %d to %d; runtime behaviour may change", from.Manifest.TargetSdk, to.Manifest.TargetSdk))
+		}
+		if len(cmp.PermissionsAdded) > 0 {
+			cmp.Risks = append(cmp.Risks, fmt.Sprintf("%d permission(s) added", len(cmp.PermissionsAdded)))
+		}
+	}
+
+	if to.VersionCode > from.VersionCode {
+		history, err := uc.otaRepo.ListHistory(ctx, to.AppID, to.PayloadType, from.VersionCode, to.VersionCode, maxChangelogEntries)
+		if err != nil {
+			return entity.OTAComparison{}, err
+		}
+		for _, ota := range history {
+			cmp.Changelog = append(cmp.Changelog, entity.ChangelogEntry{
+				OTAID:        ota.ID,
+				VersionName:  ota.VersionName,
+				VersionCode:  ota.VersionCode,
+				ReleaseNotes: ota.ReleaseNotes,
+				CreatedAt:    ota.CreatedAt,
+			})
+		}
 	}
 
-	return uc.otaRepo.Get(ctx, id, appID)
+	return cmp, nil
 }
 
 func (uc *OTAUseCase) GetAllOTAs(ctx context.Context, cursor string, limit int) ([]entity.OTA, string, int64, error) {
 	if limit <= 0 {
 		limit = 10
 	}
+
+	// Callers limited to some apps only see the releases of those apps; an empty
+	// list, unlike a nil one, matches no release at all
+	appIDs, all := viewableApps(ctx)
+	if !all && appIDs == nil {
+		appIDs = []string{}
+	}
 	
-	return uc.otaRepo.GetAll(ctx, cursor, limit)
+	return uc.otaRepo.GetAll(ctx, appIDs, cursor, limit)
 }
 
 func (uc *OTAUseCase) UpdateOTA(ctx context.Context, ota entity.OTA) (entity.OTA, error) {
@@ -73,13 +558,139 @@ func (uc *OTAUseCase) UpdateOTA(ctx context.Context, ota entity.OTA) (entity.OTA
 	if ota.URL == "" {
 		return entity.OTA{}, fmt.Errorf("URL is required")
 	}
-	
-	return uc.otaRepo.Update(ctx, ota)
+
+	existing, _, err := uc.otaRepo.Get(ctx, ota.ID, "")
+	if err != nil {
+		return entity.OTA{}, err
+	}
+	if err := authorize(ctx, ActionUpdate, existing.AppID); err != nil {
+		return entity.OTA{}, err
+	}
+	if ota.AppID != existing.AppID {
+		if err := authorize(ctx, ActionCreate, ota.AppID); err != nil {
+			return entity.OTA{}, err
+		}
+	}
+
+	// Metadata of uploaded releases comes from the APK itself
+	if existing.Manifest != nil && (ota.AppID != existing.AppID || ota.VersionCode != existing.VersionCode) {
+		return entity.OTA{}, fmt.Errorf("%w: app ID and version code of an uploaded release cannot change", ErrManifestMismatch)
+	}
+
+	// The stored artifact stays referenced by the release; upload a new
+	// release instead
+	if existing.StorageKey != "" && ota.URL != existing.URL {
+		return entity.OTA{}, ErrStoredArtifact
+	}
+
+	// An APK moved to another app or URL was never checked against the
+	// certificate pinned for it
+	if (ota.URL != existing.URL || ota.AppID != existing.AppID) && existing.PayloadType == entity.PayloadTypeAPK {
+		pinned, err := uc.certs.IsPinned(ctx, ota.AppID)
+		if err != nil {
+			return entity.OTA{}, err
+		}
+		if pinned || existing.SigningCertSHA256 != "" {
+			return entity.OTA{}, ErrUnverifiableArtifact
+		}
+	}
+
+	if ota.URL == existing.URL {
+		ota.SHA256 = existing.SHA256
+		ota.SizeBytes = existing.SizeBytes
+	} else {
+		ota.PayloadType = existing.PayloadType
+		ota.SizeBytes = 0
+		if err := uc.checkURL(ctx, &ota); err != nil {
+			return entity.OTA{}, err
+		}
+	}
+
+	var updated entity.OTA
+	err = uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			var err error
+			updated, err = uc.otaRepo.Update(ctx, ota)
+			return otaChange(entity.AuditActionUpdate, &existing, &updated), err
+		})
+	}, existing.AppID, ota.AppID)
+	return updated, err
 }
 
+// SetPinned pins a release so the retention policy never deletes its artifacts.
+func (uc *OTAUseCase) SetPinned(ctx context.Context, id string, pinned bool) (entity.OTA, error) {
+	if id == "" {
+		return entity.OTA{}, fmt.Errorf("ID is required")
+	}
+
+	existing, _, err := uc.otaRepo.Get(ctx, id, "")
+	if err != nil {
+		return entity.OTA{}, err
+	}
+	if err := authorize(ctx, ActionRollout, existing.AppID); err != nil {
+		return entity.OTA{}, err
+	}
+
+	var updated entity.OTA
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		updated, err = uc.otaRepo.SetPinned(ctx, id, pinned)
+		return otaChange(entity.AuditActionRollout, &existing, &updated), err
+	})
+	return updated, err
+}
+
+// DeleteOTA deletes a release together with its artifacts and the patches
+// towards or from it.
 func (uc *OTAUseCase) DeleteOTA(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("ID is required")
 	}
-	return uc.otaRepo.Delete(ctx, id)
+
+	ota, _, err := uc.otaRepo.Get(ctx, id, "")
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, ActionDelete, ota.AppID); err != nil {
+		return err
+	}
+
+	patches, err := uc.patchRepo.ListInvolving(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := uc.artifactRepo.ListByOTA(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Patch and artifact rows are removed with the release
+	if err := uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			return otaChange(entity.AuditActionDelete, &ota, nil), uc.otaRepo.Delete(ctx, id)
+		})
+	}, ota.AppID); err != nil {
+		return err
+	}
+
+	digests := make([]string, 0, len(patches)+len(artifacts)+1)
+	if ota.StorageKey != "" {
+		digests = append(digests, ota.SHA256)
+	}
+	for _, p := range patches {
+		if p.StorageKey != "" {
+			digests = append(digests, p.SHA256)
+		}
+	}
+	for _, a := range artifacts {
+		digests = append(digests, a.SHA256)
+	}
+	for _, digest := range digests {
+		if err := uc.blobs.Release(ctx, digest); err != nil {
+			log.Printf("Failed to release artifact %s of deleted OTA %s: %v", digest, id, err)
+		}
+	}
+
+	return nil
 }
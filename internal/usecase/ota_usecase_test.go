@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+func TestDeleteOTAReleasesBlobs(t *testing.T) {
+	store := newFakeStore()
+	seedReleases(store)
+	blobs := NewBlobStore(store, &fakeBlobRepo{store: store}, &fakeStorage{store: store})
+	audit := NewAuditUseCase(store, &fakeAuditRepo{store: store})
+	uc := NewOTAUseCase(&fakeOTARepo{store: store}, &fakePatchRepo{store: store}, &fakeArtifactRepo{store: store}, DownloadURLs{}, blobs, nil, nil, nil, false, nil, audit)
+
+	if err := uc.DeleteOTA(context.Background(), "ota-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.tables.otas["ota-1"]; ok {
+		t.Fatal("release was not deleted")
+	}
+	if _, ok := store.tables.artifacts["split-1"]; ok {
+		t.Error("artifact of the deleted release was kept")
+	}
+	if _, ok := store.tables.patches["patch-13"]; ok {
+		t.Error("patch from the deleted release was kept")
+	}
+
+	for _, digest := range []string{"apk-1", "patch-13"} {
+		if _, ok := store.tables.files[blobKey(digest)]; ok {
+			t.Errorf("file of blob %s was kept", digest)
+		}
+	}
+	if blob := store.tables.blobs["split-shared"]; blob.RefCount != 1 {
+		t.Errorf("shared blob has %d references, want 1", blob.RefCount)
+	}
+	if _, ok := store.tables.files[blobKey("split-shared")]; !ok {
+		t.Error("file of the shared blob was deleted")
+	}
+
+	// Each blob is released once, after the deletion committed
+	want := map[string][]int{"apk-1": {1}, "patch-13": {1}, "split-shared": {1}}
+	if !reflect.DeepEqual(store.releases, want) {
+		t.Errorf("released %v, want %v", store.releases, want)
+	}
+
+	if len(store.tables.audit) != 1 || store.tables.audit[0].Action != entity.AuditActionDelete || store.tables.audit[0].ResourceID != "ota-1" {
+		t.Errorf("audit entries %+v, want the deletion of ota-1", store.tables.audit)
+	}
+}
+
+func TestDeleteOTAForbidden(t *testing.T) {
+	store := newFakeStore()
+	seedReleases(store)
+	before := store.tables.clone()
+	blobs := NewBlobStore(store, &fakeBlobRepo{store: store}, &fakeStorage{store: store})
+	audit := NewAuditUseCase(store, &fakeAuditRepo{store: store})
+	uc := NewOTAUseCase(&fakeOTARepo{store: store}, &fakePatchRepo{store: store}, &fakeArtifactRepo{store: store}, DownloadURLs{}, blobs, nil, nil, nil, false, nil, audit)
+
+	ctx := WithPrincipal(context.Background(), entity.Principal{Bindings: []entity.RoleBinding{{Role: entity.RolePublisher, AppID: "launcher"}}})
+	if err := uc.DeleteOTA(ctx, "ota-1"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("DeleteOTA error = %v, want ErrForbidden", err)
+	}
+
+	if !reflect.DeepEqual(store.tables, before) || len(store.releases) != 0 {
+		t.Error("a forbidden deletion changed the database")
+	}
+}
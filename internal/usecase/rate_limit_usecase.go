@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// Rate limit tiers: every request by source IP before it is authenticated,
+// then device check-ins and downloads, and everything else.
+const (
+	RateLimitTierIP     = "ip"
+	RateLimitTierDevice = "device"
+	RateLimitTierAdmin  = "admin"
+)
+
+// RateLimit allows PerMinute requests a minute on average, in bursts of up to
+// Burst requests. A zero PerMinute allows every request.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// RateLimitUseCase limits how often each caller may call the API, with a
+// token bucket per caller and tier.
+type RateLimitUseCase struct {
+	repo   repository.RateLimitRepository
+	limits map[string]RateLimit
+}
+
+func NewRateLimitUseCase(repo repository.RateLimitRepository, limits map[string]RateLimit) *RateLimitUseCase {
+	return &RateLimitUseCase{
+		repo:   repo,
+		limits: limits,
+	}
+}
+
+// Allow counts a request of caller against the limit of tier. When the
+// caller is over the limit, it returns false and how long to wait before
+// retrying.
+func (uc *RateLimitUseCase) Allow(ctx context.Context, tier string, caller string) (bool, time.Duration, error) {
+	limit, ok := uc.limits[tier]
+	if !ok || limit.PerMinute <= 0 {
+		return true, 0, nil
+	}
+	return uc.repo.Take(ctx, tier+":"+caller, float64(limit.PerMinute)/60, limit.Burst)
+}
+
+// Run deletes the buckets of callers gone quiet at the given interval until
+// ctx is cancelled. A bucket is deleted once it would have refilled, since a
+// new bucket starts full.
+func (uc *RateLimitUseCase) Run(ctx context.Context, interval time.Duration) {
+	var idle time.Duration
+	for _, limit := range uc.limits {
+		if limit.PerMinute > 0 {
+			idle = max(idle, time.Duration(limit.Burst)*time.Minute/time.Duration(limit.PerMinute))
+		}
+	}
+	idle = max(idle, time.Minute)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.repo.DeleteIdle(ctx, idle); err != nil {
+				log.Printf("Failed to delete idle rate limit buckets: %v", err)
+			}
+		}
+	}
+}
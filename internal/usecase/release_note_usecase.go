@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/locale"
+	"launcherbackend_api/pkg/markdown"
+)
+
+var (
+	// ErrInvalidLocale is returned for a locale that is not a language tag.
+	ErrInvalidLocale = errors.New("invalid locale")
+	// ErrUnsupportedNotesFormat is returned for a release notes format that cannot be rendered.
+	ErrUnsupportedNotesFormat = errors.New("unsupported release notes format")
+)
+
+// Formats release notes can be rendered in. Release notes are written in Markdown.
+const (
+	NotesFormatMarkdown = "markdown"
+	NotesFormatHTML     = "html"
+	NotesFormatText     = "text"
+)
+
+// maxRenderedNotes bounds the render cache; it is emptied when full.
+const maxRenderedNotes = 4096
+
+type renderKey struct {
+	otaID  string
+	locale string
+	format string
+}
+
+type renderedNotes struct {
+	source [sha256.Size]byte
+	output string
+}
+
+// ReleaseNoteUseCase manages the translations of release notes and picks the
+// one that suits a reader.
+type ReleaseNoteUseCase struct {
+	otaRepo       repository.OTARepository
+	noteRepo      repository.ReleaseNoteRepository
+	defaultLocale string
+	audit         *AuditUseCase
+
+	mu       sync.Mutex
+	rendered map[renderKey]renderedNotes
+}
+
+// NewReleaseNoteUseCase creates the release note use case. A release's own
+// release notes are taken to be written in defaultLocale unless a translation
+// for that locale exists.
+func NewReleaseNoteUseCase(otaRepo repository.OTARepository, noteRepo repository.ReleaseNoteRepository, defaultLocale string, audit *AuditUseCase) (*ReleaseNoteUseCase, error) {
+	canonical, err := locale.Canonical(defaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("default release notes locale: %w", err)
+	}
+	return &ReleaseNoteUseCase{
+		otaRepo:       otaRepo,
+		noteRepo:      noteRepo,
+		defaultLocale: canonical,
+		audit:         audit,
+		rendered:      make(map[renderKey]renderedNotes),
+	}, nil
+}
+
+func (uc *ReleaseNoteUseCase) ListNotes(ctx context.Context, otaID string) ([]entity.ReleaseNote, error) {
+	if otaID == "" {
+		return nil, fmt.Errorf("ID is required")
+	}
+	if err := authorizeRelease(ctx, uc.otaRepo, ActionView, otaID); err != nil {
+		return nil, err
+	}
+	return uc.noteRepo.ListByOTA(ctx, otaID)
+}
+
+// SetNote adds or replaces the translation of a release's notes into a locale.
+func (uc *ReleaseNoteUseCase) SetNote(ctx context.Context, otaID string, tag string, notes string) (entity.ReleaseNote, error) {
+	if otaID == "" {
+		return entity.ReleaseNote{}, fmt.Errorf("ID is required")
+	}
+	canonical, err := locale.Canonical(tag)
+	if err != nil {
+		return entity.ReleaseNote{}, fmt.Errorf("%w: %v", ErrInvalidLocale, err)
+	}
+	if notes == "" {
+		return entity.ReleaseNote{}, fmt.Errorf("notes are required")
+	}
+
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return entity.ReleaseNote{}, err
+	}
+	if err := authorize(ctx, ActionUpdate, ota.AppID); err != nil {
+		return entity.ReleaseNote{}, err
+	}
+
+	var note entity.ReleaseNote
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		change := AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceReleaseNote, ResourceID: otaID + "/" + canonical, AppID: ota.AppID}
+		existing, ok, err := uc.findNote(ctx, otaID, canonical)
+		if err != nil {
+			return AuditChange{}, err
+		}
+		if ok {
+			change.Action, change.Before = entity.AuditActionUpdate, existing
+		}
+
+		note, err = uc.noteRepo.Upsert(ctx, entity.ReleaseNote{OTAID: otaID, Locale: canonical, Notes: notes})
+		change.After = note
+		return change, err
+	})
+	return note, err
+}
+
+func (uc *ReleaseNoteUseCase) DeleteNote(ctx context.Context, otaID string, tag string) error {
+	if otaID == "" {
+		return fmt.Errorf("ID is required")
+	}
+	canonical, err := locale.Canonical(tag)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLocale, err)
+	}
+
+	ota, _, err := uc.otaRepo.Get(ctx, otaID, "")
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, ActionUpdate, ota.AppID); err != nil {
+		return err
+	}
+
+	return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		existing, _, err := uc.findNote(ctx, otaID, canonical)
+		if err != nil {
+			return AuditChange{}, err
+		}
+		change := AuditChange{Action: entity.AuditActionDelete, ResourceType: entity.AuditResourceReleaseNote, ResourceID: otaID + "/" + canonical, AppID: ota.AppID, Before: existing}
+		return change, uc.noteRepo.Delete(ctx, otaID, canonical)
+	})
+}
+
+// findNote returns the translation of a release's notes into a locale.
+func (uc *ReleaseNoteUseCase) findNote(ctx context.Context, otaID string, tag string) (entity.ReleaseNote, bool, error) {
+	notes, err := uc.noteRepo.ListByOTA(ctx, otaID)
+	if err != nil {
+		return entity.ReleaseNote{}, false, err
+	}
+	for _, note := range notes {
+		if note.Locale == tag {
+			return note, true, nil
+		}
+	}
+	return entity.ReleaseNote{}, false, nil
+}
+
+// Localize replaces the release notes of ota with the translation that best
+// matches the preferred language tags, falling back to the default locale.
+func (uc *ReleaseNoteUseCase) Localize(ctx context.Context, ota *entity.OTA, preferred []string) error {
+	notes, err := uc.noteRepo.ListByOTA(ctx, ota.ID)
+	if err != nil {
+		return err
+	}
+
+	byLocale := make(map[string]string, len(notes)+1)
+	if ota.ReleaseNotes != "" {
+		byLocale[uc.defaultLocale] = ota.ReleaseNotes
+	}
+	for _, n := range notes {
+		byLocale[n.Locale] = n.Notes
+	}
+	if len(byLocale) == 0 {
+		return nil
+	}
+
+	available := make([]string, 0, len(byLocale))
+	for tag := range byLocale {
+		available = append(available, tag)
+	}
+	slices.Sort(available)
+
+	tag, ok := locale.Match(preferred, available)
+	if !ok {
+		if _, ok = byLocale[uc.defaultLocale]; !ok {
+			return nil
+		}
+		tag = uc.defaultLocale
+	}
+
+	ota.ReleaseNotes = byLocale[tag]
+	ota.ReleaseNotesLocale = tag
+	return nil
+}
+
+// Render converts the Markdown release notes of ota to the given format.
+// Renderings are cached per release, locale and format, and redone when the
+// notes change.
+func (uc *ReleaseNoteUseCase) Render(ota *entity.OTA, format string) error {
+	var render func(string) string
+	switch format {
+	case "", NotesFormatMarkdown:
+		ota.ReleaseNotesFormat = NotesFormatMarkdown
+		return nil
+	case NotesFormatHTML:
+		render = markdown.ToHTML
+	case NotesFormatText:
+		render = markdown.ToText
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedNotesFormat, format)
+	}
+
+	key := renderKey{otaID: ota.ID, locale: ota.ReleaseNotesLocale, format: format}
+	source := sha256.Sum256([]byte(ota.ReleaseNotes))
+
+	uc.mu.Lock()
+	cached, ok := uc.rendered[key]
+	uc.mu.Unlock()
+
+	if !ok || cached.source != source {
+		cached = renderedNotes{source: source, output: render(ota.ReleaseNotes)}
+
+		uc.mu.Lock()
+		if len(uc.rendered) >= maxRenderedNotes {
+			clear(uc.rendered)
+		}
+		uc.rendered[key] = cached
+		uc.mu.Unlock()
+	}
+
+	ota.ReleaseNotes = cached.output
+	ota.ReleaseNotesFormat = format
+	return nil
+}
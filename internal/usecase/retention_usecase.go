@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// RetentionUseCase deletes the artifacts of releases that fall outside an
+// app's retention policy and archives those releases.
+type RetentionUseCase struct {
+	otaRepo         repository.OTARepository
+	patchRepo       repository.OTAPatchRepository
+	artifactRepo    repository.OTAArtifactRepository
+	policyRepo      repository.RetentionPolicyRepository
+	blobs           *BlobStore
+	defaultKeepLast int
+	installTTL      time.Duration
+	tuf             *TUFUseCase
+	audit           *AuditUseCase
+}
+
+// NewRetentionUseCase creates the retention use case. Apps without a policy
+// keep defaultKeepLast releases, or all of them when it is zero. Installs
+// reported longer than installTTL ago no longer protect a release.
+func NewRetentionUseCase(otaRepo repository.OTARepository, patchRepo repository.OTAPatchRepository, artifactRepo repository.OTAArtifactRepository, policyRepo repository.RetentionPolicyRepository, blobs *BlobStore, defaultKeepLast int, installTTL time.Duration, tuf *TUFUseCase, audit *AuditUseCase) *RetentionUseCase {
+	return &RetentionUseCase{
+		otaRepo:         otaRepo,
+		patchRepo:       patchRepo,
+		artifactRepo:    artifactRepo,
+		policyRepo:      policyRepo,
+		blobs:           blobs,
+		defaultKeepLast: defaultKeepLast,
+		installTTL:      installTTL,
+		tuf:             tuf,
+		audit:           audit,
+	}
+}
+
+// GetPolicy returns the effective retention policy of an app.
+func (uc *RetentionUseCase) GetPolicy(ctx context.Context, appID string) (entity.RetentionPolicy, error) {
+	if appID == "" {
+		return entity.RetentionPolicy{}, fmt.Errorf("app ID is required")
+	}
+	if err := authorize(ctx, ActionView, appID); err != nil {
+		return entity.RetentionPolicy{}, err
+	}
+
+	policy, ok, err := uc.policyRepo.Get(ctx, appID)
+	if err != nil {
+		return entity.RetentionPolicy{}, err
+	}
+	if !ok {
+		return entity.RetentionPolicy{AppID: appID, KeepLast: uc.defaultKeepLast}, nil
+	}
+
+	return policy, nil
+}
+
+// SetPolicy sets how many published releases of an app keep their artifacts.
+// Zero keeps every release.
+func (uc *RetentionUseCase) SetPolicy(ctx context.Context, appID string, keepLast int) (entity.RetentionPolicy, error) {
+	if appID == "" {
+		return entity.RetentionPolicy{}, fmt.Errorf("app ID is required")
+	}
+	if keepLast < 0 {
+		return entity.RetentionPolicy{}, fmt.Errorf("keep last must not be negative")
+	}
+	// The policy decides which releases lose their artifacts
+	if err := authorize(ctx, ActionDelete, appID); err != nil {
+		return entity.RetentionPolicy{}, err
+	}
+
+	var policy entity.RetentionPolicy
+	err := uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		change := AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceRetentionPolicy, ResourceID: appID, AppID: appID}
+		existing, ok, err := uc.policyRepo.Get(ctx, appID)
+		if err != nil {
+			return AuditChange{}, err
+		}
+		if ok {
+			change.Action, change.Before = entity.AuditActionUpdate, existing
+		}
+
+		policy, err = uc.policyRepo.Upsert(ctx, entity.RetentionPolicy{AppID: appID, KeepLast: keepLast})
+		change.After = policy
+		return change, err
+	})
+	return policy, err
+}
+
+// Report returns what garbage collection of the app would delete, without deleting anything.
+func (uc *RetentionUseCase) Report(ctx context.Context, appID string) (entity.RetentionReport, error) {
+	report, _, err := uc.plan(ctx, appID)
+	return report, err
+}
+
+// Collect deletes the artifacts of the app's expired releases and archives them.
+func (uc *RetentionUseCase) Collect(ctx context.Context, appID string) (entity.RetentionReport, error) {
+	if err := authorize(ctx, ActionDelete, appID); err != nil {
+		return entity.RetentionReport{}, err
+	}
+
+	report, expired, err := uc.plan(ctx, appID)
+	if err != nil {
+		return entity.RetentionReport{}, err
+	}
+
+	for _, ota := range expired {
+		archived, err := uc.archive(ctx, ota)
+		if err != nil {
+			return entity.RetentionReport{}, fmt.Errorf("failed to archive OTA %s: %w", ota.ID, err)
+		}
+		if !archived {
+			log.Printf("Kept OTA %s (%s %d): it was pinned or unpublished meanwhile", ota.ID, ota.AppID, ota.VersionCode)
+			continue
+		}
+		log.Printf("Archived OTA %s (%s %d) by retention policy", ota.ID, ota.AppID, ota.VersionCode)
+	}
+
+	return report, nil
+}
+
+// Run garbage collects every app at the given interval until ctx is cancelled.
+func (uc *RetentionUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			appIDs, err := uc.otaRepo.ListAppIDs(ctx)
+			if err != nil {
+				log.Printf("Failed to list apps for retention: %v", err)
+				continue
+			}
+			for _, appID := range appIDs {
+				if _, err := uc.Collect(ctx, appID); err != nil {
+					log.Printf("Failed to apply retention policy of %s: %v", appID, err)
+				}
+			}
+		}
+	}
+}
+
+func (uc *RetentionUseCase) plan(ctx context.Context, appID string) (entity.RetentionReport, []entity.OTA, error) {
+	policy, err := uc.GetPolicy(ctx, appID)
+	if err != nil {
+		return entity.RetentionReport{}, nil, err
+	}
+
+	report := entity.RetentionReport{
+		AppID:      appID,
+		KeepLast:   policy.KeepLast,
+		Candidates: []entity.RetentionCandidate{},
+	}
+	if policy.KeepLast <= 0 {
+		return report, nil, nil
+	}
+
+	otas, err := uc.otaRepo.ListRetentionCandidates(ctx, appID, policy.KeepLast, time.Now().Add(-uc.installTTL))
+	if err != nil {
+		return entity.RetentionReport{}, nil, err
+	}
+
+	for _, ota := range otas {
+		artifacts, err := uc.artifactRepo.ListByOTA(ctx, ota.ID)
+		if err != nil {
+			return entity.RetentionReport{}, nil, err
+		}
+		patches, err := uc.patchRepo.ListInvolving(ctx, ota.ID)
+		if err != nil {
+			return entity.RetentionReport{}, nil, err
+		}
+
+		candidate := entity.RetentionCandidate{
+			OTAID:       ota.ID,
+			VersionName: ota.VersionName,
+			VersionCode: ota.VersionCode,
+			Artifacts:   len(artifacts),
+			Patches:     len(patches),
+		}
+		if ota.StorageKey != "" {
+			candidate.SizeBytes = ota.SizeBytes
+		}
+		for _, a := range artifacts {
+			candidate.ArtifactBytes += a.SizeBytes
+		}
+		for _, p := range patches {
+			candidate.PatchBytes += p.SizeBytes
+		}
+
+		report.Candidates = append(report.Candidates, candidate)
+		report.ReclaimableBytes += candidate.SizeBytes + candidate.ArtifactBytes + candidate.PatchBytes
+	}
+
+	return report, otas, nil
+}
+
+// archive archives a published release and drops its artifacts and patches
+// in one transaction, reporting whether it did. Archive re-checks that the
+// release is still published and not pinned, so a release pinned since it was
+// planned keeps everything. Blobs are released only once the transaction has
+// committed; a crash in between at worst leaks a blob.
+func (uc *RetentionUseCase) archive(ctx context.Context, release entity.OTA) (bool, error) {
+	var (
+		archived bool
+		digests  []string
+	)
+	err := uc.tuf.Apply(ctx, func(ctx context.Context) error {
+		return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+			archived, digests = false, nil
+
+			before, _, err := uc.otaRepo.Get(ctx, release.ID, "")
+			if err != nil {
+				return AuditChange{}, err
+			}
+			if archived, err = uc.otaRepo.Archive(ctx, release.ID); err != nil || !archived {
+				return AuditChange{}, err
+			}
+			if before.StorageKey != "" {
+				digests = append(digests, before.SHA256)
+			}
+
+			artifacts, err := uc.artifactRepo.ListByOTA(ctx, release.ID)
+			if err != nil {
+				return AuditChange{}, err
+			}
+			for _, a := range artifacts {
+				if err := uc.artifactRepo.Delete(ctx, a.ID); err != nil {
+					return AuditChange{}, err
+				}
+				digests = append(digests, a.SHA256)
+			}
+
+			patches, err := uc.patchRepo.ListInvolving(ctx, release.ID)
+			if err != nil {
+				return AuditChange{}, err
+			}
+			for _, p := range patches {
+				if err := uc.patchRepo.Delete(ctx, p.ID); err != nil {
+					return AuditChange{}, err
+				}
+				if p.StorageKey != "" {
+					digests = append(digests, p.SHA256)
+				}
+			}
+
+			after, _, err := uc.otaRepo.Get(ctx, release.ID, "")
+			return otaChange(entity.AuditActionUpdate, &before, &after), err
+		})
+	}, release.AppID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, digest := range digests {
+		if err := uc.blobs.Release(ctx, digest); err != nil {
+			log.Printf("Failed to release artifact %s of archived OTA %s: %v", digest, release.ID, err)
+		}
+	}
+
+	return archived, nil
+}
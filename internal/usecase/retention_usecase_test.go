@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+)
+
+// seedReleases stores three releases of an app. The first was uploaded, with
+// a split sharing its blob with the third and a patch to the third; the
+// second points at an external URL and only has a failed patch.
+func seedReleases(store *fakeStore) {
+	published := func(id string, versionCode int, digest string) entity.OTA {
+		ota := entity.OTA{ID: id, AppID: "launcher", PayloadType: entity.PayloadTypeAPK, VersionCode: versionCode, SHA256: digest, Status: entity.OTAStatusPublished}
+		if digest != "" {
+			ota.StorageKey = store.putBlob(digest, 1)
+		}
+		return ota
+	}
+	store.tables.otas["ota-1"] = published("ota-1", 1, "apk-1")
+	store.tables.otas["ota-2"] = published("ota-2", 2, "")
+	store.tables.otas["ota-3"] = published("ota-3", 3, "apk-3")
+
+	splitKey := store.putBlob("split-shared", 2)
+	store.tables.artifacts["split-1"] = entity.OTAArtifact{ID: "split-1", OTAID: "ota-1", SHA256: "split-shared", StorageKey: splitKey}
+	store.tables.artifacts["split-3"] = entity.OTAArtifact{ID: "split-3", OTAID: "ota-3", SHA256: "split-shared", StorageKey: splitKey}
+
+	store.tables.patches["patch-13"] = entity.OTAPatch{ID: "patch-13", OTAID: "ota-3", FromOTAID: "ota-1", SHA256: "patch-13", StorageKey: store.putBlob("patch-13", 1), Status: entity.PatchStatusReady}
+	store.tables.patches["patch-23"] = entity.OTAPatch{ID: "patch-23", OTAID: "ota-3", FromOTAID: "ota-2", Status: entity.PatchStatusFailed}
+}
+
+func newTestRetentionUseCase(store *fakeStore, otaRepo *fakeOTARepo) *RetentionUseCase {
+	blobs := NewBlobStore(store, &fakeBlobRepo{store: store}, &fakeStorage{store: store})
+	audit := NewAuditUseCase(store, &fakeAuditRepo{store: store})
+	return NewRetentionUseCase(otaRepo, &fakePatchRepo{store: store}, &fakeArtifactRepo{store: store}, &fakePolicyRepo{}, blobs, 1, 90*24*time.Hour, nil, audit)
+}
+
+func TestCollectArchivesExpiredReleases(t *testing.T) {
+	store := newFakeStore()
+	seedReleases(store)
+	uc := newTestRetentionUseCase(store, &fakeOTARepo{store: store})
+
+	report, err := uc.Collect(context.Background(), "launcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Candidates) != 2 {
+		t.Fatalf("report has %d candidates, want 2", len(report.Candidates))
+	}
+
+	for id, want := range map[string]string{"ota-1": entity.OTAStatusArchived, "ota-2": entity.OTAStatusArchived, "ota-3": entity.OTAStatusPublished} {
+		if got := store.tables.otas[id].Status; got != want {
+			t.Errorf("%s is %s, want %s", id, got, want)
+		}
+	}
+	if _, ok := store.tables.artifacts["split-3"]; !ok || len(store.tables.artifacts) != 1 {
+		t.Errorf("artifacts left: %v, want only split-3", store.tables.artifacts)
+	}
+	if len(store.tables.patches) != 0 {
+		t.Errorf("patches left: %v, want none", store.tables.patches)
+	}
+
+	// Blobs only referenced by the archived releases are gone with their
+	// files; the split shared with the kept release loses one reference.
+	for _, digest := range []string{"apk-1", "patch-13"} {
+		if _, ok := store.tables.blobs[digest]; ok {
+			t.Errorf("blob %s was kept", digest)
+		}
+		if _, ok := store.tables.files[blobKey(digest)]; ok {
+			t.Errorf("file of blob %s was kept", digest)
+		}
+	}
+	if blob := store.tables.blobs["split-shared"]; blob.RefCount != 1 {
+		t.Errorf("shared blob has %d references, want 1", blob.RefCount)
+	}
+	if _, ok := store.tables.files[blobKey("split-shared")]; !ok {
+		t.Error("file of the shared blob was deleted")
+	}
+
+	// Blobs are released by their own transaction, after archiving committed
+	want := map[string][]int{"apk-1": {1}, "patch-13": {1}, "split-shared": {1}}
+	if !reflect.DeepEqual(store.releases, want) {
+		t.Errorf("released %v, want %v", store.releases, want)
+	}
+
+	var archived []string
+	for _, entry := range store.tables.audit {
+		if entry.Action != entity.AuditActionUpdate || entry.ResourceType != entity.AuditResourceOTA {
+			t.Errorf("unexpected audit entry %s %s", entry.Action, entry.ResourceType)
+		}
+		archived = append(archived, entry.ResourceID)
+	}
+	slices.Sort(archived)
+	if !reflect.DeepEqual(archived, []string{"ota-1", "ota-2"}) {
+		t.Errorf("audit entries for %v, want ota-1 and ota-2", archived)
+	}
+}
+
+func TestCollectKeepsReleasesChangedMeanwhile(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(ota *entity.OTA)
+	}{
+		{"pinned", func(ota *entity.OTA) { ota.Pinned = true }},
+		{"archived", func(ota *entity.OTA) { ota.Status = entity.OTAStatusArchived }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			seedReleases(store)
+			otaRepo := &fakeOTARepo{store: store}
+			otaRepo.onListCandidates = func() {
+				ota := store.tables.otas["ota-1"]
+				tt.change(&ota)
+				store.tables.otas["ota-1"] = ota
+			}
+			uc := newTestRetentionUseCase(store, otaRepo)
+
+			if _, err := uc.Collect(context.Background(), "launcher"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := store.tables.artifacts["split-1"]; !ok {
+				t.Error("artifact of ota-1 was deleted")
+			}
+			if _, ok := store.tables.patches["patch-13"]; !ok {
+				t.Error("patch from ota-1 was deleted")
+			}
+			for _, digest := range []string{"apk-1", "patch-13", "split-shared"} {
+				if n := len(store.releases[digest]); n != 0 {
+					t.Errorf("blob %s was released %d times", digest, n)
+				}
+			}
+			for _, entry := range store.tables.audit {
+				if entry.ResourceID == "ota-1" {
+					t.Error("kept release has an audit entry")
+				}
+			}
+
+			// The release planned alongside it is archived as usual
+			if got := store.tables.otas["ota-2"].Status; got != entity.OTAStatusArchived {
+				t.Errorf("ota-2 is %s, want archived", got)
+			}
+		})
+	}
+}
+
+func TestCollectRollsBackWhenAuditFails(t *testing.T) {
+	store := newFakeStore()
+	seedReleases(store)
+	before := store.tables.clone()
+	store.auditErr = errors.New("audit log unavailable")
+	uc := newTestRetentionUseCase(store, &fakeOTARepo{store: store})
+
+	if _, err := uc.Collect(context.Background(), "launcher"); !errors.Is(err, store.auditErr) {
+		t.Fatalf("Collect error = %v, want the audit error", err)
+	}
+
+	if !reflect.DeepEqual(store.tables, before) {
+		t.Error("a failed archive changed the database")
+	}
+	if len(store.releases) != 0 {
+		t.Errorf("released %v after a rollback", store.releases)
+	}
+}
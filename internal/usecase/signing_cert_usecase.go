@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/apk"
+)
+
+var (
+	// ErrSigningCertMismatch is returned when an APK is not signed with the certificate pinned for its app.
+	ErrSigningCertMismatch = errors.New("apk signing certificate does not match the certificate registered for this app")
+	// ErrRotationPending is returned when a rotation is started while another one is still pending.
+	ErrRotationPending = errors.New("a signing certificate rotation is already pending for this app")
+)
+
+type SigningCertUseCase struct {
+	certRepo repository.SigningCertRepository
+	audit    *AuditUseCase
+}
+
+func NewSigningCertUseCase(certRepo repository.SigningCertRepository, audit *AuditUseCase) *SigningCertUseCase {
+	return &SigningCertUseCase{
+		certRepo: certRepo,
+		audit:    audit,
+	}
+}
+
+func (uc *SigningCertUseCase) ListCertificates(ctx context.Context, appID string) ([]entity.SigningCertificate, error) {
+	if appID == "" {
+		return nil, fmt.Errorf("app ID is required")
+	}
+	if err := authorize(ctx, ActionView, appID); err != nil {
+		return nil, err
+	}
+	return uc.certRepo.ListByApp(ctx, appID)
+}
+
+// RegisterCertificate pins the first certificate of an app, or starts a rotation
+// to a successor of the active certificate. A pending rotation only becomes
+// active once a release signed with it (and proving the rotation) is uploaded.
+func (uc *SigningCertUseCase) RegisterCertificate(ctx context.Context, appID string, certSHA256 string) (entity.SigningCertificate, error) {
+	if appID == "" {
+		return entity.SigningCertificate{}, fmt.Errorf("app ID is required")
+	}
+	if err := authorize(ctx, ActionAdminister, appID); err != nil {
+		return entity.SigningCertificate{}, err
+	}
+
+	digest, err := normalizeCertDigest(certSHA256)
+	if err != nil {
+		return entity.SigningCertificate{}, err
+	}
+
+	certs, err := uc.certRepo.ListByApp(ctx, appID)
+	if err != nil {
+		return entity.SigningCertificate{}, err
+	}
+
+	active, pending := splitCertificates(certs)
+	if pending != nil {
+		return entity.SigningCertificate{}, ErrRotationPending
+	}
+
+	cert := entity.SigningCertificate{
+		AppID:      appID,
+		CertSHA256: digest,
+		Status:     entity.SigningCertStatusActive,
+	}
+	if active != nil {
+		cert.ParentID = &active.ID
+		cert.Status = entity.SigningCertStatusPending
+	}
+
+	var created entity.SigningCertificate
+	err = uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		var err error
+		created, err = uc.certRepo.Create(ctx, cert)
+		return AuditChange{Action: entity.AuditActionCreate, ResourceType: entity.AuditResourceSigningCert, ResourceID: created.ID, AppID: appID, After: created}, err
+	})
+	return created, err
+}
+
+func (uc *SigningCertUseCase) CancelRotation(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("ID is required")
+	}
+
+	cert, err := uc.certRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, ActionAdminister, cert.AppID); err != nil {
+		return err
+	}
+
+	return uc.audit.Track(ctx, func(ctx context.Context) (AuditChange, error) {
+		change := AuditChange{Action: entity.AuditActionDelete, ResourceType: entity.AuditResourceSigningCert, ResourceID: id, AppID: cert.AppID, Before: cert}
+		return change, uc.certRepo.Delete(ctx, id)
+	})
+}
+
+// IsPinned reports whether releases of the app must be signed with a registered certificate.
+func (uc *SigningCertUseCase) IsPinned(ctx context.Context, appID string) (bool, error) {
+	certs, err := uc.certRepo.ListByApp(ctx, appID)
+	if err != nil {
+		return false, err
+	}
+	return len(certs) > 0, nil
+}
+
+// VerifyRelease checks the signer of an APK against the app's pinned
+// certificate and returns the certificate record the release belongs to.
+// The returned record is not persisted until ConfirmRelease is called.
+func (uc *SigningCertUseCase) VerifyRelease(ctx context.Context, appID string, info *apk.SigningInfo) (entity.SigningCertificate, error) {
+	certs, err := uc.certRepo.ListByApp(ctx, appID)
+	if err != nil {
+		return entity.SigningCertificate{}, err
+	}
+
+	active, pending := splitCertificates(certs)
+
+	// Trust on first use: the first verified upload pins the app's certificate.
+	if active == nil {
+		return entity.SigningCertificate{
+			AppID:      appID,
+			CertSHA256: info.CertSHA256,
+			Status:     entity.SigningCertStatusActive,
+		}, nil
+	}
+
+	if info.CertSHA256 == active.CertSHA256 {
+		return *active, nil
+	}
+
+	// Devices only accept a new certificate if the APK's proof-of-rotation
+	// includes the certificate they already trust.
+	if pending != nil && info.CertSHA256 == pending.CertSHA256 {
+		for _, digest := range info.Lineage {
+			if digest == active.CertSHA256 {
+				return *pending, nil
+			}
+		}
+		return entity.SigningCertificate{}, fmt.Errorf("%w: apk signed with the pending certificate must include a proof-of-rotation from %s", ErrSigningCertMismatch, active.CertSHA256)
+	}
+
+	return entity.SigningCertificate{}, fmt.Errorf("%w: got %s, expected %s", ErrSigningCertMismatch, info.CertSHA256, active.CertSHA256)
+}
+
+// ConfirmRelease persists the pin or completes the rotation returned by
+// VerifyRelease. It joins the transaction in ctx, which should also create
+// the release.
+func (uc *SigningCertUseCase) ConfirmRelease(ctx context.Context, cert entity.SigningCertificate) error {
+	switch {
+	case cert.ID == "":
+		_, err := uc.certRepo.Create(ctx, cert)
+		return err
+	case cert.Status == entity.SigningCertStatusPending:
+		_, err := uc.certRepo.Activate(ctx, cert.ID)
+		return err
+	}
+	return nil
+}
+
+func splitCertificates(certs []entity.SigningCertificate) (active *entity.SigningCertificate, pending *entity.SigningCertificate) {
+	for i := range certs {
+		switch certs[i].Status {
+		case entity.SigningCertStatusActive:
+			active = &certs[i]
+		case entity.SigningCertStatusPending:
+			pending = &certs[i]
+		}
+	}
+	return active, pending
+}
+
+// normalizeCertDigest accepts digests as printed by apksigner or keytool.
+func normalizeCertDigest(s string) (string, error) {
+	digest := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != 32 {
+		return "", fmt.Errorf("certificate SHA-256 digest must be 64 hex characters")
+	}
+	return digest, nil
+}
@@ -0,0 +1,307 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+	"launcherbackend_api/pkg/manifest"
+	"launcherbackend_api/pkg/tuf"
+)
+
+// Lifetimes of the roles whose metadata only changes with releases. The
+// timestamp's lifetime is configured, and kept short so a client notices
+// within it when it is being fed stale metadata.
+const (
+	tufRootLifetime     = 365 * 24 * time.Hour
+	tufTargetsLifetime  = 90 * 24 * time.Hour
+	tufSnapshotLifetime = 7 * 24 * time.Hour
+)
+
+// TUFKeys are the signing keys of each role.
+type TUFKeys struct {
+	Root      []manifest.Key
+	Targets   []manifest.Key
+	Snapshot  []manifest.Key
+	Timestamp []manifest.Key
+}
+
+// TUFUseCase publishes the releases of every app as metadata of The Update
+// Framework. Metadata is regenerated in the transaction of each write to an
+// app's releases, and re-signed before it expires.
+type TUFUseCase struct {
+	transactor        repository.Transactor
+	otaRepo           repository.OTARepository
+	artifactRepo      repository.OTAArtifactRepository
+	metadataRepo      repository.TUFMetadataRepository
+	keys              TUFKeys
+	timestampLifetime time.Duration
+}
+
+func NewTUFUseCase(transactor repository.Transactor, otaRepo repository.OTARepository, artifactRepo repository.OTAArtifactRepository, metadataRepo repository.TUFMetadataRepository, keys TUFKeys, timestampLifetime time.Duration) *TUFUseCase {
+	return &TUFUseCase{
+		transactor:        transactor,
+		otaRepo:           otaRepo,
+		artifactRepo:      artifactRepo,
+		metadataRepo:      metadataRepo,
+		keys:              keys,
+		timestampLifetime: timestampLifetime,
+	}
+}
+
+// Apply runs a write to the releases of apps and regenerates their metadata
+// in the same transaction, so clients never see releases the metadata does
+// not describe. A nil TUFUseCase just runs the write.
+func (uc *TUFUseCase) Apply(ctx context.Context, write func(ctx context.Context) error, appIDs ...string) error {
+	if uc == nil {
+		return write(ctx)
+	}
+
+	return uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := write(ctx); err != nil {
+			return err
+		}
+		slices.Sort(appIDs)
+		for _, appID := range slices.Compact(appIDs) {
+			if err := uc.regenerate(ctx, appID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Refresh regenerates the metadata of an app, re-signing roles that are about
+// to expire.
+func (uc *TUFUseCase) Refresh(ctx context.Context, appID string) error {
+	return uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		return uc.regenerate(ctx, appID)
+	})
+}
+
+// Run refreshes the metadata of every app at each interval until ctx is done.
+func (uc *TUFUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		uc.refreshAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *TUFUseCase) refreshAll(ctx context.Context) {
+	published, err := uc.otaRepo.ListAppIDs(ctx)
+	if err != nil {
+		log.Printf("Failed to list apps for TUF metadata: %v", err)
+		return
+	}
+	existing, err := uc.metadataRepo.ListAppIDs(ctx)
+	if err != nil {
+		log.Printf("Failed to list apps for TUF metadata: %v", err)
+		return
+	}
+
+	appIDs := append(published, existing...)
+	slices.Sort(appIDs)
+	for _, appID := range slices.Compact(appIDs) {
+		if err := uc.Refresh(ctx, appID); err != nil {
+			log.Printf("Failed to refresh TUF metadata of %s: %v", appID, err)
+		}
+	}
+}
+
+// GetMetadata returns the latest metadata of a role of an app.
+func (uc *TUFUseCase) GetMetadata(ctx context.Context, appID string, role string) (entity.TUFMetadata, bool, error) {
+	if appID == "" {
+		return entity.TUFMetadata{}, false, fmt.Errorf("app ID is required")
+	}
+	return uc.metadataRepo.GetLatest(ctx, appID, role)
+}
+
+// GetRoot returns a version of the root metadata of an app, for clients
+// walking from the root they trust to the latest one.
+func (uc *TUFUseCase) GetRoot(ctx context.Context, appID string, version int) (entity.TUFMetadata, error) {
+	if appID == "" {
+		return entity.TUFMetadata{}, fmt.Errorf("app ID is required")
+	}
+	return uc.metadataRepo.Get(ctx, appID, entity.TUFRoleRoot, version)
+}
+
+// regenerate brings the metadata of an app up to date. Each role gets a new
+// version when its content changed or it expires within half its lifetime;
+// a new version of a role changes the content of the role listing it.
+func (uc *TUFUseCase) regenerate(ctx context.Context, appID string) error {
+	if err := uc.metadataRepo.Lock(ctx, appID); err != nil {
+		return err
+	}
+
+	root := tuf.Root{Roles: make(map[string]tuf.RoleKeys)}
+	for role, keys := range map[string][]manifest.Key{
+		entity.TUFRoleRoot:      uc.keys.Root,
+		entity.TUFRoleTargets:   uc.keys.Targets,
+		entity.TUFRoleSnapshot:  uc.keys.Snapshot,
+		entity.TUFRoleTimestamp: uc.keys.Timestamp,
+	} {
+		trusted, err := tuf.Trust(&root, keys)
+		if err != nil {
+			return err
+		}
+		root.Roles[role] = trusted
+	}
+	// Clients only accept a new root signed by a key of the root they trust,
+	// so root keys rotate by first adding the new key and only then removing
+	// the old one
+	if _, err := uc.update(ctx, appID, entity.TUFRoleRoot, uc.keys.Root, tufRootLifetime, func(h tuf.Header) any {
+		root.Header = h
+		return root
+	}); err != nil {
+		return err
+	}
+
+	files, err := uc.targetFiles(ctx, appID)
+	if err != nil {
+		return err
+	}
+	targets, err := uc.update(ctx, appID, entity.TUFRoleTargets, uc.keys.Targets, tufTargetsLifetime, func(h tuf.Header) any {
+		return tuf.Targets{Header: h, Targets: files}
+	})
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := uc.update(ctx, appID, entity.TUFRoleSnapshot, uc.keys.Snapshot, tufSnapshotLifetime, func(h tuf.Header) any {
+		return tuf.Snapshot{Header: h, Meta: map[string]tuf.MetaFile{
+			"targets.json": {Version: targets.Version},
+		}}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = uc.update(ctx, appID, entity.TUFRoleTimestamp, uc.keys.Timestamp, uc.timestampLifetime, func(h tuf.Header) any {
+		return tuf.Timestamp{Header: h, Meta: map[string]tuf.MetaFile{
+			"snapshot.json": {Version: snapshot.Version, Length: int64(len(snapshot.Content)), Hashes: tuf.Digest(snapshot.Content)},
+		}}
+	})
+	return err
+}
+
+// update stores a new version of a role when build returns different content
+// than the latest version has, or the latest version expires within half of
+// lifetime, and returns the role's latest metadata.
+func (uc *TUFUseCase) update(ctx context.Context, appID string, role string, keys []manifest.Key, lifetime time.Duration, build func(tuf.Header) any) (entity.TUFMetadata, error) {
+	body, err := manifest.Canonicalize(build(tuf.NewHeader(role, 0, time.Time{})))
+	if err != nil {
+		return entity.TUFMetadata{}, fmt.Errorf("failed to encode %s metadata: %w", role, err)
+	}
+	sum := sha256.Sum256(body)
+	bodySHA256 := hex.EncodeToString(sum[:])
+
+	now := time.Now()
+	latest, ok, err := uc.metadataRepo.GetLatest(ctx, appID, role)
+	if err != nil {
+		return entity.TUFMetadata{}, err
+	}
+	if ok && latest.BodySHA256 == bodySHA256 && latest.ExpiresAt.Sub(now) > lifetime/2 {
+		return latest, nil
+	}
+
+	metadata := entity.TUFMetadata{
+		AppID:      appID,
+		Role:       role,
+		Version:    latest.Version + 1,
+		BodySHA256: bodySHA256,
+		ExpiresAt:  now.Add(lifetime).UTC().Truncate(time.Second),
+		CreatedAt:  now,
+	}
+	metadata.Content, err = tuf.Sign(build(tuf.NewHeader(role, metadata.Version, metadata.ExpiresAt)), keys)
+	if err != nil {
+		return entity.TUFMetadata{}, fmt.Errorf("failed to sign %s metadata: %w", role, err)
+	}
+
+	if err := uc.metadataRepo.Create(ctx, metadata); err != nil {
+		return entity.TUFMetadata{}, err
+	}
+	return metadata, nil
+}
+
+// targetFiles lists the published releases of an app and their artifacts as
+// target files. Releases whose digest or size is unknown cannot be verified
+// and are left out.
+func (uc *TUFUseCase) targetFiles(ctx context.Context, appID string) (map[string]tuf.TargetFile, error) {
+	releases, err := uc.otaRepo.ListPublished(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]tuf.TargetFile)
+	for _, ota := range releases {
+		custom := map[string]any{
+			"ota_id":       ota.ID,
+			"payload_type": ota.PayloadType,
+			"version_code": ota.VersionCode,
+			"version_name": ota.VersionName,
+		}
+		if ota.SHA256 != "" && ota.SizeBytes > 0 {
+			files[TargetPath(ota.ID, "")] = tuf.TargetFile{
+				Length: ota.SizeBytes,
+				Hashes: map[string]string{"sha256": strings.ToLower(ota.SHA256)},
+				Custom: custom,
+			}
+		}
+
+		artifacts, err := uc.artifactRepo.ListByOTA(ctx, ota.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, artifact := range artifacts {
+			artifactCustom := map[string]any{"abi": artifact.ABI, "density": artifact.Density, "split_name": artifact.SplitName}
+			for k, v := range custom {
+				artifactCustom[k] = v
+			}
+			files[TargetPath(ota.ID, artifact.ID)] = tuf.TargetFile{
+				Length: artifact.SizeBytes,
+				Hashes: map[string]string{"sha256": strings.ToLower(artifact.SHA256)},
+				Custom: artifactCustom,
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// TargetPath returns the target path of a release, or of one of its
+// artifacts when artifactID is set.
+func TargetPath(otaID string, artifactID string) string {
+	if artifactID == "" {
+		return "releases/" + otaID
+	}
+	return "releases/" + otaID + "/artifacts/" + artifactID
+}
+
+// ParseRootFile parses the "<version>.root.json" file name of a root version.
+func ParseRootFile(name string) (int, bool) {
+	version, ok := strings.CutSuffix(name, ".root.json")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(version)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"launcherbackend_api/internal/domain/entity"
+	"launcherbackend_api/internal/domain/repository"
+)
+
+// maxChangelogEntries bounds the changelog of a device many versions behind.
+const maxChangelogEntries = 50
+
+// UpdateUseCase answers update checks from devices.
+type UpdateUseCase struct {
+	otaRepo     repository.OTARepository
+	installRepo repository.DeviceInstallRepository
+	delta       *DeltaUseCase
+	artifacts   *ArtifactUseCase
+	download    *DownloadUseCase
+	mirrors     *MirrorUseCase
+	notes       *ReleaseNoteUseCase
+	// manifests signs responses; nil when manifests are not signed.
+	manifests *ManifestUseCase
+	// requireDevice rejects checks from devices that have not enrolled.
+	requireDevice bool
+}
+
+func NewUpdateUseCase(otaRepo repository.OTARepository, installRepo repository.DeviceInstallRepository, delta *DeltaUseCase, artifacts *ArtifactUseCase, download *DownloadUseCase, mirrors *MirrorUseCase, notes *ReleaseNoteUseCase, manifests *ManifestUseCase, requireDevice bool) *UpdateUseCase {
+	return &UpdateUseCase{
+		otaRepo:       otaRepo,
+		installRepo:   installRepo,
+		delta:         delta,
+		artifacts:     artifacts,
+		download:      download,
+		mirrors:       mirrors,
+		notes:         notes,
+		manifests:     manifests,
+		requireDevice: requireDevice,
+	}
+}
+
+// CheckUpdate returns the newest release above the device's version code. If
+// the release has artifacts built for the device's ABIs and density they are
+// offered instead of the universal artifact. Otherwise, when the device reports
+// the digest of its installed artifact and a verified patch from it exists,
+// the patch is offered alongside the full release. Healthy mirrors of the
+// release are listed as fallbacks and release notes are given in the device's
+// preferred language and format, together with a changelog of every release
+// the device skipped and the dangerous permissions the update adds. Download
+// URLs in the response are signed for the requesting device.
+//
+// An enrolled device may only check for itself and for the app its credential
+// was issued for. When manifests are signed, every response carries a signed
+// manifest of what it offers.
+func (uc *UpdateUseCase) CheckUpdate(ctx context.Context, req entity.UpdateCheckRequest) (entity.UpdateCheck, error) {
+	if req.AppID == "" {
+		return entity.UpdateCheck{}, fmt.Errorf("app ID is required")
+	}
+	if req.VersionCode < 0 {
+		return entity.UpdateCheck{}, fmt.Errorf("valid version code is required")
+	}
+	if req.PayloadType == "" {
+		req.PayloadType = entity.PayloadTypeAPK
+	}
+	if !entity.IsValidPayloadType(req.PayloadType) {
+		return entity.UpdateCheck{}, fmt.Errorf("%w: %q", ErrUnsupportedPayloadType, req.PayloadType)
+	}
+	device, enrolled := DeviceFromContext(ctx)
+	if enrolled {
+		if req.DeviceID == "" {
+			req.DeviceID = device.DeviceID
+		}
+		if req.DeviceID != device.DeviceID {
+			return entity.UpdateCheck{}, fmt.Errorf("%w: credential was issued to another device", ErrForbidden)
+		}
+		if device.AppID != "" && device.AppID != req.AppID {
+			return entity.UpdateCheck{}, fmt.Errorf("%w: credential was issued for another app", ErrForbidden)
+		}
+	} else if uc.requireDevice {
+		return entity.UpdateCheck{}, fmt.Errorf("%w: device is not enrolled", ErrUnauthenticated)
+	}
+	if uc.download.SigningEnabled() && req.DeviceID == "" {
+		return entity.UpdateCheck{}, ErrDeviceIDRequired
+	}
+
+	installedSHA256 := strings.ToLower(req.InstalledSHA256)
+
+	// Releases installed on devices are kept by the retention policy, so only
+	// enrolled devices are trusted to report what they run
+	if enrolled {
+		install := entity.DeviceInstall{
+			DeviceID:    device.DeviceID,
+			AppID:       req.AppID,
+			PayloadType: req.PayloadType,
+			VersionCode: req.VersionCode,
+			SHA256:      installedSHA256,
+			ReportedAt:  time.Now(),
+		}
+		if err := uc.installRepo.Record(ctx, install); err != nil {
+			log.Printf("Failed to record install of %s on device %s: %v", req.AppID, device.DeviceID, err)
+		}
+	}
+
+	latest, ok, err := uc.otaRepo.GetLatest(ctx, req.AppID, req.PayloadType)
+	if err != nil {
+		return entity.UpdateCheck{}, err
+	}
+	if !ok || latest.VersionCode <= req.VersionCode {
+		return uc.signed(req, entity.UpdateCheck{UpdateAvailable: false})
+	}
+
+	artifacts, err := uc.artifacts.SelectArtifacts(ctx, latest.ID, req.ABIs, req.Density)
+	if err != nil {
+		return entity.UpdateCheck{}, err
+	}
+	for i := range artifacts {
+		if artifacts[i].URL, err = uc.download.SignArtifactURL(artifacts[i], req.DeviceID); err != nil {
+			return entity.UpdateCheck{}, err
+		}
+	}
+
+	var patch *entity.OTAPatch
+	if len(artifacts) == 0 {
+		if patch, err = uc.delta.FindPatch(ctx, latest.ID, installedSHA256); err != nil {
+			return entity.UpdateCheck{}, err
+		}
+	}
+
+	mirrors, err := uc.mirrors.HealthyURLs(ctx, latest.ID)
+	if err != nil {
+		return entity.UpdateCheck{}, err
+	}
+
+	if err := uc.notes.Localize(ctx, &latest, req.Languages); err != nil {
+		return entity.UpdateCheck{}, err
+	}
+	if err := uc.notes.Render(&latest, req.NotesFormat); err != nil {
+		return entity.UpdateCheck{}, err
+	}
+
+	// Devices without an installed version get no history
+	var history []entity.OTA
+	if req.VersionCode > 0 {
+		history, err = uc.otaRepo.ListHistory(ctx, req.AppID, req.PayloadType, req.VersionCode, latest.VersionCode, maxChangelogEntries)
+		if err != nil {
+			return entity.UpdateCheck{}, err
+		}
+	}
+
+	changelog, err := uc.changelog(ctx, req, latest, history)
+	if err != nil {
+		return entity.UpdateCheck{}, err
+	}
+
+	if latest.URL, err = uc.download.SignOTAURL(latest, req.DeviceID); err != nil {
+		return entity.UpdateCheck{}, err
+	}
+	if patch != nil {
+		if patch.URL, err = uc.download.SignPatchURL(*patch, req.DeviceID); err != nil {
+			return entity.UpdateCheck{}, err
+		}
+	}
+
+	check, err := uc.signed(req, entity.UpdateCheck{
+		UpdateAvailable:           true,
+		OTA:                       &latest,
+		Patch:                     patch,
+		Artifacts:                 artifacts,
+		Mirrors:                   mirrors,
+		Changelog:                 changelog,
+		DangerousPermissionsAdded: dangerousPermissionsAdded(latest, history),
+	})
+	if err != nil {
+		return entity.UpdateCheck{}, err
+	}
+
+	// Devices learn the signing certificate from the signed manifest only;
+	// who reviewed a release is for the admin API
+	latest.SigningCertSHA256 = ""
+	latest.PermissionsAcknowledgedAt = nil
+	latest.PermissionsAcknowledgedBy = ""
+
+	return check, nil
+}
+
+// signed attaches the signed manifest to a check when manifests are signed.
+func (uc *UpdateUseCase) signed(req entity.UpdateCheckRequest, check entity.UpdateCheck) (entity.UpdateCheck, error) {
+	if uc.manifests == nil {
+		return check, nil
+	}
+	if err := uc.manifests.Sign(req, &check); err != nil {
+		return entity.UpdateCheck{}, err
+	}
+	return check, nil
+}
+
+// dangerousPermissionsAdded returns the dangerous permissions that releases
+// since the device's version added and the offered release still requests.
+func dangerousPermissionsAdded(latest entity.OTA, history []entity.OTA) []string {
+	if len(history) == 0 {
+		return latest.DangerousPermissionsAdded
+	}
+
+	var added []string
+	for _, ota := range history {
+		for _, p := range ota.DangerousPermissionsAdded {
+			if slices.Contains(added, p) {
+				continue
+			}
+			if latest.Manifest != nil && !slices.Contains(latest.Manifest.Permissions, p) {
+				continue
+			}
+			added = append(added, p)
+		}
+	}
+	return added
+}
+
+// changelog returns the localized and rendered release notes of the releases
+// in history, the releases between the device's version and the offered one.
+func (uc *UpdateUseCase) changelog(ctx context.Context, req entity.UpdateCheckRequest, latest entity.OTA, history []entity.OTA) ([]entity.ChangelogEntry, error) {
+	if len(history) == 0 {
+		return nil, nil
+	}
+
+	changelog := make([]entity.ChangelogEntry, 0, len(history))
+	for _, ota := range history {
+		if ota.ID == latest.ID {
+			ota = latest
+		} else {
+			if err := uc.notes.Localize(ctx, &ota, req.Languages); err != nil {
+				return nil, err
+			}
+			if err := uc.notes.Render(&ota, req.NotesFormat); err != nil {
+				return nil, err
+			}
+		}
+
+		changelog = append(changelog, entity.ChangelogEntry{
+			OTAID:              ota.ID,
+			VersionName:        ota.VersionName,
+			VersionCode:        ota.VersionCode,
+			ReleaseNotes:       ota.ReleaseNotes,
+			ReleaseNotesLocale: ota.ReleaseNotesLocale,
+			CreatedAt:          ota.CreatedAt,
+		})
+	}
+
+	return changelog, nil
+}
@@ -1,5 +1,20 @@
 package usecase
 
 type UseCases struct {
-	OTA *OTAUseCase
+	OTA         *OTAUseCase
+	SigningCert *SigningCertUseCase
+	Delta       *DeltaUseCase
+	Update      *UpdateUseCase
+	Download    *DownloadUseCase
+	Retention   *RetentionUseCase
+	Artifact    *ArtifactUseCase
+	Mirror      *MirrorUseCase
+	ReleaseNote *ReleaseNoteUseCase
+	APIKey      *APIKeyUseCase
+	JWT         *JWTUseCase
+	Manifest    *ManifestUseCase
+	Device      *DeviceUseCase
+	TUF         *TUFUseCase
+	Audit       *AuditUseCase
+	RateLimit   *RateLimitUseCase
 } 
\ No newline at end of file
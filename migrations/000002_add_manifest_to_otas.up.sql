@@ -0,0 +1,4 @@
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS manifest JSONB;
+
+-- Allow querying releases by manifest fields such as permissions or SDK levels
+CREATE INDEX IF NOT EXISTS idx_otas_manifest ON otas USING GIN (manifest);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS app_signing_certs (
+    id VARCHAR(36) PRIMARY KEY,
+    app_id VARCHAR(255) NOT NULL,
+    cert_sha256 VARCHAR(64) NOT NULL,
+    parent_id VARCHAR(36) REFERENCES app_signing_certs(id),
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    retired_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes
+CREATE INDEX idx_app_signing_certs_app_id ON app_signing_certs(app_id);
+CREATE UNIQUE INDEX idx_app_signing_certs_app_id_cert ON app_signing_certs(app_id, cert_sha256);
+CREATE UNIQUE INDEX idx_app_signing_certs_one_active ON app_signing_certs(app_id) WHERE status = 'active';
+CREATE UNIQUE INDEX idx_app_signing_certs_one_pending ON app_signing_certs(app_id) WHERE status = 'pending';
+
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS signing_cert_sha256 VARCHAR(64);
@@ -0,0 +1,23 @@
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS sha256 VARCHAR(64);
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS size_bytes BIGINT;
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS storage_key TEXT;
+
+CREATE TABLE IF NOT EXISTS ota_patches (
+    id VARCHAR(36) PRIMARY KEY,
+    ota_id VARCHAR(36) NOT NULL REFERENCES otas(id) ON DELETE CASCADE,
+    from_ota_id VARCHAR(36) NOT NULL REFERENCES otas(id) ON DELETE CASCADE,
+    from_version_code INTEGER NOT NULL,
+    from_sha256 VARCHAR(64) NOT NULL,
+    to_sha256 VARCHAR(64) NOT NULL,
+    sha256 VARCHAR(64),
+    size_bytes BIGINT,
+    url TEXT,
+    storage_key TEXT,
+    status VARCHAR(16) NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_ota_patches_ota_id_from_ota_id ON ota_patches(ota_id, from_ota_id);
+CREATE INDEX idx_ota_patches_ota_id_from_sha256 ON ota_patches(ota_id, from_sha256);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS ota_download_stats (
+    ota_id VARCHAR(36) PRIMARY KEY REFERENCES otas(id) ON DELETE CASCADE,
+    requests BIGINT NOT NULL DEFAULT 0,
+    completed_downloads BIGINT NOT NULL DEFAULT 0,
+    bytes_served BIGINT NOT NULL DEFAULT 0,
+    last_served_at TIMESTAMP WITH TIME ZONE
+);
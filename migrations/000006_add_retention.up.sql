@@ -0,0 +1,23 @@
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published';
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS app_retention_policies (
+    app_id VARCHAR(255) PRIMARY KEY,
+    keep_last INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS device_installs (
+    device_id VARCHAR(255) NOT NULL,
+    app_id VARCHAR(255) NOT NULL,
+    version_code INTEGER NOT NULL,
+    sha256 VARCHAR(64),
+    reported_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (device_id, app_id)
+);
+
+-- Create indexes
+CREATE INDEX idx_otas_app_id_status_version_code ON otas(app_id, status, version_code);
+CREATE INDEX idx_device_installs_app_id_version_code ON device_installs(app_id, version_code, reported_at);
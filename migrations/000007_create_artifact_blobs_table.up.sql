@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS artifact_blobs (
+    sha256 VARCHAR(64) PRIMARY KEY,
+    storage_key TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    ref_count INTEGER NOT NULL CHECK (ref_count >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Adopt artifacts stored before deduplication, one blob per distinct digest
+INSERT INTO artifact_blobs (sha256, storage_key, size_bytes, ref_count, created_at)
+SELECT sha256, MIN(storage_key), MAX(size_bytes), COUNT(*), MIN(created_at)
+FROM (
+    SELECT sha256, storage_key, size_bytes, created_at FROM otas WHERE storage_key IS NOT NULL AND sha256 IS NOT NULL
+    UNION ALL
+    SELECT sha256, storage_key, size_bytes, created_at FROM ota_patches WHERE storage_key IS NOT NULL AND sha256 IS NOT NULL
+) AS stored
+GROUP BY sha256
+ON CONFLICT (sha256) DO NOTHING;
+
+-- Copies of an adopted file stored under other keys are no longer referenced
+-- once every row points at the adopted copy. Their keys are kept here so the
+-- files can be deleted from the artifact storage, after which the rows can be
+-- dropped:
+--   SELECT storage_key FROM orphaned_artifact_files;
+CREATE TABLE IF NOT EXISTS orphaned_artifact_files (
+    storage_key TEXT PRIMARY KEY,
+    orphaned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO orphaned_artifact_files (storage_key)
+SELECT DISTINCT stored.storage_key
+FROM (
+    SELECT sha256, storage_key FROM otas WHERE storage_key IS NOT NULL AND sha256 IS NOT NULL
+    UNION
+    SELECT sha256, storage_key FROM ota_patches WHERE storage_key IS NOT NULL AND sha256 IS NOT NULL
+) AS stored
+JOIN artifact_blobs b ON b.sha256 = stored.sha256
+WHERE stored.storage_key <> b.storage_key
+ON CONFLICT (storage_key) DO NOTHING;
+
+-- Point every release and patch at the adopted copy
+UPDATE otas SET storage_key = b.storage_key FROM artifact_blobs b WHERE otas.sha256 = b.sha256 AND otas.storage_key IS NOT NULL;
+UPDATE ota_patches SET storage_key = b.storage_key FROM artifact_blobs b WHERE ota_patches.sha256 = b.sha256 AND ota_patches.storage_key IS NOT NULL;
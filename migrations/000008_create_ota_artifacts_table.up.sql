@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS ota_artifacts (
+    id VARCHAR(36) PRIMARY KEY,
+    ota_id VARCHAR(36) NOT NULL REFERENCES otas(id) ON DELETE CASCADE,
+    split_name VARCHAR(255) NOT NULL DEFAULT '',
+    abi VARCHAR(32) NOT NULL DEFAULT '',
+    density INTEGER NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_ota_artifacts_ota_id_config ON ota_artifacts(ota_id, split_name, abi, density);
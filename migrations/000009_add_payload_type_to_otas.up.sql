@@ -0,0 +1,17 @@
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS payload_type VARCHAR(32) NOT NULL DEFAULT 'apk';
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+-- Version codes are unique per payload type of an app
+DROP INDEX IF EXISTS idx_otas_app_id_version_code;
+CREATE UNIQUE INDEX idx_otas_app_id_payload_type_version_code ON otas(app_id, payload_type, version_code);
+
+DROP INDEX IF EXISTS idx_otas_app_id_status_version_code;
+CREATE INDEX idx_otas_app_id_payload_type_status_version_code ON otas(app_id, payload_type, status, version_code);
+
+-- Devices report installs per payload type
+ALTER TABLE device_installs ADD COLUMN IF NOT EXISTS payload_type VARCHAR(32) NOT NULL DEFAULT 'apk';
+ALTER TABLE device_installs DROP CONSTRAINT IF EXISTS device_installs_pkey;
+ALTER TABLE device_installs ADD PRIMARY KEY (device_id, app_id, payload_type);
+
+DROP INDEX IF EXISTS idx_device_installs_app_id_version_code;
+CREATE INDEX idx_device_installs_app_id_payload_type_version_code ON device_installs(app_id, payload_type, version_code, reported_at);
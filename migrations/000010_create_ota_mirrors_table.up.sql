@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS ota_mirrors (
+    id VARCHAR(36) PRIMARY KEY,
+    ota_id VARCHAR(36) NOT NULL REFERENCES otas(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    healthy BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_checked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_ota_mirrors_ota_id_url ON ota_mirrors(ota_id, url);
+CREATE INDEX idx_ota_mirrors_ota_id_priority ON ota_mirrors(ota_id, priority);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS ota_release_notes (
+    ota_id VARCHAR(36) NOT NULL REFERENCES otas(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    notes TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (ota_id, locale)
+);
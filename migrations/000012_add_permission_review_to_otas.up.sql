@@ -0,0 +1,3 @@
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS dangerous_permissions_added JSONB;
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS permissions_acknowledged_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE otas ADD COLUMN IF NOT EXISTS permissions_acknowledged_by VARCHAR(255);
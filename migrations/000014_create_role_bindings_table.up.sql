@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS role_bindings (
+    id VARCHAR(36) PRIMARY KEY,
+    api_key_id VARCHAR(36) NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL,
+    app_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+-- An empty app_id grants the role for every app
+CREATE UNIQUE INDEX idx_role_bindings_key_role_app ON role_bindings(api_key_id, role, app_id);
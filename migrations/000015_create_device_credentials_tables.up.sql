@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS enrollment_tokens (
+    id VARCHAR(36) PRIMARY KEY,
+    app_id VARCHAR(255) NOT NULL DEFAULT '',
+    prefix VARCHAR(16) NOT NULL,
+    salt VARCHAR(32) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    used_by_device_id VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS device_credentials (
+    id VARCHAR(36) PRIMARY KEY,
+    device_id VARCHAR(255) NOT NULL,
+    app_id VARCHAR(255) NOT NULL DEFAULT '',
+    enrollment_token_id VARCHAR(36) REFERENCES enrollment_tokens(id) ON DELETE SET NULL,
+    prefix VARCHAR(16) NOT NULL,
+    salt VARCHAR(32) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_enrollment_tokens_prefix ON enrollment_tokens(prefix);
+CREATE UNIQUE INDEX idx_device_credentials_prefix ON device_credentials(prefix);
+-- A device holds at most one credential that is not revoked
+CREATE UNIQUE INDEX idx_device_credentials_active_device ON device_credentials(device_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_device_credentials_app_id ON device_credentials(app_id);
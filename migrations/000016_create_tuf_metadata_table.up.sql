@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS tuf_metadata (
+    app_id VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    version INTEGER NOT NULL,
+    body_sha256 VARCHAR(64) NOT NULL,
+    content TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (app_id, role, version)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(16) NOT NULL,
+    resource_type VARCHAR(32) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    app_id VARCHAR(255) NOT NULL DEFAULT '',
+    actor_id VARCHAR(255) NOT NULL DEFAULT '',
+    actor_name VARCHAR(255) NOT NULL DEFAULT '',
+    actor_method VARCHAR(16) NOT NULL DEFAULT '',
+    source_ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    changes JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes; entries are listed newest first
+CREATE INDEX idx_audit_log_app_id ON audit_log(app_id, id DESC);
+CREATE INDEX idx_audit_log_resource ON audit_log(resource_type, resource_id, id DESC);
+CREATE INDEX idx_audit_log_actor_id ON audit_log(actor_id, id DESC);
+CREATE INDEX idx_audit_log_request_id ON audit_log(request_id);
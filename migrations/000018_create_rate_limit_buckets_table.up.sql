@@ -0,0 +1,10 @@
+-- Token buckets shared by every API instance. Losing them in a crash only
+-- refills them, so they skip the write-ahead log.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(512) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
@@ -0,0 +1,13 @@
+-- Stored artifacts are no longer served statically under /artifacts. Point
+-- their URLs at the API download endpoints, keeping the public base URL.
+UPDATE otas
+SET url = substring(url FROM '^(.*?)/artifacts/') || '/api/v1/otas/' || id || '/download'
+WHERE storage_key IS NOT NULL AND url ~ '/artifacts/' AND url !~ '/api/v1/otas/';
+
+UPDATE ota_artifacts
+SET url = substring(url FROM '^(.*?)/artifacts/') || '/api/v1/otas/' || ota_id || '/artifacts/' || id || '/download'
+WHERE url ~ '/artifacts/' AND url !~ '/api/v1/otas/';
+
+UPDATE ota_patches
+SET url = substring(url FROM '^(.*?)/artifacts/') || '/api/v1/otas/' || ota_id || '/patches/' || id || '/download'
+WHERE storage_key IS NOT NULL AND url ~ '/artifacts/' AND url !~ '/api/v1/otas/';
@@ -0,0 +1,6 @@
+-- Patches are generated from the rows themselves, so pending work survives
+-- restarts and failed patches are retried with backoff.
+ALTER TABLE ota_patches ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ota_patches ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_ota_patches_due ON ota_patches(next_attempt_at) WHERE status IN ('pending', 'failed');
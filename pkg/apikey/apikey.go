@@ -0,0 +1,86 @@
+// Package apikey generates API keys and other bearer secrets and verifies
+// them against salted hashes.
+//
+// A key reads "<scheme>_<prefix>_<secret>", the scheme telling kinds of keys
+// apart. The prefix identifies the key so its hash can be looked up; only the
+// salted SHA-256 of the secret is stored.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Schemes of the keys issued by this service.
+const (
+	SchemeAPIKey          = "lbk"
+	SchemeEnrollmentToken = "lbe"
+	SchemeDevice          = "lbd"
+)
+
+var ErrMalformed = errors.New("malformed API key")
+
+// Key is a newly generated API key. Token is shown to its owner once.
+type Key struct {
+	Prefix string
+	Salt   string
+	Hash   string
+	Token  string
+}
+
+// Generate creates a random key of the given scheme and its salted hash.
+func Generate(scheme string) (Key, error) {
+	prefix, err := randomString(6)
+	if err != nil {
+		return Key{}, err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return Key{}, err
+	}
+	salt, err := randomString(16)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{
+		Prefix: prefix,
+		Salt:   salt,
+		Hash:   Hash(salt, secret),
+		Token:  scheme + "_" + prefix + "_" + secret,
+	}, nil
+}
+
+// Parse splits a token of the given scheme into its prefix and secret.
+func Parse(scheme string, token string) (prefix string, secret string, err error) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != scheme || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrMalformed
+	}
+	return parts[1], parts[2], nil
+}
+
+// Hash returns the salted hash of a secret.
+func Hash(salt string, secret string) string {
+	sum := sha256.Sum256([]byte(salt + "\x00" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether secret matches the salted hash, in constant time.
+func Verify(salt string, hash string, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(salt, secret)), []byte(hash)) == 1
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// Underscores separate the parts of a token, so map them away
+	return strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(b), "_", "-"), nil
+}
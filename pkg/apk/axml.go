@@ -0,0 +1,263 @@
+package apk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Chunk types used by the Android binary XML format.
+const (
+	chunkStringPool   = 0x0001
+	chunkXML          = 0x0003
+	chunkXMLStartNS   = 0x0100
+	chunkXMLEndNS     = 0x0101
+	chunkXMLStartElem = 0x0102
+	chunkXMLEndElem   = 0x0103
+	chunkXMLCData     = 0x0104
+	chunkXMLResMap    = 0x0180
+)
+
+// Typed value data types.
+const (
+	typeReference = 0x01
+	typeString    = 0x03
+	typeIntDec    = 0x10
+	typeIntHex    = 0x11
+	typeIntBool   = 0x12
+)
+
+const noIndex = 0xFFFFFFFF
+
+var errMalformed = errors.New("malformed binary xml")
+
+type xmlAttr struct {
+	Name     string
+	ResID    uint32
+	Raw      string
+	DataType uint8
+	Data     uint32
+}
+
+type xmlElement struct {
+	Name  string
+	Depth int
+	Attrs []xmlAttr
+}
+
+// stringValue returns the attribute as a string, preferring the raw value.
+func (a xmlAttr) stringValue(pool []string) (string, bool) {
+	if a.Raw != "" {
+		return a.Raw, true
+	}
+	switch a.DataType {
+	case typeString:
+		if int(a.Data) < len(pool) {
+			return pool[a.Data], true
+		}
+	case typeIntDec, typeIntHex:
+		return fmt.Sprintf("%d", int32(a.Data)), true
+	}
+	return "", false
+}
+
+// intValue returns the attribute as an integer when it is encoded as one.
+func (a xmlAttr) intValue() (int, bool) {
+	switch a.DataType {
+	case typeIntDec, typeIntHex, typeIntBool:
+		return int(int32(a.Data)), true
+	}
+	return 0, false
+}
+
+// parseBinaryXML walks an AXML document and returns its elements in document
+// order together with the document string pool.
+func parseBinaryXML(data []byte) ([]xmlElement, []string, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint16(data[0:]) != chunkXML {
+		return nil, nil, errMalformed
+	}
+
+	headerSize := int(binary.LittleEndian.Uint16(data[2:]))
+	total := int(binary.LittleEndian.Uint32(data[4:]))
+	if total > len(data) || headerSize > total {
+		return nil, nil, errMalformed
+	}
+
+	var (
+		pool     []string
+		resMap   []uint32
+		elements []xmlElement
+		depth    int
+	)
+
+	for off := headerSize; off+8 <= total; {
+		chunkType := binary.LittleEndian.Uint16(data[off:])
+		chunkHeader := int(binary.LittleEndian.Uint16(data[off+2:]))
+		chunkSize := int(binary.LittleEndian.Uint32(data[off+4:]))
+		if chunkSize < 8 || off+chunkSize > total || chunkHeader > chunkSize {
+			return nil, nil, errMalformed
+		}
+		chunk := data[off : off+chunkSize]
+
+		switch chunkType {
+		case chunkStringPool:
+			p, err := parseStringPool(chunk)
+			if err != nil {
+				return nil, nil, err
+			}
+			pool = p
+		case chunkXMLResMap:
+			for i := chunkHeader; i+4 <= chunkSize; i += 4 {
+				resMap = append(resMap, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case chunkXMLStartElem:
+			elem, err := parseStartElement(chunk, chunkHeader, pool, resMap)
+			if err != nil {
+				return nil, nil, err
+			}
+			depth++
+			elem.Depth = depth
+			elements = append(elements, elem)
+		case chunkXMLEndElem:
+			depth--
+		case chunkXMLStartNS, chunkXMLEndNS, chunkXMLCData:
+			// Not needed for manifest extraction.
+		}
+
+		off += chunkSize
+	}
+
+	return elements, pool, nil
+}
+
+func parseStartElement(chunk []byte, headerSize int, pool []string, resMap []uint32) (xmlElement, error) {
+	ext := chunk[headerSize:]
+	if len(ext) < 20 {
+		return xmlElement{}, errMalformed
+	}
+
+	elem := xmlElement{Name: poolString(pool, binary.LittleEndian.Uint32(ext[4:]))}
+	attrStart := int(binary.LittleEndian.Uint16(ext[8:]))
+	attrSize := int(binary.LittleEndian.Uint16(ext[10:]))
+	attrCount := int(binary.LittleEndian.Uint16(ext[12:]))
+	if attrSize < 20 || attrStart+attrCount*attrSize > len(ext) {
+		return xmlElement{}, errMalformed
+	}
+
+	for i := 0; i < attrCount; i++ {
+		a := ext[attrStart+i*attrSize:]
+		nameIdx := binary.LittleEndian.Uint32(a[4:])
+		attr := xmlAttr{
+			Name:     poolString(pool, nameIdx),
+			Raw:      poolString(pool, binary.LittleEndian.Uint32(a[8:])),
+			DataType: a[15],
+			Data:     binary.LittleEndian.Uint32(a[16:]),
+		}
+		if int(nameIdx) < len(resMap) {
+			attr.ResID = resMap[nameIdx]
+		}
+		elem.Attrs = append(elem.Attrs, attr)
+	}
+
+	return elem, nil
+}
+
+func parseStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errMalformed
+	}
+
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	isUTF8 := flags&(1<<8) != 0
+
+	if headerSize+count*4 > len(chunk) || stringsStart > len(chunk) {
+		return nil, errMalformed
+	}
+
+	pool := make([]string, count)
+	for i := 0; i < count; i++ {
+		off := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		if off >= len(chunk) {
+			return nil, errMalformed
+		}
+		var (
+			s   string
+			err error
+		)
+		if isUTF8 {
+			s, err = decodeUTF8String(chunk[off:])
+		} else {
+			s, err = decodeUTF16String(chunk[off:])
+		}
+		if err != nil {
+			return nil, err
+		}
+		pool[i] = s
+	}
+
+	return pool, nil
+}
+
+func decodeUTF8String(b []byte) (string, error) {
+	// The UTF-16 length comes first and is skipped, followed by the byte length.
+	_, n, ok := decodeUTF8Length(b)
+	if !ok {
+		return "", errMalformed
+	}
+	size, m, ok := decodeUTF8Length(b[n:])
+	if !ok {
+		return "", errMalformed
+	}
+	start := n + m
+	if start+size > len(b) {
+		return "", errMalformed
+	}
+	return string(b[start : start+size]), nil
+}
+
+func decodeUTF8Length(b []byte) (int, int, bool) {
+	if len(b) < 1 {
+		return 0, 0, false
+	}
+	if b[0]&0x80 == 0 {
+		return int(b[0]), 1, true
+	}
+	if len(b) < 2 {
+		return 0, 0, false
+	}
+	return int(b[0]&0x7F)<<8 | int(b[1]), 2, true
+}
+
+func decodeUTF16String(b []byte) (string, error) {
+	if len(b) < 2 {
+		return "", errMalformed
+	}
+	size := int(binary.LittleEndian.Uint16(b))
+	start := 2
+	if size&0x8000 != 0 {
+		if len(b) < 4 {
+			return "", errMalformed
+		}
+		size = (size&0x7FFF)<<16 | int(binary.LittleEndian.Uint16(b[2:]))
+		start = 4
+	}
+	if start+size*2 > len(b) {
+		return "", errMalformed
+	}
+	units := make([]uint16, size)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[start+i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+func poolString(pool []string, idx uint32) string {
+	if idx == noIndex || int(idx) >= len(pool) {
+		return ""
+	}
+	return pool[idx]
+}
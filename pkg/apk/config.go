@@ -0,0 +1,67 @@
+package apk
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Screen densities by their resource qualifier, in dots per inch.
+var densities = map[string]int{
+	"ldpi":    120,
+	"mdpi":    160,
+	"tvdpi":   213,
+	"hdpi":    240,
+	"xhdpi":   320,
+	"xxhdpi":  480,
+	"xxxhdpi": 640,
+}
+
+// ParseDensity accepts a density qualifier such as "xxhdpi" or a dpi value
+// such as "480". An empty string yields zero, meaning any density.
+func ParseDensity(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	if dpi, ok := densities[s]; ok {
+		return dpi, nil
+	}
+
+	dpi, err := strconv.Atoi(strings.TrimSuffix(s, "dpi"))
+	if err != nil || dpi <= 0 {
+		return 0, fmt.Errorf("invalid screen density %q", s)
+	}
+	return dpi, nil
+}
+
+// NativeABIs lists the ABIs the APK ships native libraries for, taken from
+// its lib/<abi>/ directories.
+func NativeABIs(r io.ReaderAt, size int64) ([]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open apk: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range zr.File {
+		rest, ok := strings.CutPrefix(f.Name, "lib/")
+		if !ok {
+			continue
+		}
+		if abi, _, ok := strings.Cut(rest, "/"); ok && abi != "" {
+			seen[abi] = true
+		}
+	}
+
+	abis := make([]string, 0, len(seen))
+	for abi := range seen {
+		abis = append(abis, abi)
+	}
+	sort.Strings(abis)
+
+	return abis, nil
+}
@@ -0,0 +1,353 @@
+package apk
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	digestOIDs = map[string]crypto.Hash{
+		"1.3.14.3.2.26":          crypto.SHA1,
+		"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+		"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+		"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	}
+
+	// Digest attribute prefixes used in MANIFEST.MF and .SF files.
+	jarDigestNames = map[string]crypto.Hash{
+		"SHA1":    crypto.SHA1,
+		"SHA-1":   crypto.SHA1,
+		"SHA-256": crypto.SHA256,
+		"SHA-384": crypto.SHA384,
+		"SHA-512": crypto.SHA512,
+	}
+)
+
+// verifyJARSignature verifies a v1 (JAR) signature: the PKCS#7 block signs the
+// .SF file, which digests MANIFEST.MF, which in turn digests every entry.
+func verifyJARSignature(r io.ReaderAt, size int64) (*SigningInfo, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open apk: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	var blockFiles []*zip.File
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if isSignatureBlockFile(f.Name) {
+			blockFiles = append(blockFiles, f)
+		}
+	}
+
+	if len(blockFiles) == 0 {
+		return nil, ErrNotSigned
+	}
+	if len(blockFiles) > 1 {
+		return nil, fmt.Errorf("%w: multiple signers are not supported", ErrInvalidSignature)
+	}
+
+	blockFile := blockFiles[0]
+	sfName := blockFile.Name[:strings.LastIndex(blockFile.Name, ".")] + ".SF"
+	sfFile, ok := files[sfName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidSignature, sfName)
+	}
+	mfFile, ok := files["META-INF/MANIFEST.MF"]
+	if !ok {
+		return nil, fmt.Errorf("%w: MANIFEST.MF is missing", ErrInvalidSignature)
+	}
+
+	block, err := zipEntry(blockFile)
+	if err != nil {
+		return nil, err
+	}
+	sf, err := zipEntry(sfFile)
+	if err != nil {
+		return nil, err
+	}
+	mf, err := zipEntry(mfFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := verifyPKCS7(block, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignatureFile(sf, mf); err != nil {
+		return nil, err
+	}
+
+	if err := verifyManifestEntries(mf, files); err != nil {
+		return nil, err
+	}
+
+	return &SigningInfo{Scheme: SchemeV1, CertSHA256: CertSHA256(cert.Raw)}, nil
+}
+
+// verifyPKCS7 checks a detached PKCS#7 SignedData signature over content and
+// returns the signer's certificate.
+func verifyPKCS7(der []byte, content []byte) (*certificate, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, reason)
+	}
+
+	var contentInfo struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,tag:0"`
+	}
+	if _, err := asn1.Unmarshal(der, &contentInfo); err != nil || !contentInfo.ContentType.Equal(oidSignedData) {
+		return nil, invalid("signature block is not PKCS#7 signed data")
+	}
+
+	var signedData asn1.RawValue
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+		return nil, invalid("malformed signed data")
+	}
+	fields, err := sequenceElements(signedData.Bytes)
+	if err != nil || len(fields) < 4 {
+		return nil, invalid("malformed signed data")
+	}
+
+	// version, digestAlgorithms, encapContentInfo, [0] certificates, [1] crls, signerInfos
+	var certs []*certificate
+	for _, f := range fields[3 : len(fields)-1] {
+		if f.Class != asn1.ClassContextSpecific || f.Tag != 0 {
+			continue
+		}
+		raws, err := sequenceElements(f.Bytes)
+		if err != nil {
+			return nil, invalid("malformed certificates")
+		}
+		for _, raw := range raws {
+			cert, err := parseCertificate(raw.FullBytes)
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+			certs = append(certs, cert)
+		}
+	}
+
+	signerInfos, err := sequenceElements(fields[len(fields)-1].Bytes)
+	if err != nil || len(signerInfos) != 1 {
+		return nil, invalid("expected exactly one signer")
+	}
+	si, err := sequenceElements(signerInfos[0].Bytes)
+	if err != nil || len(si) < 5 {
+		return nil, invalid("malformed signer info")
+	}
+
+	// version, issuerAndSerialNumber, digestAlgorithm, [0] signedAttrs, signatureAlgorithm, signature
+	sid, err := sequenceElements(si[1].Bytes)
+	if err != nil || len(sid) != 2 {
+		return nil, invalid("unsupported signer identifier")
+	}
+	serial := twosComplement(sid[1].Bytes)
+
+	var signer *certificate
+	for _, c := range certs {
+		if bytes.Equal(c.Issuer, sid[0].FullBytes) && c.Serial.Cmp(serial) == 0 {
+			signer = c
+			break
+		}
+	}
+	if signer == nil {
+		return nil, invalid("signer certificate not found")
+	}
+
+	var digestAlg struct {
+		Algorithm asn1.ObjectIdentifier
+		Params    asn1.RawValue `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(si[2].FullBytes, &digestAlg); err != nil {
+		return nil, invalid("malformed digest algorithm")
+	}
+	h, ok := digestOIDs[digestAlg.Algorithm.String()]
+	if !ok {
+		return nil, invalid("unsupported digest algorithm " + digestAlg.Algorithm.String())
+	}
+
+	rest := si[3:]
+	signed := content
+	if rest[0].Class == asn1.ClassContextSpecific && rest[0].Tag == 0 {
+		if err := checkMessageDigest(rest[0].Bytes, h, content); err != nil {
+			return nil, err
+		}
+		// Signed attributes are signed with their universal SET tag.
+		signed = append([]byte{0x31}, rest[0].FullBytes[1:]...)
+		rest = rest[1:]
+	}
+	if len(rest) < 2 {
+		return nil, invalid("malformed signer info")
+	}
+
+	if err := verifyWithKey(signer.PublicKey, h, false, signed, rest[1].Bytes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return signer, nil
+}
+
+func checkMessageDigest(attrs []byte, h crypto.Hash, content []byte) error {
+	elems, err := sequenceElements(attrs)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signed attributes", ErrInvalidSignature)
+	}
+
+	for _, e := range elems {
+		var attr struct {
+			Type   asn1.ObjectIdentifier
+			Values asn1.RawValue `asn1:"set"`
+		}
+		if _, err := asn1.Unmarshal(e.FullBytes, &attr); err != nil || !attr.Type.Equal(oidMessageDigest) {
+			continue
+		}
+		var digest []byte
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+			return fmt.Errorf("%w: malformed message digest", ErrInvalidSignature)
+		}
+		hh := h.New()
+		hh.Write(content)
+		if !bytes.Equal(hh.Sum(nil), digest) {
+			return fmt.Errorf("%w: signature file digest mismatch", ErrInvalidSignature)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: message digest attribute missing", ErrInvalidSignature)
+}
+
+// verifySignatureFile checks the whole-manifest digest recorded in the .SF file.
+func verifySignatureFile(sf []byte, mf []byte) error {
+	main := parseManifestSections(sf)
+	if len(main) == 0 {
+		return fmt.Errorf("%w: empty signature file", ErrInvalidSignature)
+	}
+
+	for name, value := range main[0].attrs {
+		if !strings.HasSuffix(name, "-Digest-Manifest") {
+			continue
+		}
+		h, ok := jarDigestNames[strings.TrimSuffix(name, "-Digest-Manifest")]
+		if !ok {
+			continue
+		}
+		if digestMatches(h, mf, value) {
+			return nil
+		}
+		return fmt.Errorf("%w: MANIFEST.MF digest mismatch", ErrInvalidSignature)
+	}
+
+	return fmt.Errorf("%w: signature file has no supported manifest digest", ErrInvalidSignature)
+}
+
+// verifyManifestEntries checks every zip entry against its MANIFEST.MF digest.
+func verifyManifestEntries(mf []byte, files map[string]*zip.File) error {
+	covered := make(map[string]bool)
+
+	for _, section := range parseManifestSections(mf)[1:] {
+		name := section.attrs["Name"]
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("%w: manifest lists missing entry %s", ErrInvalidSignature, name)
+		}
+
+		verified := false
+		for attr, value := range section.attrs {
+			h, ok := jarDigestNames[strings.TrimSuffix(attr, "-Digest")]
+			if !ok || !strings.HasSuffix(attr, "-Digest") {
+				continue
+			}
+			data, err := zipEntry(f)
+			if err != nil {
+				return err
+			}
+			if !digestMatches(h, data, value) {
+				return fmt.Errorf("%w: digest mismatch for %s", ErrInvalidSignature, name)
+			}
+			verified = true
+			break
+		}
+		if !verified {
+			return fmt.Errorf("%w: no supported digest for %s", ErrInvalidSignature, name)
+		}
+		covered[name] = true
+	}
+
+	for name := range files {
+		if strings.HasSuffix(name, "/") || strings.HasPrefix(name, "META-INF/") {
+			continue
+		}
+		if !covered[name] {
+			return fmt.Errorf("%w: entry %s is not signed", ErrInvalidSignature, name)
+		}
+	}
+
+	return nil
+}
+
+func digestMatches(h crypto.Hash, data []byte, encoded string) bool {
+	want, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	hh := h.New()
+	hh.Write(data)
+	return bytes.Equal(hh.Sum(nil), want)
+}
+
+type manifestSection struct {
+	attrs map[string]string
+}
+
+// parseManifestSections splits a JAR manifest into its blank-line separated
+// sections, joining continuation lines.
+func parseManifestSections(data []byte) []manifestSection {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var sections []manifestSection
+	current := manifestSection{attrs: map[string]string{}}
+	var lastKey string
+
+	flush := func() {
+		if len(current.attrs) > 0 || len(sections) == 0 {
+			sections = append(sections, current)
+		}
+		current = manifestSection{attrs: map[string]string{}}
+		lastKey = ""
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, " ") && lastKey != "":
+			current.attrs[lastKey] += line[1:]
+		default:
+			if i := strings.Index(line, ": "); i > 0 {
+				lastKey = line[:i]
+				current.attrs[lastKey] = line[i+2:]
+			}
+		}
+	}
+	if len(current.attrs) > 0 {
+		flush()
+	}
+
+	return sections
+}
@@ -0,0 +1,132 @@
+// Package apk reads metadata out of Android application packages.
+package apk
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Android framework resource IDs of the manifest attributes we read. Obfuscated
+// builds may strip attribute names, so these are preferred when present.
+const (
+	resName             = 0x01010003
+	resMinSdkVersion    = 0x0101020c
+	resVersionCode      = 0x0101021b
+	resVersionName      = 0x0101021c
+	resTargetSdkVersion = 0x01010270
+)
+
+var ErrManifestNotFound = errors.New("AndroidManifest.xml not found in apk")
+
+// Manifest holds the fields extracted from an APK's AndroidManifest.xml.
+type Manifest struct {
+	PackageName string
+	VersionCode int
+	VersionName string
+	MinSdk      int
+	TargetSdk   int
+	Permissions []string
+	// Split is the name of the split APK, empty for a base or standalone APK.
+	Split string
+}
+
+// ParseManifest opens the APK and decodes its binary AndroidManifest.xml.
+func ParseManifest(r io.ReaderAt, size int64) (*Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open apk: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if f.Name != "AndroidManifest.xml" {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open manifest: %w", err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		return decodeManifest(data)
+	}
+
+	return nil, ErrManifestNotFound
+}
+
+func decodeManifest(data []byte) (*Manifest, error) {
+	elements, pool, err := parseBinaryXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(elements) == 0 || elements[0].Name != "manifest" {
+		return nil, fmt.Errorf("failed to parse manifest: missing <manifest> root")
+	}
+
+	m := &Manifest{}
+	for _, elem := range elements {
+		switch {
+		case elem.Name == "manifest" && elem.Depth == 1:
+			for _, a := range elem.Attrs {
+				switch {
+				case a.Name == "package":
+					m.PackageName, _ = a.stringValue(pool)
+				case a.ResID == resVersionCode || a.Name == "versionCode":
+					m.VersionCode = attrInt(a, pool)
+				case a.ResID == resVersionName || a.Name == "versionName":
+					m.VersionName, _ = a.stringValue(pool)
+				case a.Name == "split":
+					m.Split, _ = a.stringValue(pool)
+				}
+			}
+		case elem.Name == "uses-sdk" && elem.Depth == 2:
+			for _, a := range elem.Attrs {
+				switch {
+				case a.ResID == resMinSdkVersion || a.Name == "minSdkVersion":
+					m.MinSdk = attrInt(a, pool)
+				case a.ResID == resTargetSdkVersion || a.Name == "targetSdkVersion":
+					m.TargetSdk = attrInt(a, pool)
+				}
+			}
+		case (elem.Name == "uses-permission" || elem.Name == "uses-permission-sdk-23") && elem.Depth == 2:
+			for _, a := range elem.Attrs {
+				if a.ResID == resName || a.Name == "name" {
+					if name, ok := a.stringValue(pool); ok && name != "" {
+						m.Permissions = append(m.Permissions, name)
+					}
+				}
+			}
+		}
+	}
+
+	if m.PackageName == "" {
+		return nil, fmt.Errorf("failed to parse manifest: package name is missing")
+	}
+	if m.VersionCode <= 0 {
+		return nil, fmt.Errorf("failed to parse manifest: version code is missing")
+	}
+	if m.TargetSdk == 0 {
+		m.TargetSdk = m.MinSdk
+	}
+
+	return m, nil
+}
+
+func attrInt(a xmlAttr, pool []string) int {
+	if v, ok := a.intValue(); ok {
+		return v
+	}
+	if s, ok := a.stringValue(pool); ok {
+		if v, err := strconv.Atoi(s); err == nil {
+			return v
+		}
+	}
+	return 0
+}
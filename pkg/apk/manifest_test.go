@@ -0,0 +1,321 @@
+package apk
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// axmlNode is an element of a binary XML fixture.
+type axmlNode struct {
+	name     string
+	attrs    []axmlAttr
+	children []axmlNode
+}
+
+// axmlAttr is an attribute of a binary XML fixture. Attributes with a raw
+// value are encoded as strings, the others as typed values.
+type axmlAttr struct {
+	name  string
+	resID uint32
+	raw   string
+	typ   uint8
+	data  uint32
+}
+
+func strAttr(name string, resID uint32, value string) axmlAttr {
+	return axmlAttr{name: name, resID: resID, raw: value, typ: typeString}
+}
+
+func intAttr(name string, resID uint32, value uint32) axmlAttr {
+	return axmlAttr{name: name, resID: resID, typ: typeIntDec, data: value}
+}
+
+// encodeAXML encodes a document the way aapt2 does: attribute names with a
+// resource ID come first in the string pool, in the order of the resource map.
+func encodeAXML(root axmlNode, utf8 bool) []byte {
+	var (
+		pool   []string
+		resMap []uint32
+		index  = map[string]uint32{}
+		// Attribute names with a resource ID each have their own pool
+		// entry, even when obfuscation made them all empty.
+		attrIndex = map[uint32]uint32{}
+	)
+	intern := func(s string) uint32 {
+		if i, ok := index[s]; ok {
+			return i
+		}
+		index[s] = uint32(len(pool))
+		pool = append(pool, s)
+		return index[s]
+	}
+
+	var walk func(n axmlNode, visit func(axmlNode))
+	walk = func(n axmlNode, visit func(axmlNode)) {
+		visit(n)
+		for _, c := range n.children {
+			walk(c, visit)
+		}
+	}
+	walk(root, func(n axmlNode) {
+		for _, a := range n.attrs {
+			if _, ok := attrIndex[a.resID]; !ok && a.resID != 0 {
+				attrIndex[a.resID] = uint32(len(pool))
+				pool = append(pool, a.name)
+				resMap = append(resMap, a.resID)
+			}
+		}
+	})
+	walk(root, func(n axmlNode) {
+		intern(n.name)
+		for _, a := range n.attrs {
+			if a.resID == 0 {
+				intern(a.name)
+			}
+			if a.raw != "" {
+				intern(a.raw)
+			}
+		}
+	})
+
+	var body bytes.Buffer
+	body.Write(encodeStringPool(pool, utf8))
+
+	resChunk := chunk(chunkXMLResMap, 8, nil)
+	for _, id := range resMap {
+		resChunk = binary.LittleEndian.AppendUint32(resChunk, id)
+	}
+	body.Write(finishChunk(resChunk))
+
+	var emit func(n axmlNode)
+	emit = func(n axmlNode) {
+		start := chunk(chunkXMLStartElem, 16, []uint32{1, noIndex})
+		start = binary.LittleEndian.AppendUint32(start, noIndex)
+		start = binary.LittleEndian.AppendUint32(start, index[n.name])
+		for _, v := range []uint16{20, 20, uint16(len(n.attrs)), 0, 0, 0} {
+			start = binary.LittleEndian.AppendUint16(start, v)
+		}
+		for _, a := range n.attrs {
+			raw := uint32(noIndex)
+			data := a.data
+			if a.raw != "" {
+				raw = index[a.raw]
+				data = raw
+			}
+			name := index[a.name]
+			if a.resID != 0 {
+				name = attrIndex[a.resID]
+			}
+			start = binary.LittleEndian.AppendUint32(start, noIndex)
+			start = binary.LittleEndian.AppendUint32(start, name)
+			start = binary.LittleEndian.AppendUint32(start, raw)
+			start = append(start, 8, 0, 0, a.typ)
+			start = binary.LittleEndian.AppendUint32(start, data)
+		}
+		body.Write(finishChunk(start))
+
+		for _, c := range n.children {
+			emit(c)
+		}
+
+		end := chunk(chunkXMLEndElem, 16, []uint32{1, noIndex})
+		end = binary.LittleEndian.AppendUint32(end, noIndex)
+		end = binary.LittleEndian.AppendUint32(end, index[n.name])
+		body.Write(finishChunk(end))
+	}
+	emit(root)
+
+	doc := chunk(chunkXML, 8, nil)
+	doc = append(doc, body.Bytes()...)
+	return finishChunk(doc)
+}
+
+// chunk starts a chunk with the given header size; its size is filled in by
+// finishChunk.
+func chunk(typ uint16, headerSize uint16, header []uint32) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, typ)
+	b = binary.LittleEndian.AppendUint16(b, headerSize)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	for _, v := range header {
+		b = binary.LittleEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+func finishChunk(b []byte) []byte {
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(b)))
+	return b
+}
+
+func encodeStringPool(pool []string, utf8 bool) []byte {
+	var data []byte
+	offsets := make([]uint32, len(pool))
+	for i, s := range pool {
+		offsets[i] = uint32(len(data))
+		if utf8 {
+			data = append(data, byte(len(utf16.Encode([]rune(s)))), byte(len(s)))
+			data = append(data, s...)
+			data = append(data, 0)
+		} else {
+			units := utf16.Encode([]rune(s))
+			data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+			for _, u := range units {
+				data = binary.LittleEndian.AppendUint16(data, u)
+			}
+			data = binary.LittleEndian.AppendUint16(data, 0)
+		}
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	var flags uint32
+	if utf8 {
+		flags = 1 << 8
+	}
+	b := chunk(chunkStringPool, 28, []uint32{uint32(len(pool)), 0, flags, uint32(28 + 4*len(pool)), 0})
+	for _, off := range offsets {
+		b = binary.LittleEndian.AppendUint32(b, off)
+	}
+	b = append(b, data...)
+	return finishChunk(b)
+}
+
+// buildZip returns a zip archive of the given entries, in order.
+func buildZip(t *testing.T, entries ...zipFixture) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type zipFixture struct {
+	name string
+	data []byte
+}
+
+// launcherManifest is the manifest of a typical release.
+func launcherManifest() axmlNode {
+	return axmlNode{
+		name: "manifest",
+		attrs: []axmlAttr{
+			intAttr("versionCode", resVersionCode, 42),
+			strAttr("versionName", resVersionName, "4.2.0"),
+			strAttr("package", 0, "com.example.launcher"),
+		},
+		children: []axmlNode{
+			{name: "uses-sdk", attrs: []axmlAttr{
+				intAttr("minSdkVersion", resMinSdkVersion, 26),
+				intAttr("targetSdkVersion", resTargetSdkVersion, 34),
+			}},
+			{name: "uses-permission", attrs: []axmlAttr{strAttr("name", resName, "android.permission.INTERNET")}},
+			{name: "uses-permission-sdk-23", attrs: []axmlAttr{strAttr("name", resName, "android.permission.CAMERA")}},
+			{name: "application", children: []axmlNode{
+				// Only top-level declarations count.
+				{name: "uses-permission", attrs: []axmlAttr{strAttr("name", resName, "android.permission.NESTED")}},
+			}},
+		},
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	want := &Manifest{
+		PackageName: "com.example.launcher",
+		VersionCode: 42,
+		VersionName: "4.2.0",
+		MinSdk:      26,
+		TargetSdk:   34,
+		Permissions: []string{"android.permission.INTERNET", "android.permission.CAMERA"},
+	}
+
+	obfuscated := launcherManifest()
+	for i := range obfuscated.attrs {
+		if obfuscated.attrs[i].resID != 0 {
+			obfuscated.attrs[i].name = ""
+		}
+	}
+
+	split := launcherManifest()
+	split.attrs = append(split.attrs, strAttr("split", 0, "config.arm64_v8a"))
+	splitWant := *want
+	splitWant.Split = "config.arm64_v8a"
+
+	noTarget := launcherManifest()
+	noTarget.children[0].attrs = noTarget.children[0].attrs[:1]
+	noTargetWant := *want
+	noTargetWant.TargetSdk = 26
+
+	stringVersion := launcherManifest()
+	stringVersion.attrs[0] = strAttr("versionCode", resVersionCode, "42")
+
+	noPackage := launcherManifest()
+	noPackage.attrs = noPackage.attrs[:2]
+
+	noVersion := launcherManifest()
+	noVersion.attrs = noVersion.attrs[1:]
+
+	tests := []struct {
+		name     string
+		manifest []byte
+		want     *Manifest
+	}{
+		{"UTF-16 string pool", encodeAXML(launcherManifest(), false), want},
+		{"UTF-8 string pool", encodeAXML(launcherManifest(), true), want},
+		{"attribute names stripped", encodeAXML(obfuscated, false), want},
+		{"split APK", encodeAXML(split, false), &splitWant},
+		{"target SDK defaults to min SDK", encodeAXML(noTarget, false), &noTargetWant},
+		{"version code as string", encodeAXML(stringVersion, false), want},
+		{"missing package", encodeAXML(noPackage, false), nil},
+		{"missing version code", encodeAXML(noVersion, false), nil},
+		{"other root element", encodeAXML(axmlNode{name: "resources"}, false), nil},
+		{"truncated", encodeAXML(launcherManifest(), false)[:100], nil},
+		{"text XML", []byte(`<manifest package="com.example"/>`), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apk := buildZip(t, zipFixture{"classes.dex", []byte("dex\n035")}, zipFixture{"AndroidManifest.xml", tt.manifest})
+			got, err := ParseManifest(bytes.NewReader(apk), int64(len(apk)))
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("ParseManifest = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseManifest = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseManifestNotFound(t *testing.T) {
+	apk := buildZip(t, zipFixture{"classes.dex", []byte("dex\n035")})
+	if _, err := ParseManifest(bytes.NewReader(apk), int64(len(apk))); !errors.Is(err, ErrManifestNotFound) {
+		t.Fatalf("ParseManifest error = %v, want ErrManifestNotFound", err)
+	}
+
+	if _, err := ParseManifest(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Fatal("ParseManifest accepted a file that is not a zip")
+	}
+}
@@ -0,0 +1,28 @@
+package apk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddedDangerousPermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		previous  []string
+		requested []string
+		want      []string
+	}{
+		{"first release", nil, []string{"android.permission.INTERNET", "android.permission.CAMERA"}, []string{"android.permission.CAMERA"}},
+		{"already granted", []string{"android.permission.CAMERA"}, []string{"android.permission.CAMERA"}, nil},
+		{"newly requested", []string{"android.permission.CAMERA"}, []string{"android.permission.CAMERA", "android.permission.RECORD_AUDIO", "android.permission.RECORD_AUDIO"}, []string{"android.permission.RECORD_AUDIO"}},
+		{"normal permissions only", nil, []string{"android.permission.INTERNET", "android.permission.VIBRATE"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddedDangerousPermissions(tt.previous, tt.requested); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("AddedDangerousPermissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
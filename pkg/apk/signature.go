@@ -0,0 +1,214 @@
+package apk
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrNotSigned        = errors.New("apk is not signed")
+	ErrInvalidSignature = errors.New("apk signature is invalid")
+)
+
+// Signature schemes reported in SigningInfo.Scheme.
+const (
+	SchemeV1 = 1
+	SchemeV2 = 2
+	SchemeV3 = 3
+)
+
+// SigningInfo describes the certificate an APK was verifiably signed with.
+type SigningInfo struct {
+	Scheme     int
+	CertSHA256 string
+	// Lineage lists the SHA-256 digests of the certificates in the APK's v3
+	// proof-of-rotation, oldest first. Empty when the APK carries no lineage.
+	Lineage []string
+}
+
+// VerifySigning verifies the APK signature and returns the signer certificate.
+// The v3 and v2 signing blocks take precedence over v1 JAR signatures.
+func VerifySigning(r io.ReaderAt, size int64) (*SigningInfo, error) {
+	info, err := verifySigningBlock(r, size)
+	if errors.Is(err, errNoSigningBlock) {
+		return verifyJARSignature(r, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Devices older than Android 7.0 only check the v1 signature, so when one
+	// is present it must come from the same certificate.
+	v1, err := verifyJARSignature(r, size)
+	if err != nil && !errors.Is(err, ErrNotSigned) {
+		return nil, err
+	}
+	if v1 != nil && v1.CertSHA256 != info.CertSHA256 && !containsString(info.Lineage, v1.CertSHA256) {
+		return nil, fmt.Errorf("%w: v1 and v%d signatures use different certificates", ErrInvalidSignature, info.Scheme)
+	}
+
+	return info, nil
+}
+
+// CertSHA256 returns the lowercase hex SHA-256 digest of a DER certificate.
+func CertSHA256(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// certificate is the subset of an X.509 certificate needed to verify APK
+// signatures. Parsed by hand because Android signing certificates frequently
+// violate rules that crypto/x509 enforces, such as negative serial numbers.
+type certificate struct {
+	Raw       []byte
+	Issuer    []byte
+	Serial    *big.Int
+	PublicKey crypto.PublicKey
+	SPKI      []byte
+}
+
+func parseCertificate(der []byte) (*certificate, error) {
+	var cert, tbs asn1.RawValue
+	if rest, err := asn1.Unmarshal(der, &cert); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("invalid certificate")
+	}
+	if _, err := asn1.Unmarshal(cert.Bytes, &tbs); err != nil {
+		return nil, fmt.Errorf("invalid certificate")
+	}
+
+	fields, err := sequenceElements(tbs.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate")
+	}
+	// Skip the optional explicit [0] version.
+	if len(fields) > 0 && fields[0].Class == asn1.ClassContextSpecific && fields[0].Tag == 0 {
+		fields = fields[1:]
+	}
+	// serial, signature algorithm, issuer, validity, subject, subjectPublicKeyInfo
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("invalid certificate")
+	}
+
+	serial := new(big.Int)
+	if _, err := asn1.Unmarshal(fields[0].FullBytes, &serial); err != nil {
+		// Fall back to raw two's-complement decoding for non-minimal encodings.
+		serial = twosComplement(fields[0].Bytes)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(fields[5].FullBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported certificate public key: %w", err)
+	}
+
+	return &certificate{
+		Raw:       der,
+		Issuer:    fields[2].FullBytes,
+		Serial:    serial,
+		PublicKey: pub,
+		SPKI:      fields[5].FullBytes,
+	}, nil
+}
+
+func twosComplement(b []byte) *big.Int {
+	n := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return n
+}
+
+func sequenceElements(b []byte) ([]asn1.RawValue, error) {
+	var out []asn1.RawValue
+	for len(b) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(b, &v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		b = rest
+	}
+	return out, nil
+}
+
+// verifyWithKey checks sig over msg using one of the key types Android supports.
+func verifyWithKey(pub crypto.PublicKey, hash crypto.Hash, pss bool, msg, sig []byte) error {
+	h := hash.New()
+	h.Write(msg)
+	digest := h.Sum(nil)
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if pss {
+			return rsa.VerifyPSS(key, hash, digest, sig, &rsa.PSSOptions{SaltLength: hash.Size()})
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signing key type %T", pub)
+	}
+}
+
+// zipEntry reads a whole entry from the archive.
+func zipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func isSignatureBlockFile(name string) bool {
+	if !strings.HasPrefix(name, "META-INF/") || strings.Count(name, "/") != 1 {
+		return false
+	}
+	upper := strings.ToUpper(name)
+	return strings.HasSuffix(upper, ".RSA") || strings.HasSuffix(upper, ".DSA") || strings.HasSuffix(upper, ".EC")
+}
+
+func sameCertificate(a, b *certificate) bool {
+	return bytes.Equal(a.Raw, b.Raw)
+}
+
+func readUint32(b []byte) (uint32, []byte, error) {
+	if len(b) < 4 {
+		return 0, nil, ErrInvalidSignature
+	}
+	return binary.LittleEndian.Uint32(b), b[4:], nil
+}
+
+// lengthPrefixed splits a uint32 length-prefixed slice off the front of b.
+func lengthPrefixed(b []byte) ([]byte, []byte, error) {
+	n, rest, err := readUint32(b)
+	if err != nil || uint64(n) > uint64(len(rest)) {
+		return nil, nil, ErrInvalidSignature
+	}
+	return rest[:n], rest[n:], nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,451 @@
+package apk
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+const (
+	blockIDV2         = 0x7109871a
+	blockIDV3         = 0xf05368c0
+	attrProofOfRotate = 0x3ba06f8c
+
+	signingBlockMagic = "APK Sig Block 42"
+	eocdSignature     = 0x06054b50
+	eocdMinSize       = 22
+	contentChunkSize  = 1 << 20
+)
+
+var errNoSigningBlock = errors.New("apk has no signing block")
+
+type sigAlgorithm struct {
+	hash     crypto.Hash
+	pss      bool
+	strength int
+}
+
+// Signature algorithms that use the chunked SHA-2 content digest. The
+// verity-based variants are not supported and are ignored when present.
+var sigAlgorithms = map[uint32]sigAlgorithm{
+	0x0101: {hash: crypto.SHA256, pss: true, strength: 1},
+	0x0102: {hash: crypto.SHA512, pss: true, strength: 2},
+	0x0103: {hash: crypto.SHA256, strength: 1},
+	0x0104: {hash: crypto.SHA512, strength: 2},
+	0x0201: {hash: crypto.SHA256, strength: 1},
+	0x0202: {hash: crypto.SHA512, strength: 2},
+}
+
+type zipSections struct {
+	signingBlockOffset int64
+	cdOffset           int64
+	eocdOffset         int64
+	eocd               []byte
+	blocks             map[uint32][]byte
+}
+
+func verifySigningBlock(r io.ReaderAt, size int64) (*SigningInfo, error) {
+	sections, err := findSigningBlock(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, ok := sections.blocks[blockIDV3]; ok {
+		info, err := verifySigners(r, sections, block, SchemeV3)
+		if err != nil {
+			return nil, err
+		}
+
+		// Android 7.0 to 8.1 only check the v2 signature, so when one is
+		// present it must come from the same certificate or one it rotated
+		// from.
+		if block, ok := sections.blocks[blockIDV2]; ok {
+			v2, err := verifySigners(r, sections, block, SchemeV2)
+			if err != nil {
+				return nil, err
+			}
+			if v2.CertSHA256 != info.CertSHA256 && !containsString(info.Lineage, v2.CertSHA256) {
+				return nil, fmt.Errorf("%w: v2 and v3 signatures use different certificates", ErrInvalidSignature)
+			}
+		}
+		return info, nil
+	}
+	if block, ok := sections.blocks[blockIDV2]; ok {
+		return verifySigners(r, sections, block, SchemeV2)
+	}
+
+	return nil, errNoSigningBlock
+}
+
+func findSigningBlock(r io.ReaderAt, size int64) (*zipSections, error) {
+	if size < eocdMinSize {
+		return nil, fmt.Errorf("%w: file too small", ErrInvalidSignature)
+	}
+
+	// The EOCD record sits at the end of the file, followed by an optional comment.
+	tailSize := int64(eocdMinSize + 0xFFFF)
+	if tailSize > size {
+		tailSize = size
+	}
+	tail := make([]byte, tailSize)
+	if _, err := r.ReadAt(tail, size-tailSize); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	eocdPos := -1
+	for i := len(tail) - eocdMinSize; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(tail[i:]) != eocdSignature {
+			continue
+		}
+		commentLen := int(binary.LittleEndian.Uint16(tail[i+20:]))
+		if i+eocdMinSize+commentLen == len(tail) {
+			eocdPos = i
+			break
+		}
+	}
+	if eocdPos < 0 {
+		return nil, fmt.Errorf("%w: end of central directory not found", ErrInvalidSignature)
+	}
+
+	s := &zipSections{
+		eocdOffset: size - tailSize + int64(eocdPos),
+		eocd:       tail[eocdPos:],
+	}
+	s.cdOffset = int64(binary.LittleEndian.Uint32(s.eocd[16:]))
+	if s.cdOffset < 32 || s.cdOffset > s.eocdOffset {
+		return nil, errNoSigningBlock
+	}
+
+	footer := make([]byte, 24)
+	if _, err := r.ReadAt(footer, s.cdOffset-24); err != nil {
+		return nil, err
+	}
+	if string(footer[8:]) != signingBlockMagic {
+		return nil, errNoSigningBlock
+	}
+
+	blockSize := binary.LittleEndian.Uint64(footer)
+	if blockSize < 24 || blockSize > uint64(s.cdOffset-8) {
+		return nil, fmt.Errorf("%w: bad signing block size", ErrInvalidSignature)
+	}
+	s.signingBlockOffset = s.cdOffset - int64(blockSize) - 8
+
+	block := make([]byte, blockSize+8)
+	if _, err := r.ReadAt(block, s.signingBlockOffset); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(block) != blockSize {
+		return nil, fmt.Errorf("%w: signing block size mismatch", ErrInvalidSignature)
+	}
+
+	s.blocks = make(map[uint32][]byte)
+	pairs := block[8 : len(block)-24]
+	for len(pairs) > 0 {
+		if len(pairs) < 8 {
+			return nil, fmt.Errorf("%w: truncated signing block", ErrInvalidSignature)
+		}
+		n := binary.LittleEndian.Uint64(pairs)
+		if n < 4 || n > uint64(len(pairs)-8) {
+			return nil, fmt.Errorf("%w: bad signing block entry", ErrInvalidSignature)
+		}
+		id := binary.LittleEndian.Uint32(pairs[8:])
+		s.blocks[id] = pairs[12 : 8+n]
+		pairs = pairs[8+n:]
+	}
+
+	return s, nil
+}
+
+func verifySigners(r io.ReaderAt, s *zipSections, block []byte, scheme int) (*SigningInfo, error) {
+	signers, _, err := lengthPrefixed(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var info *SigningInfo
+	for len(signers) > 0 {
+		var signer []byte
+		if signer, signers, err = lengthPrefixed(signers); err != nil {
+			return nil, err
+		}
+		if info != nil {
+			return nil, fmt.Errorf("%w: multiple signers are not supported", ErrInvalidSignature)
+		}
+		if info, err = verifySigner(r, s, signer, scheme); err != nil {
+			return nil, err
+		}
+	}
+
+	if info == nil {
+		return nil, fmt.Errorf("%w: no signers", ErrInvalidSignature)
+	}
+
+	return info, nil
+}
+
+func verifySigner(r io.ReaderAt, s *zipSections, signer []byte, scheme int) (*SigningInfo, error) {
+	signedData, rest, err := lengthPrefixed(signer)
+	if err != nil {
+		return nil, err
+	}
+	if scheme == SchemeV3 {
+		// minSdkVersion and maxSdkVersion
+		if len(rest) < 8 {
+			return nil, ErrInvalidSignature
+		}
+		rest = rest[8:]
+	}
+	signatures, rest, err := lengthPrefixed(rest)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, _, err := lengthPrefixed(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick the strongest supported signature and verify it over the signed data.
+	var (
+		bestID  uint32
+		bestSig []byte
+	)
+	for len(signatures) > 0 {
+		var sig []byte
+		if sig, signatures, err = lengthPrefixed(signatures); err != nil {
+			return nil, err
+		}
+		id, value, err := readUint32(sig)
+		if err != nil {
+			return nil, err
+		}
+		alg, ok := sigAlgorithms[id]
+		if !ok {
+			continue
+		}
+		if bestSig == nil || alg.strength > sigAlgorithms[bestID].strength {
+			if bestSig, _, err = lengthPrefixed(value); err != nil {
+				return nil, err
+			}
+			bestID = id
+		}
+	}
+	if bestSig == nil {
+		return nil, fmt.Errorf("%w: no supported signature algorithm", ErrInvalidSignature)
+	}
+
+	digests, rest, err := lengthPrefixed(signedData)
+	if err != nil {
+		return nil, err
+	}
+	certs, rest, err := lengthPrefixed(rest)
+	if err != nil {
+		return nil, err
+	}
+	firstCert, _, err := lengthPrefixed(certs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signer has no certificate", ErrInvalidSignature)
+	}
+	cert, err := parseCertificate(firstCert)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !bytes.Equal(cert.SPKI, publicKey) {
+		return nil, fmt.Errorf("%w: certificate does not match signer public key", ErrInvalidSignature)
+	}
+
+	alg := sigAlgorithms[bestID]
+	if err := verifyWithKey(cert.PublicKey, alg.hash, alg.pss, signedData, bestSig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	// The signed data carries the expected content digest for the chosen algorithm.
+	var expected []byte
+	for len(digests) > 0 {
+		var d []byte
+		if d, digests, err = lengthPrefixed(digests); err != nil {
+			return nil, err
+		}
+		id, value, err := readUint32(d)
+		if err != nil {
+			return nil, err
+		}
+		if id == bestID {
+			if expected, _, err = lengthPrefixed(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if expected == nil {
+		return nil, fmt.Errorf("%w: missing content digest", ErrInvalidSignature)
+	}
+
+	actual, err := contentDigest(r, s, alg.hash)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, actual) {
+		return nil, fmt.Errorf("%w: content digest mismatch", ErrInvalidSignature)
+	}
+
+	info := &SigningInfo{Scheme: scheme, CertSHA256: CertSHA256(cert.Raw)}
+
+	if scheme == SchemeV3 {
+		// minSdkVersion and maxSdkVersion precede the additional attributes.
+		if len(rest) < 8 {
+			return nil, ErrInvalidSignature
+		}
+		attrs, _, err := lengthPrefixed(rest[8:])
+		if err != nil {
+			return nil, err
+		}
+		for len(attrs) > 0 {
+			var attr []byte
+			if attr, attrs, err = lengthPrefixed(attrs); err != nil {
+				return nil, err
+			}
+			id, value, err := readUint32(attr)
+			if err != nil {
+				return nil, err
+			}
+			if id != attrProofOfRotate {
+				continue
+			}
+			if info.Lineage, err = parseLineage(value, cert); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// parseLineage verifies a v3 proof-of-rotation structure, in which every
+// certificate is signed by its predecessor, and returns the certificate digests
+// oldest first.
+func parseLineage(b []byte, signer *certificate) ([]string, error) {
+	version, nodes, err := readUint32(b)
+	if err != nil || version != 1 {
+		return nil, fmt.Errorf("%w: unsupported proof-of-rotation version", ErrInvalidSignature)
+	}
+
+	var (
+		lineage []string
+		prev    *certificate
+		prevAlg uint32
+	)
+	for len(nodes) > 0 {
+		var node []byte
+		if node, nodes, err = lengthPrefixed(nodes); err != nil {
+			return nil, err
+		}
+		signedData, rest, err := lengthPrefixed(node)
+		if err != nil {
+			return nil, err
+		}
+		// flags
+		if _, rest, err = readUint32(rest); err != nil {
+			return nil, err
+		}
+		algID, rest, err := readUint32(rest)
+		if err != nil {
+			return nil, err
+		}
+		signature, _, err := lengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		certDER, rest, err := lengthPrefixed(signedData)
+		if err != nil {
+			return nil, err
+		}
+		signedAlg, _, err := readUint32(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		if prev != nil {
+			alg, ok := sigAlgorithms[prevAlg]
+			if !ok || signedAlg != prevAlg {
+				return nil, fmt.Errorf("%w: unsupported proof-of-rotation algorithm", ErrInvalidSignature)
+			}
+			if err := verifyWithKey(prev.PublicKey, alg.hash, alg.pss, signedData, signature); err != nil {
+				return nil, fmt.Errorf("%w: broken proof-of-rotation: %v", ErrInvalidSignature, err)
+			}
+		}
+
+		cert, err := parseCertificate(certDER)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		lineage = append(lineage, CertSHA256(cert.Raw))
+		prev, prevAlg = cert, algID
+	}
+
+	if prev == nil || !sameCertificate(prev, signer) {
+		return nil, fmt.Errorf("%w: proof-of-rotation does not end with the signer certificate", ErrInvalidSignature)
+	}
+
+	return lineage, nil
+}
+
+// contentDigest computes the APK Signature Scheme chunked digest over the zip
+// entries, the central directory and the EOCD record.
+func contentDigest(r io.ReaderAt, s *zipSections, h crypto.Hash) ([]byte, error) {
+	newHash := func() hash.Hash {
+		if h == crypto.SHA512 {
+			return sha512.New()
+		}
+		return sha256.New()
+	}
+
+	// The EOCD is digested as if the central directory started at the signing block.
+	eocd := append([]byte(nil), s.eocd...)
+	binary.LittleEndian.PutUint32(eocd[16:], uint32(s.signingBlockOffset))
+
+	sections := []io.Reader{
+		io.NewSectionReader(r, 0, s.signingBlockOffset),
+		io.NewSectionReader(r, s.cdOffset, s.eocdOffset-s.cdOffset),
+		bytes.NewReader(eocd),
+	}
+
+	var chunkDigests []byte
+	var count uint32
+	buf := make([]byte, contentChunkSize)
+	for _, section := range sections {
+		for {
+			n, err := io.ReadFull(section, buf)
+			if n > 0 {
+				ch := newHash()
+				var prefix [5]byte
+				prefix[0] = 0xa5
+				binary.LittleEndian.PutUint32(prefix[1:], uint32(n))
+				ch.Write(prefix[:])
+				ch.Write(buf[:n])
+				chunkDigests = ch.Sum(chunkDigests)
+				count++
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	top := newHash()
+	var prefix [5]byte
+	prefix[0] = 0x5a
+	binary.LittleEndian.PutUint32(prefix[1:], count)
+	top.Write(prefix[:])
+	top.Write(chunkDigests)
+
+	return top.Sum(nil), nil
+}
@@ -0,0 +1,356 @@
+package apk
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	algRSAPKCS1SHA256 = 0x0103
+	algECDSASHA256    = 0x0201
+)
+
+// testSigner is a signing key with its self-signed certificate.
+type testSigner struct {
+	key  crypto.Signer
+	cert []byte
+	alg  uint32
+}
+
+func newTestSigner(t *testing.T, name string, ec bool) testSigner {
+	t.Helper()
+
+	var (
+		key crypto.Signer
+		alg uint32
+		err error
+	)
+	if ec {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		alg = algECDSASHA256
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		alg = algRSAPKCS1SHA256
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testSigner{key: key, cert: cert, alg: alg}
+}
+
+func (s testSigner) sign(t *testing.T, msg []byte) []byte {
+	t.Helper()
+	digest := sha256.Sum256(msg)
+
+	var (
+		sig []byte
+		err error
+	)
+	switch key := s.key.(type) {
+	case *ecdsa.PrivateKey:
+		sig, err = ecdsa.SignASN1(rand.Reader, key, digest[:])
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func (s testSigner) spki(t *testing.T) []byte {
+	t.Helper()
+	spki, err := x509.MarshalPKIXPublicKey(s.key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spki
+}
+
+func lp(parts ...[]byte) []byte {
+	var n int
+	for _, p := range parts {
+		n += len(p)
+	}
+	b := binary.LittleEndian.AppendUint32(nil, uint32(n))
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func u32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// lineageNode is a certificate in a proof-of-rotation fixture, signed by the
+// key of the node before it.
+type lineageNode struct {
+	signer testSigner
+	// by overrides the signer of the node, to forge a rotation.
+	by *testSigner
+}
+
+func encodeLineage(t *testing.T, nodes []lineageNode) []byte {
+	t.Helper()
+
+	b := u32(1)
+	for i, n := range nodes {
+		var prevAlg uint32
+		if i > 0 {
+			prevAlg = nodes[i-1].signer.alg
+		}
+		signedData := append(lp(n.signer.cert), u32(prevAlg)...)
+
+		var signature []byte
+		if i > 0 {
+			by := nodes[i-1].signer
+			if n.by != nil {
+				by = *n.by
+			}
+			signature = by.sign(t, signedData)
+		}
+
+		b = append(b, lp(lp(signedData), u32(0), u32(n.signer.alg), lp(signature))...)
+	}
+	return b
+}
+
+// blockFixture describes a v2 or v3 signature of an APK.
+type blockFixture struct {
+	scheme  int
+	signer  testSigner
+	lineage []lineageNode
+	// signedBy and certificate override the key signing the signed data and
+	// the certificate listed for the signer, to forge a signature.
+	signedBy    *testSigner
+	certificate []byte
+}
+
+// signAPK inserts an APK Signing Block with the given signatures between the
+// entries and the central directory of an unsigned APK.
+func signAPK(t *testing.T, unsigned []byte, blocks ...blockFixture) []byte {
+	t.Helper()
+
+	eocd := len(unsigned) - eocdMinSize
+	if binary.LittleEndian.Uint32(unsigned[eocd:]) != eocdSignature {
+		t.Fatal("fixture APK must not have a zip comment")
+	}
+	cdOffset := int(binary.LittleEndian.Uint32(unsigned[eocd+16:]))
+	digest := apkContentDigest(unsigned[:cdOffset], unsigned[cdOffset:eocd], unsigned[eocd:])
+
+	var pairs []byte
+	for _, b := range blocks {
+		cert := b.signer.cert
+		if b.certificate != nil {
+			cert = b.certificate
+		}
+		var attrs []byte
+		if b.lineage != nil {
+			attrs = lp(u32(attrProofOfRotate), encodeLineage(t, b.lineage))
+		}
+
+		signedData := append(lp(lp(u32(b.signer.alg), lp(digest))), lp(lp(cert))...)
+		sdk := append(u32(24), u32(0x7fffffff)...)
+		if b.scheme == SchemeV3 {
+			signedData = append(signedData, sdk...)
+		}
+		signedData = append(signedData, lp(attrs)...)
+
+		signedBy := b.signer
+		if b.signedBy != nil {
+			signedBy = *b.signedBy
+		}
+		signatures := lp(lp(u32(b.signer.alg), lp(signedBy.sign(t, signedData))))
+
+		signer := lp(signedData)
+		if b.scheme == SchemeV3 {
+			signer = append(signer, sdk...)
+		}
+		signer = append(signer, signatures...)
+		signer = append(signer, lp(b.signer.spki(t))...)
+
+		id := uint32(blockIDV2)
+		if b.scheme == SchemeV3 {
+			id = blockIDV3
+		}
+		value := append(u32(id), lp(lp(signer))...)
+		pairs = binary.LittleEndian.AppendUint64(pairs, uint64(len(value)))
+		pairs = append(pairs, value...)
+	}
+
+	size := uint64(len(pairs) + 8 + len(signingBlockMagic))
+	block := binary.LittleEndian.AppendUint64(nil, size)
+	block = append(block, pairs...)
+	block = binary.LittleEndian.AppendUint64(block, size)
+	block = append(block, signingBlockMagic...)
+
+	signed := append([]byte(nil), unsigned[:cdOffset]...)
+	signed = append(signed, block...)
+	signed = append(signed, unsigned[cdOffset:]...)
+	binary.LittleEndian.PutUint32(signed[len(signed)-eocdMinSize+16:], uint32(cdOffset+len(block)))
+	return signed
+}
+
+// apkContentDigest is the SHA-256 chunked digest of the entries, central
+// directory and EOCD of an APK, each section fitting in a single chunk.
+func apkContentDigest(sections ...[]byte) []byte {
+	var chunks []byte
+	for _, section := range sections {
+		sum := sha256.Sum256(append(append([]byte{0xa5}, u32(uint32(len(section)))...), section...))
+		chunks = append(chunks, sum[:]...)
+	}
+	sum := sha256.Sum256(append(append([]byte{0x5a}, u32(uint32(len(sections)))...), chunks...))
+	return sum[:]
+}
+
+func TestVerifySigning(t *testing.T) {
+	unsigned := buildZip(t,
+		zipFixture{"AndroidManifest.xml", encodeAXML(launcherManifest(), false)},
+		zipFixture{"classes.dex", bytes.Repeat([]byte("dex\n035"), 100)},
+	)
+
+	ecSigner := newTestSigner(t, "release", true)
+	rsaSigner := newTestSigner(t, "legacy", false)
+	oldest := newTestSigner(t, "original", false)
+	stranger := newTestSigner(t, "stranger", true)
+
+	tampered := signAPK(t, unsigned, blockFixture{scheme: SchemeV2, signer: ecSigner})
+	// Past the local header of AndroidManifest.xml, in its compressed data.
+	tampered[30+len("AndroidManifest.xml")+4] ^= 0xff
+
+	tests := []struct {
+		name string
+		apk  []byte
+		want *SigningInfo
+		err  error
+	}{
+		{
+			name: "v2 ECDSA",
+			apk:  signAPK(t, unsigned, blockFixture{scheme: SchemeV2, signer: ecSigner}),
+			want: &SigningInfo{Scheme: SchemeV2, CertSHA256: CertSHA256(ecSigner.cert)},
+		},
+		{
+			name: "v2 RSA",
+			apk:  signAPK(t, unsigned, blockFixture{scheme: SchemeV2, signer: rsaSigner}),
+			want: &SigningInfo{Scheme: SchemeV2, CertSHA256: CertSHA256(rsaSigner.cert)},
+		},
+		{
+			name: "v3",
+			apk:  signAPK(t, unsigned, blockFixture{scheme: SchemeV3, signer: ecSigner}),
+			want: &SigningInfo{Scheme: SchemeV3, CertSHA256: CertSHA256(ecSigner.cert)},
+		},
+		{
+			name: "v3 takes precedence over v2",
+			apk: signAPK(t, unsigned,
+				blockFixture{scheme: SchemeV2, signer: ecSigner},
+				blockFixture{scheme: SchemeV3, signer: ecSigner},
+			),
+			want: &SigningInfo{Scheme: SchemeV3, CertSHA256: CertSHA256(ecSigner.cert)},
+		},
+		{
+			name: "v2 signed by a key v3 rotated from",
+			apk: signAPK(t, unsigned,
+				blockFixture{scheme: SchemeV2, signer: rsaSigner},
+				blockFixture{scheme: SchemeV3, signer: ecSigner, lineage: []lineageNode{
+					{signer: rsaSigner}, {signer: ecSigner},
+				}},
+			),
+			want: &SigningInfo{
+				Scheme:     SchemeV3,
+				CertSHA256: CertSHA256(ecSigner.cert),
+				Lineage:    []string{CertSHA256(rsaSigner.cert), CertSHA256(ecSigner.cert)},
+			},
+		},
+		{
+			name: "v2 and v3 signed by unrelated keys",
+			apk: signAPK(t, unsigned,
+				blockFixture{scheme: SchemeV2, signer: stranger},
+				blockFixture{scheme: SchemeV3, signer: ecSigner},
+			),
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "v3 rotation lineage",
+			apk: signAPK(t, unsigned, blockFixture{scheme: SchemeV3, signer: ecSigner, lineage: []lineageNode{
+				{signer: oldest}, {signer: rsaSigner}, {signer: ecSigner},
+			}}),
+			want: &SigningInfo{
+				Scheme:     SchemeV3,
+				CertSHA256: CertSHA256(ecSigner.cert),
+				Lineage:    []string{CertSHA256(oldest.cert), CertSHA256(rsaSigner.cert), CertSHA256(ecSigner.cert)},
+			},
+		},
+		{
+			name: "lineage with a forged rotation",
+			apk: signAPK(t, unsigned, blockFixture{scheme: SchemeV3, signer: ecSigner, lineage: []lineageNode{
+				{signer: oldest}, {signer: ecSigner, by: &stranger},
+			}}),
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "lineage not ending with the signer",
+			apk: signAPK(t, unsigned, blockFixture{scheme: SchemeV3, signer: ecSigner, lineage: []lineageNode{
+				{signer: oldest}, {signer: rsaSigner},
+			}}),
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "signed data signed by another key",
+			apk:  signAPK(t, unsigned, blockFixture{scheme: SchemeV2, signer: ecSigner, signedBy: &stranger}),
+			err:  ErrInvalidSignature,
+		},
+		{
+			name: "certificate of another key",
+			apk:  signAPK(t, unsigned, blockFixture{scheme: SchemeV2, signer: ecSigner, certificate: stranger.cert}),
+			err:  ErrInvalidSignature,
+		},
+		{
+			name: "entries changed after signing",
+			apk:  tampered,
+			err:  ErrInvalidSignature,
+		},
+		{
+			name: "unsigned",
+			apk:  unsigned,
+			err:  ErrNotSigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifySigning(bytes.NewReader(tt.apk), int64(len(tt.apk)))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("VerifySigning error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("VerifySigning = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
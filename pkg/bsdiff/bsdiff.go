@@ -0,0 +1,396 @@
+// Package bsdiff produces and applies binary patches using Colin Percival's
+// bsdiff algorithm. Patches use a streaming zlib container instead of the
+// original bzip2 layout: a header followed by control triples, each directly
+// followed by its diff and extra bytes.
+package bsdiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const magic = "LBSDIFF1"
+
+var ErrCorruptPatch = errors.New("corrupt patch")
+
+// Diff returns a patch that transforms old into new. Besides both inputs and
+// the patch, it holds a suffix array of old in memory, 8 bytes per byte of
+// old, so diffing takes about ten times the size of old plus twice that of new.
+func Diff(old, new []byte) ([]byte, error) {
+	if len(old) >= math.MaxInt32 {
+		return nil, fmt.Errorf("old file too large for diffing")
+	}
+
+	var out bytes.Buffer
+	out.WriteString(magic)
+	writeInt64(&out, int64(len(new)))
+
+	zw := zlib.NewWriter(&out)
+	if err := diff(old, new, zw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// Patch applies a patch produced by Diff to old. The new file is allocated
+// upfront at the size recorded in the patch, up to 2 GiB, so patches must come
+// from a trusted source.
+func Patch(old, patch []byte) ([]byte, error) {
+	if len(patch) < len(magic)+8 || string(patch[:len(magic)]) != magic {
+		return nil, ErrCorruptPatch
+	}
+
+	newSize := readInt64(patch[len(magic):])
+	if newSize < 0 || newSize > math.MaxInt32 {
+		return nil, ErrCorruptPatch
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(patch[len(magic)+8:]))
+	if err != nil {
+		return nil, ErrCorruptPatch
+	}
+	defer zr.Close()
+
+	new := make([]byte, newSize)
+	var ctrl [24]byte
+	oldPos, newPos := int64(0), int64(0)
+	for newPos < newSize {
+		if _, err := io.ReadFull(zr, ctrl[:]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		diffLen, extraLen, seek := readInt64(ctrl[0:]), readInt64(ctrl[8:]), readInt64(ctrl[16:])
+
+		if diffLen < 0 || diffLen > newSize-newPos {
+			return nil, ErrCorruptPatch
+		}
+		if _, err := io.ReadFull(zr, new[newPos:newPos+diffLen]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		for i := int64(0); i < diffLen; i++ {
+			if o := oldPos + i; o >= 0 && o < int64(len(old)) {
+				new[newPos+i] += old[o]
+			}
+		}
+		newPos += diffLen
+		oldPos += diffLen
+
+		if extraLen < 0 || extraLen > newSize-newPos {
+			return nil, ErrCorruptPatch
+		}
+		if _, err := io.ReadFull(zr, new[newPos:newPos+extraLen]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		newPos += extraLen
+		oldPos += seek
+	}
+
+	return new, nil
+}
+
+func diff(old, new []byte, w io.Writer) error {
+	I := qsufsort(old)
+	oldSize, newSize := len(old), len(new)
+
+	var (
+		scan, pos, length             int
+		lastScan, lastPos, lastOffset int
+		diffBuf                       []byte
+		ctrl                          [24]byte
+	)
+
+	for scan < newSize {
+		oldScore := 0
+		scan += length
+		for scsc := scan; scan < newSize; scan++ {
+			pos, length = search(I, old, new[scan:], 0, oldSize)
+
+			for ; scsc < scan+length; scsc++ {
+				if scsc+lastOffset < oldSize && old[scsc+lastOffset] == new[scsc] {
+					oldScore++
+				}
+			}
+
+			if (length == oldScore && length != 0) || length > oldScore+8 {
+				break
+			}
+
+			if scan+lastOffset < oldSize && old[scan+lastOffset] == new[scan] {
+				oldScore--
+			}
+		}
+
+		if length == oldScore && scan != newSize {
+			continue
+		}
+
+		// Extend the previous match forwards.
+		s, sf, lenf := 0, 0, 0
+		for i := 0; lastScan+i < scan && lastPos+i < oldSize; {
+			if old[lastPos+i] == new[lastScan+i] {
+				s++
+			}
+			i++
+			if s*2-i > sf*2-lenf {
+				sf, lenf = s, i
+			}
+		}
+
+		// Extend the current match backwards.
+		lenb := 0
+		if scan < newSize {
+			s, sb := 0, 0
+			for i := 1; scan >= lastScan+i && pos >= i; i++ {
+				if old[pos-i] == new[scan-i] {
+					s++
+				}
+				if s*2-i > sb*2-lenb {
+					sb, lenb = s, i
+				}
+			}
+		}
+
+		// Resolve any overlap between the two extensions.
+		if lastScan+lenf > scan-lenb {
+			overlap := (lastScan + lenf) - (scan - lenb)
+			s, ss, lens := 0, 0, 0
+			for i := 0; i < overlap; i++ {
+				if new[lastScan+lenf-overlap+i] == old[lastPos+lenf-overlap+i] {
+					s++
+				}
+				if new[scan-lenb+i] == old[pos-lenb+i] {
+					s--
+				}
+				if s > ss {
+					ss, lens = s, i+1
+				}
+			}
+			lenf += lens - overlap
+			lenb -= lens
+		}
+
+		extraLen := (scan - lenb) - (lastScan + lenf)
+		putInt64(ctrl[0:], int64(lenf))
+		putInt64(ctrl[8:], int64(extraLen))
+		putInt64(ctrl[16:], int64((pos-lenb)-(lastPos+lenf)))
+		if _, err := w.Write(ctrl[:]); err != nil {
+			return err
+		}
+
+		diffBuf = diffBuf[:0]
+		for i := 0; i < lenf; i++ {
+			diffBuf = append(diffBuf, new[lastScan+i]-old[lastPos+i])
+		}
+		if _, err := w.Write(diffBuf); err != nil {
+			return err
+		}
+		if _, err := w.Write(new[lastScan+lenf : lastScan+lenf+extraLen]); err != nil {
+			return err
+		}
+
+		lastScan = scan - lenb
+		lastPos = pos - lenb
+		lastOffset = pos - scan
+	}
+
+	return nil
+}
+
+func search(I []int32, old, new []byte, st, en int) (pos int, n int) {
+	for en-st >= 2 {
+		x := st + (en-st)/2
+		if bytes.Compare(old[I[x]:], new[:min(len(old)-int(I[x]), len(new))]) < 0 {
+			st = x
+		} else {
+			en = x
+		}
+	}
+
+	x := matchLen(old[I[st]:], new)
+	y := matchLen(old[I[en]:], new)
+	if x > y {
+		return int(I[st]), x
+	}
+	return int(I[en]), y
+}
+
+func matchLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// qsufsort builds the suffix array of buf using Larsson and Sadakane's algorithm.
+func qsufsort(buf []byte) []int32 {
+	n := len(buf)
+	I := make([]int32, n+1)
+	V := make([]int32, n+1)
+
+	var buckets [256]int32
+	for _, c := range buf {
+		buckets[c]++
+	}
+	for i := 1; i < 256; i++ {
+		buckets[i] += buckets[i-1]
+	}
+	for i := 255; i > 0; i-- {
+		buckets[i] = buckets[i-1]
+	}
+	buckets[0] = 0
+
+	for i, c := range buf {
+		buckets[c]++
+		I[buckets[c]] = int32(i)
+	}
+	I[0] = int32(n)
+	for i, c := range buf {
+		V[i] = buckets[c]
+	}
+	V[n] = 0
+	for i := 1; i < 256; i++ {
+		if buckets[i] == buckets[i-1]+1 {
+			I[buckets[i]] = -1
+		}
+	}
+	I[0] = -1
+
+	for h := 1; I[0] != -int32(n+1); h += h {
+		length := 0
+		i := 0
+		for i < n+1 {
+			if I[i] < 0 {
+				length -= int(I[i])
+				i -= int(I[i])
+			} else {
+				if length != 0 {
+					I[i-length] = -int32(length)
+				}
+				length = int(V[I[i]]) + 1 - i
+				split(I, V, i, length, h)
+				i += length
+				length = 0
+			}
+		}
+		if length != 0 {
+			I[i-length] = -int32(length)
+		}
+	}
+
+	for i := 0; i < n+1; i++ {
+		I[V[i]] = int32(i)
+	}
+
+	return I
+}
+
+func split(I, V []int32, start, length, h int) {
+	if length < 16 {
+		for k := start; k < start+length; {
+			j := 1
+			x := V[int(I[k])+h]
+			for i := 1; k+i < start+length; i++ {
+				if v := V[int(I[k+i])+h]; v < x {
+					x = v
+					j = 0
+				}
+				if V[int(I[k+i])+h] == x {
+					I[k+j], I[k+i] = I[k+i], I[k+j]
+					j++
+				}
+			}
+			for i := 0; i < j; i++ {
+				V[I[k+i]] = int32(k + j - 1)
+			}
+			if j == 1 {
+				I[k] = -1
+			}
+			k += j
+		}
+		return
+	}
+
+	x := V[int(I[start+length/2])+h]
+	jj, kk := 0, 0
+	for i := start; i < start+length; i++ {
+		if v := V[int(I[i])+h]; v < x {
+			jj++
+		} else if v == x {
+			kk++
+		}
+	}
+	jj += start
+	kk += jj
+
+	i, j, k := start, 0, 0
+	for i < jj {
+		if v := V[int(I[i])+h]; v < x {
+			i++
+		} else if v == x {
+			I[i], I[jj+j] = I[jj+j], I[i]
+			j++
+		} else {
+			I[i], I[kk+k] = I[kk+k], I[i]
+			k++
+		}
+	}
+
+	for jj+j < kk {
+		if V[int(I[jj+j])+h] == x {
+			j++
+		} else {
+			I[jj+j], I[kk+k] = I[kk+k], I[jj+j]
+			k++
+		}
+	}
+
+	if jj > start {
+		split(I, V, start, jj-start, h)
+	}
+
+	for i := 0; i < kk-jj; i++ {
+		V[I[jj+i]] = int32(kk - 1)
+	}
+	if jj == kk-1 {
+		I[jj] = -1
+	}
+
+	if start+length > kk {
+		split(I, V, kk, start+length-kk, h)
+	}
+}
+
+// Offsets are stored in sign-magnitude form, as in the original bsdiff.
+func putInt64(b []byte, v int64) {
+	u := uint64(v)
+	if v < 0 {
+		u = uint64(-v) | 1<<63
+	}
+	binary.LittleEndian.PutUint64(b, u)
+}
+
+func readInt64(b []byte) int64 {
+	u := binary.LittleEndian.Uint64(b)
+	v := int64(u &^ (1 << 63))
+	if u&(1<<63) != 0 {
+		v = -v
+	}
+	return v
+}
+
+func writeInt64(w io.Writer, v int64) {
+	var b [8]byte
+	putInt64(b[:], v)
+	w.Write(b[:])
+}
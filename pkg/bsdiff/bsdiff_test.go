@@ -0,0 +1,112 @@
+package bsdiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDiffPatchRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+
+	base := random(64 << 10)
+	edited := append([]byte(nil), base...)
+	copy(edited[1000:], "changed in the middle")
+	edited = append(edited[:30000], append(random(512), edited[30000:]...)...)
+
+	tests := []struct {
+		name     string
+		old, new []byte
+	}{
+		{"identical", base, base},
+		{"edited", base, edited},
+		{"truncated", base, base[:len(base)/2]},
+		{"from empty", nil, random(4096)},
+		{"to empty", base, nil},
+		{"unrelated", random(8192), random(8192)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Diff(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("Diff: %v", err)
+			}
+			got, err := Patch(tt.old, patch)
+			if err != nil {
+				t.Fatalf("Patch: %v", err)
+			}
+			if !bytes.Equal(got, tt.new) {
+				t.Fatalf("round trip produced %d bytes, want %d", len(got), len(tt.new))
+			}
+		})
+	}
+}
+
+func TestDiffIsSmallForSimilarInputs(t *testing.T) {
+	old := bytes.Repeat([]byte("launcher release payload "), 4096)
+	new := append([]byte(nil), old...)
+	copy(new[5000:], "patched")
+
+	patch, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) >= len(new)/10 {
+		t.Fatalf("patch is %d bytes for a %d byte file", len(patch), len(new))
+	}
+}
+
+func TestPatchRejectsCorruptInput(t *testing.T) {
+	old := []byte("old contents")
+	valid, err := Diff(old, []byte("new contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		patch []byte
+	}{
+		{"empty", nil},
+		{"bad magic", append([]byte("XXXXXXXX"), valid[8:]...)},
+		{"header only", valid[:len(magic)+8]},
+		{"truncated body", valid[:len(magic)+8+4]},
+		{"huge diff length", craftedPatch(12, math.MaxInt64, 0)},
+		{"huge extra length", craftedPatch(12, 4, math.MaxInt64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Patch(old, tt.patch); !errors.Is(err, ErrCorruptPatch) {
+				t.Fatalf("got %v, want ErrCorruptPatch", err)
+			}
+		})
+	}
+}
+
+// craftedPatch returns a patch for a file of newSize bytes holding a single
+// control triple, followed by newSize bytes of data.
+func craftedPatch(newSize, diffLen, extraLen int64) []byte {
+	var out bytes.Buffer
+	out.WriteString(magic)
+	writeInt64(&out, newSize)
+
+	zw := zlib.NewWriter(&out)
+	var ctrl [24]byte
+	putInt64(ctrl[0:], diffLen)
+	putInt64(ctrl[8:], extraLen)
+	zw.Write(ctrl[:])
+	zw.Write(make([]byte, newSize))
+	zw.Close()
+
+	return out.Bytes()
+}
@@ -0,0 +1,153 @@
+// Package httprange parses HTTP Range requests (RFC 9110, section 14).
+package httprange
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRanges bounds the ranges of one request. Each range of a multipart
+// response costs a part header and a seek, so long lists are ignored.
+const maxRanges = 32
+
+var (
+	// ErrInvalid means the Range header is malformed and must be ignored.
+	ErrInvalid = errors.New("invalid range")
+	// ErrUnsatisfiable means no requested range overlaps the resource.
+	ErrUnsatisfiable = errors.New("range not satisfiable")
+)
+
+// Range is a byte range with an inclusive start and a length.
+type Range struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange formats the Content-Range header value for the range.
+func (r Range) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// Parse parses a Range header against a resource of the given size. Ranges
+// that do not overlap the resource are dropped; if none remain ErrUnsatisfiable
+// is returned. Overlapping and adjacent ranges are coalesced in ascending
+// order, so no byte is sent twice, and headers with more than maxRanges
+// ranges are invalid.
+func Parse(header string, size int64) ([]Range, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, ErrInvalid
+	}
+
+	specs := strings.Split(header[len(prefix):], ",")
+	if len(specs) > maxRanges {
+		return nil, ErrInvalid
+	}
+
+	var ranges []Range
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		dash := strings.IndexByte(spec, '-')
+		if dash < 0 {
+			return nil, ErrInvalid
+		}
+		startStr, endStr := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+		var r Range
+		if startStr == "" {
+			// Suffix range: the last n bytes.
+			n, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || n < 0 {
+				return nil, ErrInvalid
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r = Range{Start: size - n, Length: n}
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, ErrInvalid
+			}
+			end := size - 1
+			if endStr != "" {
+				if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+					return nil, ErrInvalid
+				}
+			}
+			if start >= size {
+				continue
+			}
+			if end >= size {
+				end = size - 1
+			}
+			r = Range{Start: start, Length: end - start + 1}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		return nil, ErrUnsatisfiable
+	}
+
+	return coalesce(ranges), nil
+}
+
+// coalesce sorts ranges and merges those that overlap or touch.
+func coalesce(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start > last.Start+last.Length {
+			merged = append(merged, r)
+			continue
+		}
+		if end := r.Start + r.Length; end > last.Start+last.Length {
+			last.Length = end - last.Start
+		}
+	}
+	return merged
+}
+
+// IfRangeMatches evaluates an If-Range precondition. The range is honoured
+// only if the validator still identifies the current representation; entity
+// tags are compared strongly, dates must match Last-Modified exactly.
+func IfRangeMatches(ifRange string, etag string, lastModified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return !strings.HasPrefix(ifRange, "W/") && ifRange == etag
+	}
+
+	t, err := time.Parse(time.RFC1123, ifRange)
+	if err != nil {
+		return false
+	}
+
+	return lastModified.Truncate(time.Second).Equal(t)
+}
+
+// TotalLength returns the number of bytes covered by the ranges.
+func TotalLength(ranges []Range) int64 {
+	var n int64
+	for _, r := range ranges {
+		n += r.Length
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package httprange
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		size   int64
+		want   []Range
+		err    error
+	}{
+		{"first bytes", "bytes=0-99", 1000, []Range{{0, 100}}, nil},
+		{"open ended", "bytes=900-", 1000, []Range{{900, 100}}, nil},
+		{"end clamped to size", "bytes=990-2000", 1000, []Range{{990, 10}}, nil},
+		{"suffix", "bytes=-100", 1000, []Range{{900, 100}}, nil},
+		{"suffix larger than resource", "bytes=-5000", 1000, []Range{{0, 1000}}, nil},
+		{"multi-range", "bytes=0-9, 20-29,-5", 100, []Range{{0, 10}, {20, 10}, {95, 5}}, nil},
+		{"multi-range is sorted", "bytes=-5,20-29,0-9", 100, []Range{{0, 10}, {20, 10}, {95, 5}}, nil},
+		{"overlapping ranges are merged", "bytes=0-,0-,0-,10-20", 100, []Range{{0, 100}}, nil},
+		{"adjacent ranges are merged", "bytes=0-9,10-19,15-24,50-59", 100, []Range{{0, 25}, {50, 10}}, nil},
+		{"suffix overlapping a range", "bytes=90-94,-8", 100, []Range{{90, 10}}, nil},
+		{"too many ranges", "bytes=" + strings.Repeat("0-0,", maxRanges) + "0-0", 100, nil, ErrInvalid},
+		{"multi-range drops unsatisfiable parts", "bytes=0-9,500-600", 100, []Range{{0, 10}}, nil},
+		{"start past end", "bytes=1000-", 1000, nil, ErrUnsatisfiable},
+		{"zero length suffix", "bytes=-0", 1000, nil, ErrUnsatisfiable},
+		{"empty resource", "bytes=0-", 0, nil, ErrUnsatisfiable},
+		{"all parts unsatisfiable", "bytes=200-300,400-", 100, nil, ErrUnsatisfiable},
+		{"other unit", "items=0-9", 1000, nil, ErrInvalid},
+		{"missing dash", "bytes=100", 1000, nil, ErrInvalid},
+		{"end before start", "bytes=50-10", 1000, nil, ErrInvalid},
+		{"negative start", "bytes=-10-20", 1000, nil, ErrInvalid},
+		{"not a number", "bytes=a-b", 1000, nil, ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.header, tt.size)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q, %d) error = %v, want %v", tt.header, tt.size, err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse(%q, %d) = %v, want %v", tt.header, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentRange(t *testing.T) {
+	if got := (Range{Start: 900, Length: 100}).ContentRange(1000); got != "bytes 900-999/1000" {
+		t.Fatalf("ContentRange = %q", got)
+	}
+	if got := TotalLength([]Range{{0, 10}, {20, 10}, {95, 5}}); got != 25 {
+		t.Fatalf("TotalLength = %d, want 25", got)
+	}
+}
+
+func TestIfRangeMatches(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 30, 45, 500, time.UTC)
+	etag := `"abc123"`
+
+	tests := []struct {
+		name    string
+		ifRange string
+		want    bool
+	}{
+		{"absent", "", true},
+		{"matching etag", `"abc123"`, true},
+		{"other etag", `"def456"`, false},
+		{"weak etag", `W/"abc123"`, false},
+		{"matching date", modified.Format(time.RFC1123), true},
+		{"earlier date", modified.Add(-time.Second).Format(time.RFC1123), false},
+		{"later date", modified.Add(time.Hour).Format(time.RFC1123), false},
+		{"malformed date", "yesterday", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IfRangeMatches(tt.ifRange, etag, modified); got != tt.want {
+				t.Fatalf("IfRangeMatches(%q) = %v, want %v", tt.ifRange, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxKeySetSize bounds the JWKS documents that are read.
+const maxKeySetSize = 1 << 20
+
+// Key is a public key of a key set.
+type Key struct {
+	ID string
+	// Algorithm restricts the key to one algorithm when set.
+	Algorithm string
+	Public    crypto.PublicKey
+}
+
+// KeySet is a parsed JWKS document.
+type KeySet struct {
+	keys []Key
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// ParseKeySet parses a JWKS document. Keys that are not RSA or EC P-256,
+// P-384 or P-521 signature keys are skipped.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var doc jwks
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	set := &KeySet{}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		public, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		if public == nil {
+			continue
+		}
+		set.keys = append(set.keys, Key{ID: k.Kid, Algorithm: k.Alg, Public: public})
+	}
+	if len(set.keys) == 0 {
+		return nil, errors.New("JWKS has no usable signature keys")
+	}
+
+	return set, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if n.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve := curveByName(k.Crv)
+		if curve == nil {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func curveByName(name string) elliptic.Curve {
+	switch name {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	}
+	return nil
+}
+
+// Lookup returns the keys with the given ID, or every key when kid is empty.
+func (s *KeySet) Lookup(kid string) []Key {
+	if kid == "" {
+		return s.keys
+	}
+	var keys []Key
+	for _, k := range s.keys {
+		if k.ID == kid {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// MarshalKeySet encodes public keys as a JWKS document, for publishing keys
+// generated locally.
+func MarshalKeySet(keys ...Key) ([]byte, error) {
+	var doc jwks
+	for _, k := range keys {
+		j := jwk{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+		switch public := k.Public.(type) {
+		case *rsa.PublicKey:
+			j.Kty = "RSA"
+			j.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			j.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			j.Kty = "EC"
+			j.Crv = public.Curve.Params().Name
+			size := (public.Curve.Params().BitSize + 7) / 8
+			j.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			j.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		default:
+			return nil, fmt.Errorf("unsupported key type %T", k.Public)
+		}
+		doc.Keys = append(doc.Keys, j)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// KeySource loads a key set from a file or an HTTPS URL and reloads it
+// periodically, and early when a token names a key it does not know, so
+// signing keys can be rotated without a restart.
+type KeySource struct {
+	location   string
+	client     *http.Client
+	refresh    time.Duration
+	minRefresh time.Duration
+
+	mu          sync.Mutex
+	set         *KeySet
+	loadedAt    time.Time
+	lastAttempt time.Time
+}
+
+// NewKeySource creates a key source for a file path or an https:// URL,
+// reloaded every refresh. Unknown key IDs trigger a reload at most every
+// minRefresh.
+func NewKeySource(location string, refresh time.Duration, minRefresh time.Duration) *KeySource {
+	return &KeySource{
+		location:   location,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		refresh:    refresh,
+		minRefresh: minRefresh,
+	}
+}
+
+// Load reads the key set, replacing the current one.
+func (s *KeySource) Load(ctx context.Context) error {
+	data, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	set, err := ParseKeySet(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.set = set
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySource) read(ctx context.Context) ([]byte, error) {
+	if strings.HasPrefix(s.location, "http://") {
+		return nil, errors.New("JWKS URL must use https")
+	}
+	if !strings.HasPrefix(s.location, "https://") {
+		f, err := os.Open(s.location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS: %w", err)
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxKeySetSize))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxKeySetSize))
+}
+
+// Keys returns the keys with the given ID, reloading the key set when it is
+// stale or does not have the key. A failed reload keeps the previous keys.
+func (s *KeySource) Keys(ctx context.Context, kid string) ([]Key, error) {
+	s.mu.Lock()
+	set := s.set
+	now := time.Now()
+	stale := set == nil || (s.refresh > 0 && now.Sub(s.loadedAt) >= s.refresh)
+	missing := set != nil && len(set.Lookup(kid)) == 0
+	reload := (stale || missing) && now.Sub(s.lastAttempt) >= s.minRefresh
+	if reload {
+		s.lastAttempt = now
+	}
+	s.mu.Unlock()
+
+	if reload {
+		if err := s.Load(ctx); err != nil {
+			if set == nil {
+				return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, err)
+			}
+			log.Printf("Failed to reload JWKS, keeping the previous keys: %v", err)
+		}
+		s.mu.Lock()
+		set = s.set
+		s.mu.Unlock()
+	}
+	if set == nil {
+		return nil, ErrKeySetUnavailable
+	}
+
+	return set.Lookup(kid), nil
+}
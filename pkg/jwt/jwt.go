@@ -0,0 +1,289 @@
+// Package jwt verifies JSON Web Tokens signed with RS256, RS384, RS512, ES256,
+// ES384 or ES512 against the public keys of a JWKS document, as issued by
+// OpenID Connect providers, and signs tokens with local keys.
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed            = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrUnknownKey           = errors.New("unknown signing key")
+	ErrSignature            = errors.New("invalid signature")
+	ErrExpired              = errors.New("token has expired")
+	ErrNotYetValid          = errors.New("token is not valid yet")
+	ErrIssuer               = errors.New("unexpected issuer")
+	ErrAudience             = errors.New("unexpected audience")
+	// ErrKeySetUnavailable is returned when no keys could be loaded to
+	// verify a token with; the token itself may be fine.
+	ErrKeySetUnavailable = errors.New("JWKS is unavailable")
+)
+
+type algorithm struct {
+	hash crypto.Hash
+	// ec is set for ECDSA algorithms, which only accept keys on this curve
+	ec string
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"ES256": {hash: crypto.SHA256, ec: "P-256"},
+	"ES384": {hash: crypto.SHA384, ec: "P-384"},
+	"ES512": {hash: crypto.SHA512, ec: "P-521"},
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Claims are the claims of a token.
+type Claims map[string]any
+
+// String returns a string claim, or "" when it is missing or not a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings returns a claim holding a string or an array of strings. A name
+// with dots, such as realm_access.roles, looks into nested objects.
+func (c Claims) Strings(name string) []string {
+	var value any = map[string]any(c)
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Time returns a NumericDate claim.
+func (c Claims) Time(name string) (time.Time, bool) {
+	n, ok := c[name].(float64)
+	if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+// Options are the checks a Verifier makes besides the signature.
+type Options struct {
+	// Issuer must equal the iss claim.
+	Issuer string
+	// Audience must be one of the aud claim.
+	Audience string
+	// ClockSkew is tolerated when checking exp and nbf.
+	ClockSkew time.Duration
+}
+
+// Verifier verifies tokens against the keys of a KeySource.
+type Verifier struct {
+	keys *KeySource
+	opts Options
+	now  func() time.Time
+}
+
+func NewVerifier(keys *KeySource, opts Options) *Verifier {
+	return &Verifier{
+		keys: keys,
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+// Verify checks the signature, issuer, audience and validity period of a
+// token and returns its claims. Tokens must expire.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, err
+	}
+	alg, ok := algorithms[hdr.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, hdr.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	keys, err := v.keys.Keys(ctx, hdr.Kid)
+	if err != nil {
+		return nil, err
+	}
+	verified := false
+	for _, key := range keys {
+		if key.Algorithm != "" && key.Algorithm != hdr.Alg {
+			continue
+		}
+		if verifySignature(alg, key.Public, parts[0]+"."+parts[1], signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, hdr.Kid)
+		}
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) checkClaims(claims Claims) error {
+	now := v.now()
+
+	exp, ok := claims.Time("exp")
+	if !ok {
+		return fmt.Errorf("%w: no exp claim", ErrMalformed)
+	}
+	if !now.Before(exp.Add(v.opts.ClockSkew)) {
+		return ErrExpired
+	}
+	if nbf, ok := claims.Time("nbf"); ok && now.Add(v.opts.ClockSkew).Before(nbf) {
+		return ErrNotYetValid
+	}
+
+	if iss := claims.String("iss"); iss != v.opts.Issuer {
+		return fmt.Errorf("%w: %q", ErrIssuer, iss)
+	}
+	if !slices.Contains(claims.Strings("aud"), v.opts.Audience) {
+		return ErrAudience
+	}
+
+	return nil
+}
+
+func verifySignature(alg algorithm, public crypto.PublicKey, signed string, signature []byte) bool {
+	h := alg.hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return alg.ec == "" && rsa.VerifyPKCS1v15(key, alg.hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		if alg.ec == "" || key.Curve.Params().Name != alg.ec {
+			return false
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+// Sign issues a token signed with a local RSA or ECDSA private key.
+func Sign(alg string, kid string, key crypto.Signer, claims Claims) (string, error) {
+	a, ok := algorithms[alg]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	hdr, err := json.Marshal(header{Alg: alg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(hdr) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	h := a.hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if a.ec != "" {
+			return "", fmt.Errorf("%w: %s needs an EC key", ErrUnsupportedAlgorithm, alg)
+		}
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, a.hash, digest)
+	case *ecdsa.PrivateKey:
+		if k.Curve.Params().Name != a.ec {
+			return "", fmt.Errorf("%w: %s needs a %s key", ErrUnsupportedAlgorithm, alg, a.ec)
+		}
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest)
+		if err == nil {
+			size := (k.Curve.Params().BitSize + 7) / 8
+			signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+		}
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,253 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	id      string
+	alg     string
+	private crypto.Signer
+}
+
+func (k testKey) public() Key {
+	return Key{ID: k.id, Algorithm: k.alg, Public: k.private.Public()}
+}
+
+func newTestKeys(t *testing.T) (rsaKey, ecKey testKey) {
+	t.Helper()
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{"rsa-1", "RS256", rsaPrivate}, testKey{"ec-1", "ES256", ecPrivate}
+}
+
+// writeKeySet publishes keys as a JWKS file and returns its path.
+func writeKeySet(t *testing.T, path string, keys ...Key) string {
+	t.Helper()
+	data, err := MarshalKeySet(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "jwks.json")
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sign(t *testing.T, key testKey, claims Claims) string {
+	t.Helper()
+	token, err := Sign(key.alg, key.id, key.private, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, ecKey := newTestKeys(t)
+	unpublished, _ := newTestKeys(t)
+	unpublished.id = "rsa-2"
+
+	now := time.Unix(1700000000, 0)
+	verifier := NewVerifier(NewKeySource(writeKeySet(t, "", rsaKey.public(), ecKey.public()), 0, time.Hour), Options{
+		Issuer:    "https://issuer.example",
+		Audience:  "launcher-api",
+		ClockSkew: 30 * time.Second,
+	})
+	verifier.now = func() time.Time { return now }
+
+	claims := func(overrides Claims) Claims {
+		c := Claims{
+			"sub": "user-1",
+			"iss": "https://issuer.example",
+			"aud": "launcher-api",
+			"exp": float64(now.Add(time.Hour).Unix()),
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(c, name)
+			} else {
+				c[name] = value
+			}
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"RS256", sign(t, rsaKey, claims(nil)), nil},
+		{"ES256", sign(t, ecKey, claims(nil)), nil},
+		{"audience in a list", sign(t, rsaKey, claims(Claims{"aud": []any{"other", "launcher-api"}})), nil},
+		{"other audience", sign(t, rsaKey, claims(Claims{"aud": []any{"other"}})), ErrAudience},
+		{"missing audience", sign(t, rsaKey, claims(Claims{"aud": nil})), ErrAudience},
+		{"other issuer", sign(t, rsaKey, claims(Claims{"iss": "https://evil.example"})), ErrIssuer},
+		{"expired within skew", sign(t, rsaKey, claims(Claims{"exp": float64(now.Add(-10 * time.Second).Unix())})), nil},
+		{"expired beyond skew", sign(t, rsaKey, claims(Claims{"exp": float64(now.Add(-time.Minute).Unix())})), ErrExpired},
+		{"not valid yet within skew", sign(t, rsaKey, claims(Claims{"nbf": float64(now.Add(10 * time.Second).Unix())})), nil},
+		{"not valid yet beyond skew", sign(t, rsaKey, claims(Claims{"nbf": float64(now.Add(time.Minute).Unix())})), ErrNotYetValid},
+		{"no expiry", sign(t, rsaKey, claims(Claims{"exp": nil})), ErrMalformed},
+		{"unknown key", sign(t, unpublished, claims(nil)), ErrUnknownKey},
+		{"key published under another kid", signAs(t, unpublished, "rsa-1", claims(nil)), ErrSignature},
+		{"algorithm not allowed for the key", resign(t, sign(t, rsaKey, claims(nil)), "RS384"), ErrSignature},
+		{"unsupported algorithm", resign(t, sign(t, rsaKey, claims(nil)), "none"), ErrUnsupportedAlgorithm},
+		{"tampered payload", tamper(t, sign(t, ecKey, claims(nil))), ErrSignature},
+		{"malformed", "not-a-token", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(context.Background(), tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.err)
+			}
+			if err == nil && got.String("sub") != "user-1" {
+				t.Fatalf("Verify claims = %v", got)
+			}
+		})
+	}
+}
+
+func signAs(t *testing.T, key testKey, kid string, claims Claims) string {
+	key.id = kid
+	return sign(t, key, claims)
+}
+
+// resign swaps the alg of a token's header, keeping its signature.
+func resign(t *testing.T, token string, alg string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	header := `{"alg":"` + alg + `","kid":"rsa-1","typ":"JWT"}`
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(header))
+	return strings.Join(parts, ".")
+}
+
+// tamper replaces a token's payload, keeping its signature.
+func tamper(t *testing.T, token string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), "user-1", "admin", 1)))
+	return strings.Join(parts, ".")
+}
+
+func TestKeyRotation(t *testing.T) {
+	current, _ := newTestKeys(t)
+	next, _ := newTestKeys(t)
+	next.id = "rsa-2"
+
+	path := writeKeySet(t, "", current.public())
+	opts := Options{Issuer: "issuer", Audience: "api"}
+	claims := Claims{"iss": "issuer", "aud": "api", "exp": float64(time.Now().Add(time.Hour).Unix())}
+
+	verifier := NewVerifier(NewKeySource(path, 0, 0), opts)
+	if _, err := verifier.Verify(context.Background(), sign(t, current, claims)); err != nil {
+		t.Fatalf("current key: %v", err)
+	}
+
+	// The issuer publishes the next key and starts signing with it.
+	writeKeySet(t, path, current.public(), next.public())
+	if _, err := verifier.Verify(context.Background(), sign(t, next, claims)); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), sign(t, current, claims)); err != nil {
+		t.Fatalf("previous key during rotation: %v", err)
+	}
+
+	// Unknown kids reload the key set at most every minRefresh.
+	throttled := NewVerifier(NewKeySource(path, 0, time.Hour), opts)
+	if _, err := throttled.Verify(context.Background(), sign(t, current, claims)); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := newTestKeys(t)
+	third.id = "rsa-3"
+	writeKeySet(t, path, next.public(), third.public())
+	if _, err := throttled.Verify(context.Background(), sign(t, third, claims)); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("throttled reload error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeySetUnavailable(t *testing.T) {
+	verifier := NewVerifier(NewKeySource(filepath.Join(t.TempDir(), "missing.json"), 0, 0), Options{})
+	if _, err := verifier.Verify(context.Background(), resign(t, "e30.e30.AA", "RS256")); !errors.Is(err, ErrKeySetUnavailable) {
+		t.Fatalf("Verify error = %v, want ErrKeySetUnavailable", err)
+	}
+}
+
+func TestParseKeySet(t *testing.T) {
+	_, ecKey := newTestKeys(t)
+	published, err := MarshalKeySet(ecKey.public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := strings.Replace(string(published), `"keys": [`, `"keys": [{"kty":"EC","use":"enc","crv":"P-256"},{"kty":"oct","k":"c2VjcmV0"},`, 1)
+
+	tests := []struct {
+		name string
+		jwks string
+		keys int
+		ok   bool
+	}{
+		{"published keys", string(published), 1, true},
+		{"encryption and symmetric keys are skipped", mixed, 1, true},
+		{"no signature keys", `{"keys":[{"kty":"EC","use":"enc","crv":"P-256"},{"kty":"oct","k":"c2VjcmV0"}]}`, 0, false},
+		{"unsupported curve", `{"keys":[{"kty":"EC","crv":"P-192","x":"AA","y":"AA"}]}`, 0, false},
+		{"point not on curve", `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`, 0, false},
+		{"short RSA modulus", `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`, 0, false},
+		{"not JSON", `keys`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseKeySet([]byte(tt.jwks))
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseKeySet error = %v, want ok = %v", err, tt.ok)
+			}
+			if err == nil && len(set.Lookup("")) != tt.keys {
+				t.Fatalf("ParseKeySet returned %d keys, want %d", len(set.Lookup("")), tt.keys)
+			}
+		})
+	}
+}
+
+func TestClaimsStrings(t *testing.T) {
+	claims := Claims{
+		"aud":          "api",
+		"realm_access": map[string]any{"roles": []any{"admin", 7, "viewer"}},
+	}
+	if got := claims.Strings("aud"); len(got) != 1 || got[0] != "api" {
+		t.Fatalf("Strings(aud) = %v", got)
+	}
+	if got := claims.Strings("realm_access.roles"); len(got) != 2 || got[0] != "admin" || got[1] != "viewer" {
+		t.Fatalf("Strings(realm_access.roles) = %v", got)
+	}
+	if got := claims.Strings("realm_access.missing"); got != nil {
+		t.Fatalf("Strings(realm_access.missing) = %v", got)
+	}
+}
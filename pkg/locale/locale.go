@@ -0,0 +1,135 @@
+// Package locale normalizes language tags and negotiates between the
+// languages a client prefers and those a resource is available in.
+package locale
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidTag = errors.New("invalid language tag")
+
+// Canonical normalizes a language tag such as "id_id" or "zh-hant-tw" to the
+// conventional casing "id-ID" or "zh-Hant-TW". Only the language, script and
+// region subtags are accepted.
+func Canonical(tag string) (string, error) {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+
+	lang := strings.ToLower(parts[0])
+	if len(lang) < 2 || len(lang) > 3 || !isAlpha(lang) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+
+	canonical := []string{lang}
+	rest := parts[1:]
+	if len(rest) > 0 && len(rest[0]) == 4 && isAlpha(rest[0]) {
+		canonical = append(canonical, strings.ToUpper(rest[0][:1])+strings.ToLower(rest[0][1:]))
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		switch region := rest[0]; {
+		case len(region) == 2 && isAlpha(region):
+			canonical = append(canonical, strings.ToUpper(region))
+		case len(region) == 3 && isDigit(region):
+			canonical = append(canonical, region)
+		default:
+			return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+
+	return strings.Join(canonical, "-"), nil
+}
+
+// ParseAcceptLanguage returns the canonical tags of an Accept-Language header
+// ordered by decreasing quality. Wildcards, refused languages and malformed
+// entries are skipped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var entries []weighted
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 || tag == "*" {
+			continue
+		}
+		canonical, err := Canonical(tag)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, weighted{tag: canonical, q: q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	tags := make([]string, len(entries))
+	for i, e := range entries {
+		tags[i] = e.tag
+	}
+	return tags
+}
+
+// Match returns the available tag that best serves the preferred tags, tried
+// in order. A preferred tag matches an identical tag first, then a less
+// specific one ("en" for "en-US"), then any tag of the same language ("en-GB"
+// for "en" or "en-US"). All tags must be canonical.
+func Match(preferred []string, available []string) (string, bool) {
+	for _, want := range preferred {
+		for _, have := range available {
+			if have == want {
+				return have, true
+			}
+		}
+		for _, have := range available {
+			if strings.HasPrefix(want, have+"-") {
+				return have, true
+			}
+		}
+		for _, have := range available {
+			if language(have) == language(want) {
+				return have, true
+			}
+		}
+	}
+	return "", false
+}
+
+func language(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	return lang
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
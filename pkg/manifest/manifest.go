@@ -0,0 +1,148 @@
+// Package manifest signs canonical JSON manifests with Ed25519 keys, so
+// devices can verify update responses regardless of the servers and proxies
+// they passed through.
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Algorithm names the signature algorithm of every signature.
+const Algorithm = "ed25519"
+
+// Key is an Ed25519 signing key. Its ID is derived from the public key.
+type Key struct {
+	ID      string
+	Private ed25519.PrivateKey
+}
+
+// Public returns the public key of the key.
+func (k Key) Public() ed25519.PublicKey {
+	return k.Private.Public().(ed25519.PublicKey)
+}
+
+// Signature is a detached signature of a manifest by one key.
+type Signature struct {
+	KeyID     string
+	Signature []byte
+}
+
+// KeyID returns the ID of a public key: the first 8 bytes of its SHA-256
+// digest in hex.
+func KeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadKey reads a PKCS #8 PEM Ed25519 private key, as written by
+// "openssl genpkey -algorithm ed25519".
+func LoadKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return Key{}, fmt.Errorf("%s is not a PEM private key", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid signing key %s: %w", path, err)
+	}
+	private, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return Key{}, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+
+	key := Key{Private: private}
+	key.ID = KeyID(key.Public())
+	return key, nil
+}
+
+// LoadKeys reads the keys of comma separated paths.
+func LoadKeys(paths string) ([]Key, error) {
+	var keys []Key
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		key, err := LoadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Signer signs manifests with every one of its keys. Keeping the outgoing and
+// the incoming key configured while devices learn the new one lets keys
+// rotate without devices ever seeing an update they cannot verify.
+type Signer struct {
+	keys []Key
+}
+
+func NewSigner(keys []Key) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if seen[k.ID] {
+			return nil, fmt.Errorf("signing key %s is configured twice", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return &Signer{keys: keys}, nil
+}
+
+// Keys returns the signing keys.
+func (s *Signer) Keys() []Key {
+	return s.keys
+}
+
+// Sign returns the signatures of payload by every key.
+func (s *Signer) Sign(payload []byte) []Signature {
+	signatures := make([]Signature, 0, len(s.keys))
+	for _, k := range s.keys {
+		signatures = append(signatures, Signature{KeyID: k.ID, Signature: ed25519.Sign(k.Private, payload)})
+	}
+	return signatures
+}
+
+// Canonicalize encodes v as canonical JSON: object keys sorted, no
+// insignificant whitespace and no HTML escaping, so the same manifest always
+// has the same bytes.
+func Canonicalize(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Struct fields are encoded in declaration order; maps sort their keys
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
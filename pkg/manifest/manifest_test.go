@@ -0,0 +1,159 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newKey(t *testing.T) Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := Key{Private: private}
+	key.ID = KeyID(key.Public())
+	return key
+}
+
+func TestCanonicalize(t *testing.T) {
+	type release struct {
+		VersionCode int64             `json:"version_code"`
+		Name        string            `json:"name"`
+		Hashes      map[string]string `json:"hashes,omitempty"`
+		Notes       *string           `json:"notes"`
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"struct fields are sorted", release{VersionCode: 42, Name: "1.2"}, `{"name":"1.2","notes":null,"version_code":42}`},
+		{"nested maps are sorted", map[string]any{"b": map[string]int{"z": 1, "a": 2}, "a": []int{3, 1}}, `{"a":[3,1],"b":{"a":2,"z":1}}`},
+		{"HTML is not escaped", map[string]string{"url": "https://example.com/a?b=1&c=<2>"}, `{"url":"https://example.com/a?b=1&c=<2>"}`},
+		{"large integers keep their digits", map[string]int64{"size": 9007199254740993}, `{"size":9007199254740993}`},
+		{"unicode is kept", map[string]string{"name": "Café"}, `{"name":"Café"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("Canonicalize = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeIsStable(t *testing.T) {
+	a, err := Canonicalize(map[string]any{"x": 1, "y": map[string]any{"q": true, "p": "v"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type inner struct {
+		Q bool   `json:"q"`
+		P string `json:"p"`
+	}
+	type outer struct {
+		Y inner `json:"y"`
+		X int   `json:"x"`
+	}
+	b, err := Canonicalize(outer{Y: inner{Q: true, P: "v"}, X: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != string(b) {
+		t.Fatalf("equal documents encode differently: %s and %s", a, b)
+	}
+}
+
+func TestSignerSign(t *testing.T) {
+	outgoing, incoming := newKey(t), newKey(t)
+	signer, err := NewSigner([]Key{outgoing, incoming})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := Canonicalize(map[string]any{"ota_id": "1", "sha256": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatures := signer.Sign(payload)
+	if len(signatures) != 2 {
+		t.Fatalf("got %d signatures, want one per key", len(signatures))
+	}
+
+	tampered, err := Canonicalize(map[string]any{"ota_id": "1", "sha256": "abd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range []Key{outgoing, incoming} {
+		sig := signatures[i]
+		if sig.KeyID != key.ID {
+			t.Fatalf("signature %d has key ID %s, want %s", i, sig.KeyID, key.ID)
+		}
+		if !ed25519.Verify(key.Public(), payload, sig.Signature) {
+			t.Fatalf("signature by %s does not verify", key.ID)
+		}
+		if ed25519.Verify(key.Public(), tampered, sig.Signature) {
+			t.Fatalf("signature by %s verifies a tampered payload", key.ID)
+		}
+	}
+	if ed25519.Verify(incoming.Public(), payload, signatures[0].Signature) {
+		t.Fatal("signature verifies with another key")
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	key := newKey(t)
+	if _, err := NewSigner(nil); err == nil {
+		t.Fatal("expected an error without keys")
+	}
+	if _, err := NewSigner([]Key{key, key}); err == nil {
+		t.Fatal("expected an error for a key configured twice")
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	key := newKey(t)
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := writePEM("key.pem", "PRIVATE KEY", der)
+	wrongType := writePEM("cert.pem", "CERTIFICATE", der)
+	garbage := writePEM("garbage.pem", "PRIVATE KEY", []byte("not a key"))
+
+	keys, err := LoadKeys(" " + valid + ", ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID != key.ID || !keys[0].Private.Equal(key.Private) {
+		t.Fatalf("LoadKeys = %+v", keys)
+	}
+
+	for _, path := range []string{wrongType, garbage, filepath.Join(dir, "missing.pem")} {
+		if _, err := LoadKey(path); err == nil {
+			t.Fatalf("LoadKey(%s) succeeded", filepath.Base(path))
+		}
+	}
+}
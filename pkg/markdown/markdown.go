@@ -0,0 +1,249 @@
+// Package markdown renders the subset of Markdown used in release notes to
+// HTML and to plain text.
+//
+// Supported are ATX headings, paragraphs, bullet and ordered lists, block
+// quotes, fenced code blocks, thematic breaks, and inline code, links,
+// **strong** and *emphasis*. Raw HTML is not supported: all source text is
+// escaped and only http, https and mailto links are emitted, so the HTML
+// output is safe to show in a WebView without further sanitizing.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type blockKind int
+
+const (
+	paragraph blockKind = iota
+	heading
+	bulletList
+	orderedList
+	quote
+	code
+	rule
+)
+
+type block struct {
+	kind  blockKind
+	level int // heading level, or first number of an ordered list
+	lines []string
+	items []string
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedPattern = regexp.MustCompile(`^\s*(\d{1,9})[.)]\s+(.*)$`)
+	rulePattern    = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	quotePattern   = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	fencePattern   = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+
+	inlinePattern = regexp.MustCompile("`([^`]+)`|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+	strongPattern = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	emPattern     = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+)
+
+func parse(src string) []block {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+
+	var blocks []block
+	var current *block
+	flush := func() {
+		if current != nil {
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			flush()
+			b := block{kind: code}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				b.lines = append(b.lines, lines[i])
+			}
+			blocks = append(blocks, b)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			blocks = append(blocks, block{kind: heading, level: len(m[1]), lines: []string{m[2]}})
+			continue
+		}
+
+		if rulePattern.MatchString(line) {
+			flush()
+			blocks = append(blocks, block{kind: rule})
+			continue
+		}
+
+		if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			if current == nil || current.kind != bulletList {
+				flush()
+				current = &block{kind: bulletList}
+			}
+			current.items = append(current.items, m[1])
+			continue
+		}
+
+		if m := orderedPattern.FindStringSubmatch(line); m != nil {
+			if current == nil || current.kind != orderedList {
+				flush()
+				start, _ := strconv.Atoi(m[1])
+				current = &block{kind: orderedList, level: start}
+			}
+			current.items = append(current.items, m[2])
+			continue
+		}
+
+		if m := quotePattern.FindStringSubmatch(line); m != nil {
+			if current == nil || current.kind != quote {
+				flush()
+				current = &block{kind: quote}
+			}
+			current.lines = append(current.lines, m[1])
+			continue
+		}
+
+		text := strings.TrimSpace(line)
+		switch {
+		case current != nil && (current.kind == bulletList || current.kind == orderedList):
+			// A lazy continuation line belongs to the last list item
+			current.items[len(current.items)-1] += "\n" + text
+		case current != nil && (current.kind == paragraph || current.kind == quote):
+			current.lines = append(current.lines, text)
+		default:
+			flush()
+			current = &block{kind: paragraph, lines: []string{text}}
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// ToHTML renders Markdown to HTML.
+func ToHTML(src string) string {
+	var b strings.Builder
+	for _, blk := range parse(src) {
+		switch blk.kind {
+		case heading:
+			tag := "h" + strconv.Itoa(blk.level)
+			b.WriteString("<" + tag + ">" + inlineHTML(blk.lines[0]) + "</" + tag + ">\n")
+		case paragraph:
+			b.WriteString("<p>" + inlineHTML(strings.Join(blk.lines, "\n")) + "</p>\n")
+		case quote:
+			b.WriteString("<blockquote><p>" + inlineHTML(strings.Join(blk.lines, "\n")) + "</p></blockquote>\n")
+		case bulletList, orderedList:
+			open, end := "<ul>", "</ul>"
+			if blk.kind == orderedList {
+				open, end = "<ol>", "</ol>"
+				if blk.level != 1 {
+					open = `<ol start="` + strconv.Itoa(blk.level) + `">`
+				}
+			}
+			b.WriteString(open + "\n")
+			for _, item := range blk.items {
+				b.WriteString("<li>" + inlineHTML(item) + "</li>\n")
+			}
+			b.WriteString(end + "\n")
+		case code:
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(blk.lines, "\n")) + "</code></pre>\n")
+		case rule:
+			b.WriteString("<hr>\n")
+		}
+	}
+	return b.String()
+}
+
+// ToText renders Markdown to plain text: markup is removed, list items are
+// bulleted or numbered and links are followed by their URL.
+func ToText(src string) string {
+	var parts []string
+	for _, blk := range parse(src) {
+		switch blk.kind {
+		case heading:
+			parts = append(parts, inlineText(blk.lines[0]))
+		case paragraph, quote:
+			parts = append(parts, inlineText(strings.Join(blk.lines, "\n")))
+		case bulletList, orderedList:
+			items := make([]string, len(blk.items))
+			for i, item := range blk.items {
+				marker := "• "
+				if blk.kind == orderedList {
+					marker = strconv.Itoa(blk.level+i) + ". "
+				}
+				items[i] = marker + strings.ReplaceAll(inlineText(item), "\n", "\n  ")
+			}
+			parts = append(parts, strings.Join(items, "\n"))
+		case code:
+			parts = append(parts, strings.Join(blk.lines, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func inlineHTML(s string) string {
+	return renderInline(s, func(text string) string {
+		text = html.EscapeString(text)
+		text = strongPattern.ReplaceAllString(text, "<strong>$1$2</strong>")
+		return emPattern.ReplaceAllString(text, "<em>$1</em>")
+	}, func(codeText string) string {
+		return "<code>" + html.EscapeString(codeText) + "</code>"
+	}, func(text, url string, safe bool) string {
+		if !safe {
+			return text
+		}
+		return `<a href="` + html.EscapeString(url) + `">` + text + "</a>"
+	})
+}
+
+func inlineText(s string) string {
+	return renderInline(s, func(text string) string {
+		text = strongPattern.ReplaceAllString(text, "$1$2")
+		return emPattern.ReplaceAllString(text, "$1")
+	}, func(codeText string) string {
+		return codeText
+	}, func(text, url string, safe bool) string {
+		if !safe || text == url {
+			return text
+		}
+		return text + " (" + url + ")"
+	})
+}
+
+// renderInline splits s into code spans, links and the text around them and
+// renders each with the given function. Link text is rendered as text first.
+func renderInline(s string, text func(string) string, codeSpan func(string) string, link func(text, url string, safe bool) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inlinePattern.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(text(s[last:m[0]]))
+		if m[2] >= 0 {
+			b.WriteString(codeSpan(s[m[2]:m[3]]))
+		} else {
+			url := s[m[6]:m[7]]
+			b.WriteString(link(text(s[m[4]:m[5]]), url, safeURL(url)))
+		}
+		last = m[1]
+	}
+	b.WriteString(text(s[last:]))
+	return b.String()
+}
+
+func safeURL(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "mailto:")
+}
@@ -0,0 +1,224 @@
+// Package mtls configures TLS servers that authenticate clients by
+// certificates of a client CA, rejecting certificates revoked by its CRL.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crlCheckInterval bounds how often the CRL file is checked for changes.
+const crlCheckInterval = time.Minute
+
+var (
+	ErrRevoked    = errors.New("client certificate is revoked")
+	ErrCRLExpired = errors.New("CRL has expired")
+)
+
+// Identity fields a device ID can be taken from.
+const (
+	FieldCommonName   = "cn"
+	FieldSerialNumber = "serial_number"
+	FieldDNSName      = "dns"
+	FieldURI          = "uri"
+)
+
+// IsValidField reports whether a device ID can be taken from field.
+func IsValidField(field string) bool {
+	switch field {
+	case FieldCommonName, FieldSerialNumber, FieldDNSName, FieldURI:
+		return true
+	}
+	return false
+}
+
+// Options configures the TLS server.
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM certificates of the CAs client certificates
+	// must chain to. Client certificates are not requested when it is empty.
+	ClientCAFile string
+	// ClientCRLFile holds the PEM or DER CRL of the client CA, which must
+	// then be the only one. It is reloaded when it changes.
+	ClientCRLFile string
+	// RequireClientCert rejects connections without a client certificate.
+	// Otherwise a certificate is verified only when one is sent.
+	RequireClientCert bool
+}
+
+// ServerConfig returns the TLS configuration of a server.
+func ServerConfig(opts Options) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if opts.ClientCAFile == "" {
+		if opts.ClientCRLFile != "" || opts.RequireClientCert {
+			return nil, errors.New("a client CA is required to verify client certificates")
+		}
+		return config, nil
+	}
+
+	caPEM, err := os.ReadFile(opts.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("client CA file has no PEM certificates")
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	if opts.RequireClientCert {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if opts.ClientCRLFile != "" {
+		crl := &crlFile{path: opts.ClientCRLFile}
+		if err := crl.load(); err != nil {
+			return nil, err
+		}
+		config.VerifyPeerCertificate = func(_ [][]byte, chains [][]*x509.Certificate) error {
+			return crl.check(chains)
+		}
+	}
+
+	return config, nil
+}
+
+// DeviceID returns the device ID a verified client certificate carries in
+// field, or false when the connection has no verified client certificate.
+func DeviceID(state *tls.ConnectionState, field string) (string, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	cert := state.VerifiedChains[0][0]
+
+	var id string
+	switch field {
+	case FieldCommonName:
+		id = cert.Subject.CommonName
+	case FieldSerialNumber:
+		id = cert.Subject.SerialNumber
+	case FieldDNSName:
+		if len(cert.DNSNames) > 0 {
+			id = cert.DNSNames[0]
+		}
+	case FieldURI:
+		if len(cert.URIs) > 0 {
+			id = cert.URIs[0].String()
+		}
+	}
+	id = strings.TrimSpace(id)
+	return id, id != ""
+}
+
+// crlFile is a CRL read from a file, reloaded when the file changes.
+type crlFile struct {
+	path string
+
+	mu        sync.Mutex
+	modTime   time.Time
+	checkedAt time.Time
+	crl       *x509.RevocationList
+	revoked   map[string]bool
+}
+
+func (f *crlFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read CRL: %w", err)
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read CRL: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return fmt.Errorf("CRL file holds a %s", block.Type)
+		}
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return fmt.Errorf("invalid CRL: %w", err)
+	}
+
+	revoked := make(map[string]bool, len(crl.RevokedCertificateEntries))
+	for _, entry := range crl.RevokedCertificateEntries {
+		revoked[serialKey(entry.SerialNumber)] = true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.crl = crl
+	f.revoked = revoked
+	f.modTime = info.ModTime()
+	f.checkedAt = time.Now()
+	return nil
+}
+
+// current returns the CRL, reloading it first when the file has changed. A
+// failed reload keeps the previous CRL.
+func (f *crlFile) current() (*x509.RevocationList, map[string]bool) {
+	f.mu.Lock()
+	reload := false
+	if time.Since(f.checkedAt) >= crlCheckInterval {
+		f.checkedAt = time.Now()
+		info, err := os.Stat(f.path)
+		reload = err == nil && !info.ModTime().Equal(f.modTime)
+	}
+	f.mu.Unlock()
+
+	if reload {
+		if err := f.load(); err != nil {
+			log.Printf("Failed to reload CRL, keeping the previous one: %v", err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.crl, f.revoked
+}
+
+// check rejects a verified chain whose leaf the CRL revokes. The CRL must be
+// signed by the CA that issued the leaf and must not be past its next update.
+func (f *crlFile) check(chains [][]*x509.Certificate) error {
+	if len(chains) == 0 || len(chains[0]) < 2 {
+		return nil
+	}
+	leaf, issuer := chains[0][0], chains[0][1]
+
+	crl, revoked := f.current()
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return fmt.Errorf("CRL is not signed by the issuer of the client certificate: %w", err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		log.Printf("Rejecting client certificates: the CRL expired at %s", crl.NextUpdate.Format(time.RFC3339))
+		return ErrCRLExpired
+	}
+	if revoked[serialKey(leaf.SerialNumber)] {
+		return fmt.Errorf("%w: serial %s", ErrRevoked, leaf.SerialNumber.Text(16))
+	}
+
+	return nil
+}
+
+func serialKey(serial *big.Int) string {
+	return serial.Text(16)
+}
@@ -0,0 +1,222 @@
+// Package payload validates the non-APK packages shipped through OTA releases.
+package payload
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxConfigBundleSize bounds config bundles, which devices parse in memory.
+const MaxConfigBundleSize = 1 << 20
+
+var ErrInvalid = errors.New("invalid payload")
+
+// ThemeManifest is the theme.json at the root of a theme pack.
+type ThemeManifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Author  string `json:"author,omitempty"`
+}
+
+// ValidateThemePack checks a theme pack: a zip archive with a theme.json
+// naming the theme. It returns the metadata to show for the release.
+func ValidateThemePack(r io.ReaderAt, size int64) (map[string]string, error) {
+	zr, err := openZip(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	f := findFile(zr, "theme.json")
+	if f == nil {
+		return nil, fmt.Errorf("%w: theme.json not found", ErrInvalid)
+	}
+
+	data, err := readFile(f, MaxConfigBundleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ThemeManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: theme.json: %v", ErrInvalid, err)
+	}
+	if strings.TrimSpace(manifest.Name) == "" {
+		return nil, fmt.Errorf("%w: theme.json has no name", ErrInvalid)
+	}
+
+	info := map[string]string{"theme_name": manifest.Name}
+	if manifest.Version != "" {
+		info["theme_version"] = manifest.Version
+	}
+	if manifest.Author != "" {
+		info["theme_author"] = manifest.Author
+	}
+
+	return info, nil
+}
+
+// ValidateWallpaperPack checks a wallpaper pack: a zip archive holding only
+// PNG, JPEG or WebP images, recognised by their content.
+func ValidateWallpaperPack(r io.ReaderAt, size int64) (map[string]string, error) {
+	zr, err := openZip(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	images := 0
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+		}
+		head := make([]byte, 12)
+		n, _ := io.ReadFull(rc, head)
+		rc.Close()
+
+		if !isImage(head[:n]) {
+			return nil, fmt.Errorf("%w: %s is not a PNG, JPEG or WebP image", ErrInvalid, f.Name)
+		}
+		images++
+	}
+
+	if images == 0 {
+		return nil, fmt.Errorf("%w: wallpaper pack contains no images", ErrInvalid)
+	}
+
+	return map[string]string{"wallpapers": strconv.Itoa(images)}, nil
+}
+
+// ValidateConfigBundle checks a config bundle: a single JSON object.
+func ValidateConfigBundle(r io.ReaderAt, size int64) (map[string]string, error) {
+	if size > MaxConfigBundleSize {
+		return nil, fmt.Errorf("%w: config bundle exceeds %d bytes", ErrInvalid, MaxConfigBundleSize)
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var bundle map[string]json.RawMessage
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: config bundle must be a JSON object: %v", ErrInvalid, err)
+	}
+
+	return map[string]string{"keys": strconv.Itoa(len(bundle))}, nil
+}
+
+// ValidateFirmware checks a firmware package: an Android OTA zip, either an
+// A/B package with payload.bin or a recovery package with an update-binary.
+// Every entry is read in full so a corrupted download is caught by its CRC.
+// Fields of META-INF/com/android/metadata are returned when present.
+func ValidateFirmware(r io.ReaderAt, size int64) (map[string]string, error) {
+	zr, err := openZip(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+		}
+	}
+
+	if findFile(zr, "payload.bin") == nil && findFile(zr, "META-INF/com/google/android/update-binary") == nil {
+		return nil, fmt.Errorf("%w: neither payload.bin nor an update-binary found", ErrInvalid)
+	}
+
+	info := map[string]string{}
+	if f := findFile(zr, "META-INF/com/android/metadata"); f != nil {
+		data, err := readFile(f, MaxConfigBundleSize)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			switch key {
+			case "ota-type", "pre-device", "post-build", "post-timestamp":
+				if ok {
+					info[key] = value
+				}
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// openZip opens a zip archive and rejects entries that would escape the
+// directory the device extracts it into.
+func openZip(r io.ReaderAt, size int64) (*zip.Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a zip archive: %v", ErrInvalid, err)
+	}
+
+	for _, f := range zr.File {
+		name := f.Name
+		if strings.HasPrefix(name, "/") || strings.Contains(name, "\\") || strings.HasPrefix(path.Clean(name), "../") || path.Clean(name) == ".." {
+			return nil, fmt.Errorf("%w: unsafe path %q", ErrInvalid, name)
+		}
+	}
+
+	return zr, nil
+}
+
+func findFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func readFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalid, f.Name, limit)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+	}
+	return data, nil
+}
+
+func isImage(head []byte) bool {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return true
+	case bytes.HasPrefix(head, []byte{0xff, 0xd8, 0xff}):
+		return true
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+// Package tuf builds and signs metadata in the format of The Update
+// Framework (https://theupdateframework.io), whose root, targets, snapshot
+// and timestamp roles let clients detect rollback, freeze and mix-and-match
+// attacks on the files they download.
+package tuf
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"launcherbackend_api/pkg/manifest"
+)
+
+// SpecVersion is the version of the specification the metadata follows.
+const SpecVersion = "1.0.31"
+
+// Header holds the fields every role's signed metadata starts with.
+type Header struct {
+	Type        string    `json:"_type"`
+	SpecVersion string    `json:"spec_version"`
+	Version     int       `json:"version"`
+	Expires     time.Time `json:"expires"`
+}
+
+// NewHeader returns the header of a role's metadata. Expiry times are given
+// in whole seconds in UTC, as the specification requires.
+func NewHeader(role string, version int, expires time.Time) Header {
+	if !expires.IsZero() {
+		expires = expires.UTC().Truncate(time.Second)
+	}
+	return Header{Type: role, SpecVersion: SpecVersion, Version: version, Expires: expires}
+}
+
+// PublicKey is a public key as listed by the root role.
+type PublicKey struct {
+	KeyType string `json:"keytype"`
+	Scheme  string `json:"scheme"`
+	KeyVal  KeyVal `json:"keyval"`
+}
+
+type KeyVal struct {
+	Public string `json:"public"`
+}
+
+// RoleKeys are the keys trusted for a role and how many must sign.
+type RoleKeys struct {
+	KeyIDs    []string `json:"keyids"`
+	Threshold int      `json:"threshold"`
+}
+
+type Root struct {
+	Header
+	ConsistentSnapshot bool                 `json:"consistent_snapshot"`
+	Keys               map[string]PublicKey `json:"keys"`
+	Roles              map[string]RoleKeys  `json:"roles"`
+}
+
+// TargetFile describes a file clients may download.
+type TargetFile struct {
+	Length int64             `json:"length"`
+	Hashes map[string]string `json:"hashes"`
+	Custom map[string]any    `json:"custom,omitempty"`
+}
+
+type Targets struct {
+	Header
+	Targets map[string]TargetFile `json:"targets"`
+}
+
+// MetaFile describes a metadata file listed by the snapshot or timestamp role.
+type MetaFile struct {
+	Version int               `json:"version"`
+	Length  int64             `json:"length,omitempty"`
+	Hashes  map[string]string `json:"hashes,omitempty"`
+}
+
+type Snapshot struct {
+	Header
+	Meta map[string]MetaFile `json:"meta"`
+}
+
+type Timestamp struct {
+	Header
+	Meta map[string]MetaFile `json:"meta"`
+}
+
+// Signature is a signature of the signed part of metadata.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Ed25519PublicKey returns the root role's description of an Ed25519 key.
+func Ed25519PublicKey(public ed25519.PublicKey) PublicKey {
+	return PublicKey{KeyType: "ed25519", Scheme: "ed25519", KeyVal: KeyVal{Public: hex.EncodeToString(public)}}
+}
+
+// KeyID returns the ID of a key: the SHA-256 digest of its canonical JSON.
+func KeyID(key PublicKey) (string, error) {
+	data, err := manifest.Canonicalize(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Trust adds keys to a root role's key list and returns their role entry,
+// trusting any one of them.
+func Trust(root *Root, keys []manifest.Key) (RoleKeys, error) {
+	if root.Keys == nil {
+		root.Keys = make(map[string]PublicKey)
+	}
+
+	role := RoleKeys{KeyIDs: []string{}, Threshold: 1}
+	for _, k := range keys {
+		public := Ed25519PublicKey(k.Public())
+		id, err := KeyID(public)
+		if err != nil {
+			return RoleKeys{}, err
+		}
+		root.Keys[id] = public
+		role.KeyIDs = append(role.KeyIDs, id)
+	}
+	return role, nil
+}
+
+// Sign returns the canonical JSON metadata document of signed, signed with
+// every key.
+func Sign(signed any, keys []manifest.Key) ([]byte, error) {
+	payload, err := manifest.Canonicalize(signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	signatures := make([]Signature, 0, len(keys))
+	for _, k := range keys {
+		id, err := KeyID(Ed25519PublicKey(k.Public()))
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, Signature{KeyID: id, Sig: hex.EncodeToString(ed25519.Sign(k.Private, payload))})
+	}
+
+	return manifest.Canonicalize(map[string]any{
+		"signatures": signatures,
+		"signed":     signed,
+	})
+}
+
+// Digest returns the SHA-256 digest of a metadata document as listed by the
+// snapshot and timestamp roles.
+func Digest(data []byte) map[string]string {
+	sum := sha256.Sum256(data)
+	return map[string]string{"sha256": hex.EncodeToString(sum[:])}
+}
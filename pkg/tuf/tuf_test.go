@@ -0,0 +1,190 @@
+package tuf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"launcherbackend_api/pkg/manifest"
+)
+
+func newKey(t *testing.T) manifest.Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := manifest.Key{Private: private}
+	key.ID = manifest.KeyID(key.Public())
+	return key
+}
+
+func TestKeyID(t *testing.T) {
+	public := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	for i := range public {
+		public[i] = byte(i)
+	}
+
+	id, err := KeyID(Ed25519PublicKey(public))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canonical := `{"keytype":"ed25519","keyval":{"public":"` + hex.EncodeToString(public) + `"},"scheme":"ed25519"}`
+	sum := sha256.Sum256([]byte(canonical))
+	if want := hex.EncodeToString(sum[:]); id != want {
+		t.Fatalf("KeyID = %s, want the digest of %s", id, canonical)
+	}
+}
+
+func TestSign(t *testing.T) {
+	keys := []manifest.Key{newKey(t), newKey(t)}
+
+	root := Root{Header: NewHeader("root", 1, time.Now().Add(24*time.Hour))}
+	role, err := Trust(&root, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.Roles = map[string]RoleKeys{"root": role, "targets": role, "snapshot": role, "timestamp": role}
+
+	targets := Targets{
+		Header: NewHeader("targets", 3, time.Now().Add(time.Hour)),
+		Targets: map[string]TargetFile{
+			"com.example.app/42.apk": {Length: 1024, Hashes: map[string]string{"sha256": "ab"}},
+		},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		signed any
+	}{
+		{"root", root},
+		{"targets", targets},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Sign(tt.signed, keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify(t, root, document, tt.signed)
+
+			tampered := bytes.Clone(document)
+			for i := bytes.Index(tampered, []byte(`"signed":`)); i < len(tampered); i++ {
+				if tampered[i] == '1' {
+					tampered[i] = '2'
+					break
+				}
+			}
+			if countValid(t, root, tampered) != 0 {
+				t.Fatal("signatures verify a tampered document")
+			}
+		})
+	}
+}
+
+// verify checks that every trusted key signed the canonical form of signed.
+func verify(t *testing.T, root Root, document []byte, signed any) {
+	t.Helper()
+
+	canonical, err := manifest.Canonicalize(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		Signed json.RawMessage `json:"signed"`
+	}
+	if err := json.Unmarshal(document, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if string(envelope.Signed) != string(canonical) {
+		t.Fatalf("signed part is not canonical:\n%s\nwant\n%s", envelope.Signed, canonical)
+	}
+	if again, err := manifest.Canonicalize(json.RawMessage(document)); err != nil || string(again) != string(document) {
+		t.Fatalf("document is not canonical JSON: %s", document)
+	}
+
+	if n := countValid(t, root, document); n != len(root.Keys) {
+		t.Fatalf("%d of %d trusted keys signed", n, len(root.Keys))
+	}
+}
+
+func countValid(t *testing.T, root Root, document []byte) int {
+	t.Helper()
+
+	var envelope struct {
+		Signatures []Signature     `json:"signatures"`
+		Signed     json.RawMessage `json:"signed"`
+	}
+	if err := json.Unmarshal(document, &envelope); err != nil {
+		return 0
+	}
+
+	valid := 0
+	for _, sig := range envelope.Signatures {
+		key, ok := root.Keys[sig.KeyID]
+		if !ok {
+			t.Fatalf("signature by untrusted key %s", sig.KeyID)
+		}
+		public, err := hex.DecodeString(key.KeyVal.Public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := hex.DecodeString(sig.Sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ed25519.Verify(public, envelope.Signed, signature) {
+			valid++
+		}
+	}
+	return valid
+}
+
+func TestTrust(t *testing.T) {
+	keys := []manifest.Key{newKey(t), newKey(t)}
+	var root Root
+
+	role, err := Trust(&root, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Threshold != 1 || len(role.KeyIDs) != 2 || len(root.Keys) != 2 {
+		t.Fatalf("Trust = %+v with %d root keys", role, len(root.Keys))
+	}
+	for i, id := range role.KeyIDs {
+		if root.Keys[id].KeyVal.Public != hex.EncodeToString(keys[i].Public()) {
+			t.Fatalf("root key %s is not key %d", id, i)
+		}
+	}
+
+	// Trusting the same key for another role reuses its entry.
+	if _, err := Trust(&root, keys[:1]); err != nil || len(root.Keys) != 2 {
+		t.Fatalf("Trust again: %v, %d root keys", err, len(root.Keys))
+	}
+}
+
+func TestNewHeader(t *testing.T) {
+	local := time.FixedZone("CET", 3600)
+	h := NewHeader("timestamp", 7, time.Date(2024, 5, 1, 13, 0, 0, 999, local))
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"_type":"timestamp","spec_version":"` + SpecVersion + `","version":7,"expires":"2024-05-01T12:00:00Z"}`
+	if string(data) != want {
+		t.Fatalf("header = %s, want %s", data, want)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	got := Digest([]byte("abc"))
+	if got["sha256"] != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" || len(got) != 1 {
+		t.Fatalf("Digest = %v", got)
+	}
+}
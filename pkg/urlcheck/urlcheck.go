@@ -0,0 +1,218 @@
+// Package urlcheck verifies that an external artifact URL is reachable and
+// serves what it is expected to.
+package urlcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInsecure       = errors.New("URL must use HTTPS")
+	ErrHostNotAllowed = errors.New("URL host is not allowed")
+	ErrInternalHost   = errors.New("URL host resolves to an internal address")
+	ErrUnreachable    = errors.New("URL is not reachable")
+	ErrContentType    = errors.New("URL serves an unexpected content type")
+	ErrContentLength  = errors.New("URL serves an unexpected content length")
+	ErrDigestMismatch = errors.New("URL content does not match the expected SHA-256")
+)
+
+// Options describe what the content behind a URL must look like.
+type Options struct {
+	// ContentTypes lists the accepted media types. Empty accepts any.
+	ContentTypes []string
+	// SHA256 is the expected hex digest of the content. When set the content is
+	// downloaded and hashed.
+	SHA256 string
+	// ComputeDigest downloads and hashes the content even without an expected digest.
+	ComputeDigest bool
+}
+
+// Result describes the content behind a URL.
+type Result struct {
+	ContentType   string
+	ContentLength int64
+	// SHA256 is only set when the content was downloaded.
+	SHA256 string
+}
+
+type Checker struct {
+	client       *http.Client
+	allowedHosts []string
+	maxSize      int64
+}
+
+// NewChecker creates a checker. allowedHosts lists the hosts URLs may point
+// at; an entry starting with a dot also allows its subdomains, and an empty
+// list allows any host. Content larger than maxSize bytes is rejected.
+func NewChecker(allowedHosts []string, maxSize int64, timeout time.Duration) *Checker {
+	c := &Checker{
+		allowedHosts: allowedHosts,
+		maxSize:      maxSize,
+	}
+	// The address is checked after DNS resolution, right before connecting,
+	// so a host cannot resolve to a public address for the check and to an
+	// internal one for the request. Environment proxies would hide the
+	// address, so none is used.
+	dialer := &net.Dialer{Timeout: timeout, Control: checkAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	c.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return c.checkURL(req.URL)
+		},
+	}
+	return c
+}
+
+// ParseHosts parses a comma separated list of hosts.
+func ParseHosts(s string) []string {
+	var hosts []string
+	for _, host := range strings.Split(s, ",") {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// Check requests the URL and verifies its scheme, host, status, content type
+// and length, and digest when asked to.
+func (c *Checker) Check(ctx context.Context, rawURL string, opts Options) (Result, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	if err := c.checkURL(u); err != nil {
+		return Result{}, err
+	}
+
+	download := opts.SHA256 != "" || opts.ComputeDigest
+
+	var resp *http.Response
+	if download {
+		resp, err = c.do(ctx, http.MethodGet, rawURL)
+	} else {
+		// Not every server implements HEAD; presigned object store URLs in
+		// particular are often only valid for GET
+		resp, err = c.do(ctx, http.MethodHead, rawURL)
+		if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented || resp.StatusCode == http.StatusForbidden) {
+			resp.Body.Close()
+			resp, err = c.do(ctx, http.MethodGet, rawURL)
+		}
+	}
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && (errors.Is(urlErr.Err, ErrInsecure) || errors.Is(urlErr.Err, ErrHostNotAllowed)) {
+			return Result{}, fmt.Errorf("redirect rejected: %w", urlErr.Err)
+		}
+		if errors.Is(err, ErrInternalHost) {
+			return Result{}, fmt.Errorf("request rejected: %w", err)
+		}
+		return Result{}, fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Result{}, fmt.Errorf("%w: server answered %s", ErrUnreachable, resp.Status)
+	}
+
+	result := Result{ContentLength: resp.ContentLength}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		result.ContentType = mediaType
+	}
+	if len(opts.ContentTypes) > 0 && !slices.Contains(opts.ContentTypes, result.ContentType) {
+		return Result{}, fmt.Errorf("%w: %q, expected one of %s", ErrContentType, result.ContentType, strings.Join(opts.ContentTypes, ", "))
+	}
+
+	if result.ContentLength <= 0 {
+		return Result{}, fmt.Errorf("%w: server did not report a content length", ErrContentLength)
+	}
+	if c.maxSize > 0 && result.ContentLength > c.maxSize {
+		return Result{}, fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrContentLength, result.ContentLength, c.maxSize)
+	}
+
+	if !download {
+		return result, nil
+	}
+
+	digest := sha256.New()
+	n, err := io.Copy(digest, io.LimitReader(resp.Body, result.ContentLength+1))
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	if n != result.ContentLength {
+		return Result{}, fmt.Errorf("%w: received %d of %d bytes", ErrContentLength, n, result.ContentLength)
+	}
+
+	result.SHA256 = hex.EncodeToString(digest.Sum(nil))
+	if opts.SHA256 != "" && !strings.EqualFold(opts.SHA256, result.SHA256) {
+		return Result{}, fmt.Errorf("%w: got %s", ErrDigestMismatch, result.SHA256)
+	}
+
+	return result, nil
+}
+
+func (c *Checker) do(ctx context.Context, method string, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+func (c *Checker) checkURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return ErrInsecure
+	}
+	if len(c.allowedHosts) == 0 {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range c.allowedHosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && (strings.HasSuffix(host, allowed) || host == allowed[1:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+}
+
+// checkAddress keeps requests away from the server's own network: loopback,
+// private, link-local and other non-public addresses are refused.
+func checkAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInternalHost, address)
+	}
+	addr := addrPort.Addr().Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || cgnat.Contains(addr) {
+		return fmt.Errorf("%w: %s", ErrInternalHost, addr)
+	}
+	return nil
+}
+
+// cgnat is the shared address space of carrier-grade NAT, which
+// netip.Addr.IsPrivate does not cover.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
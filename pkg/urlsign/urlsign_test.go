@@ -0,0 +1,107 @@
+package urlsign
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	old := Key{ID: "k1", Secret: []byte("0123456789abcdef")}
+	current := Key{ID: "k2", Secret: []byte("fedcba9876543210")}
+
+	signer, err := NewSigner([]Key{old, current}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := NewSigner([]Key{old}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger, err := NewSigner([]Key{{ID: "k2", Secret: []byte("another secret!!")}}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource := "/api/v1/download/ota/1"
+	expires := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		signer   *Signer
+		resource string
+		tamper   func(q url.Values)
+		now      time.Time
+		err      error
+	}{
+		{name: "valid", signer: signer, resource: resource, now: now},
+		{name: "valid until expiry", signer: signer, resource: resource, now: expires},
+		{name: "signed with a retired key", signer: retired, resource: resource, now: now},
+		{name: "expired", signer: signer, resource: resource, now: expires.Add(time.Second), err: ErrExpired},
+		{name: "other resource", signer: signer, resource: "/api/v1/download/ota/2", now: now, err: ErrInvalidSignature},
+		{name: "other device", signer: signer, resource: resource, now: now, err: ErrInvalidSignature,
+			tamper: func(q url.Values) { q["device_id"] = []string{"device-2"} }},
+		{name: "extended expiry", signer: signer, resource: resource, now: now, err: ErrInvalidSignature,
+			tamper: func(q url.Values) { q["expires"] = []string{"1900000000"} }},
+		{name: "malformed expiry", signer: signer, resource: resource, now: now, err: ErrInvalidSignature,
+			tamper: func(q url.Values) { q["expires"] = []string{"soon"} }},
+		{name: "altered signature", signer: signer, resource: resource, now: now, err: ErrInvalidSignature,
+			tamper: func(q url.Values) { q["sig"] = []string{q["sig"][0][1:] + "A"} }},
+		{name: "missing signature", signer: signer, resource: resource, now: now, err: ErrMissingSignature,
+			tamper: func(q url.Values) { delete(q, "sig") }},
+		{name: "unknown key", signer: signer, resource: resource, now: now, err: ErrUnknownKey,
+			tamper: func(q url.Values) { q["kid"] = []string{"k3"} }},
+		{name: "signed with another secret", signer: stranger, resource: resource, now: now, err: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.signer.Sign(resource, "device-1", expires)
+			if tt.tamper != nil {
+				tt.tamper(query)
+			}
+			if err := signer.Verify(tt.resource, query, tt.now); !errors.Is(err, tt.err) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []Key
+		active string
+		ok     bool
+	}{
+		{"valid", []Key{{ID: "k1", Secret: []byte("0123456789abcdef")}}, "k1", true},
+		{"short secret", []Key{{ID: "k1", Secret: []byte("short")}}, "k1", false},
+		{"missing ID", []Key{{Secret: []byte("0123456789abcdef")}}, "", false},
+		{"active key not configured", []Key{{ID: "k1", Secret: []byte("0123456789abcdef")}}, "k2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(tt.keys, tt.active); (err == nil) != tt.ok {
+				t.Fatalf("NewSigner error = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys(" k1:first-secret , k2:second:secret,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != "k1" || string(keys[0].Secret) != "first-secret" ||
+		keys[1].ID != "k2" || string(keys[1].Secret) != "second:secret" {
+		t.Fatalf("ParseKeys = %+v", keys)
+	}
+
+	if _, err := ParseKeys("no-separator"); err == nil {
+		t.Fatal("expected an error for a key without a secret")
+	}
+}
@@ -87,7 +87,7 @@ func insertOTA(db *sql.DB, ota entity.OTA) {
 	query := `
 		INSERT INTO otas (id, app_id, version_name, version_code, release_notes, url, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (app_id, version_code) DO UPDATE SET
+		ON CONFLICT (app_id, payload_type, version_code) DO UPDATE SET
 			version_name = EXCLUDED.version_name,
 			release_notes = EXCLUDED.release_notes,
 			url = EXCLUDED.url,